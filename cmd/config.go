@@ -15,10 +15,13 @@
 package cmd
 
 import (
+	"encoding/csv"
 	"errors"
 	"fmt"
 	"os"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/in-toto/go-witness/log"
 	"github.com/in-toto/witness/options"
@@ -27,72 +30,419 @@ import (
 	"github.com/spf13/viper"
 )
 
+// configValue is a value the config file sets for a flag of a command
+type configValue struct {
+	// key is the dotted path of the value in the config file, e.g. run.attestor.secretscan.fail-on-detection
+	key   string
+	flag  *pflag.Flag
+	value any
+}
+
+// Where a flag got its value from, in order of precedence
+const (
+	sourceFlag    = "flag"
+	sourceEnv     = "env"
+	sourceConfig  = "config"
+	sourceDefault = "default"
+)
+
+// readsConfigAnnotation marks commands that read the config file themselves, such as witness config validate, which
+// reports every problem of the file rather than failing on the first one like loading it for a command does
+const readsConfigAnnotation = "witness.dev/reads-config"
+
+// envPrefix starts the name of every environment variable witness reads flags from
+const envPrefix = "WITNESS"
+
+// flagSource records where a flag got its value from. key is the environment variable or config key it was read
+// from.
+type flagSource struct {
+	source string
+	key    string
+}
+
+func (fs flagSource) String() string {
+	if fs.key == "" {
+		return fs.source
+	}
+
+	return fmt.Sprintf("%s (%s)", fs.source, fs.key)
+}
+
+// initConfig sets the flags of the command being run that weren't given on the command line, first from the
+// environment and then from the config file. The global flags can only be set from the environment, since they
+// choose the config file.
 func initConfig(rootCmd *cobra.Command, rootOptions *options.RootOptions) error {
-	v := viper.New()
+	if err := applyEnv(rootCmd.PersistentFlags(), rootCmd.PersistentFlags(), nil, map[string]flagSource{}); err != nil {
+		return err
+	}
+
+	if cmd, err := executingCommand(rootCmd); err == nil && cmd.Annotations[readsConfigAnnotation] != "" {
+		return nil
+	}
+
+	layers, err := readConfigFile(rootCmd, rootOptions)
+	if err != nil {
+		return err
+	}
+
+	if layers != nil {
+		log.Infof("Using config file: %v", rootOptions.Config)
+	}
+
+	values, err := mergeConfigLayers(rootCmd, layers, rootOptions.Profile)
+	if err != nil {
+		return err
+	}
+
+	// only the command being run is configured, the others were checked for unknown keys above
+	cmd, err := executingCommand(rootCmd)
+	if err != nil {
+		return nil
+	}
+
+	_, err = resolveFlags(cmd, values[cmd])
+	return err
+}
+
+// readConfigFile reads the layers of the config file and the files it includes, returning none if no config file was
+// given
+func readConfigFile(rootCmd *cobra.Command, rootOptions *options.RootOptions) ([]configLayer, error) {
 	if _, err := os.Stat(rootOptions.Config); errors.Is(err, os.ErrNotExist) {
 		if rootCmd.Flags().Lookup("config").Changed {
-			return fmt.Errorf("config file %s does not exist", rootOptions.Config)
+			return nil, fmt.Errorf("config file %s does not exist", rootOptions.Config)
 		} else {
 			// This is the deprecated behavior of loading this file by default, error out now
 			if _, err := os.Stat(".witness.yaml"); err == nil {
-				return fmt.Errorf("default use of .witness.yaml is deprecated, please specify the config file with --config or -c")
+				return nil, fmt.Errorf("default use of .witness.yaml is deprecated, please specify the config file with --config or -c")
 			}
 		}
 
+		if rootOptions.Profile != "" {
+			return nil, fmt.Errorf("profile %s was selected without a config file", rootOptions.Profile)
+		}
+
 		log.Debug("No config file found, using command line arguments")
-		return nil
+		return nil, nil
 	}
 
-	v.SetConfigFile(rootOptions.Config)
-	if v.ConfigFileUsed() != "" {
-		log.Infof("Using config file: %v", v.ConfigFileUsed())
-	}
+	return loadConfigLayers(rootOptions.Config)
+}
 
+// loadConfigFile reads the settings of the config file at path
+func loadConfigFile(path string) (map[string]any, error) {
+	v := viper.New()
+	v.SetConfigFile(path)
 	if err := v.ReadInConfig(); err != nil {
-		return fmt.Errorf("failed to read config file: %w", err)
+		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
 
-	//Currently we do not accept configuration for root commands
-	commands := rootCmd.Commands()
-	for _, cm := range commands {
-		//Check which command we are running
-		if !contains(os.Args, cm.Name()) {
-			continue
+	return v.AllSettings(), nil
+}
+
+// describeUnknownKeys adds the closest valid key to each unknown key that looks like a misspelling of one. prefix is
+// the path of the section holding the keys, such as the profile they are in.
+func describeUnknownKeys(rootCmd *cobra.Command, prefix string, unknown []string) []string {
+	keys := configKeys(rootCmd)
+	described := make([]string, 0, len(unknown))
+	for _, key := range unknown {
+		if suggestion := suggest(strings.TrimPrefix(key, prefix), keys); suggestion != "" {
+			key = fmt.Sprintf("%s (did you mean %s%s?)", key, prefix, suggestion)
 		}
 
-		flags := cm.Flags()
-		flags.VisitAll(func(f *pflag.Flag) {
-			configKey := fmt.Sprintf("%s.%s", cm.Name(), f.Name)
-			if !f.Changed {
-				if f.Value.Type() == "stringSlice" {
-					configValue := v.GetStringSlice(configKey)
-					if len(configValue) > 0 {
-						configValueStr := strings.Join(configValue, ",")
-						if err := flags.Set(f.Name, configValueStr); err != nil {
-							log.Errorf("failed to set config value: %w", err)
-						}
-					}
-				} else {
-					configValue := v.GetString(configKey)
-					if configValue != "" {
-						if err := flags.Set(f.Name, configValue); err != nil {
-							log.Errorf("failed to set config value: %w", err)
-						}
-					}
-				}
-			}
+		described = append(described, key)
+	}
+
+	return described
+}
+
+// configKeys returns the sections of every command and the keys of their flags
+func configKeys(cmd *cobra.Command) []string {
+	keys := []string{}
+	if cmd.HasParent() {
+		section := strings.Join(commandPath(cmd), ".")
+		keys = append(keys, section)
+		cmd.LocalFlags().VisitAll(func(flag *pflag.Flag) {
+			keys = append(keys, section+"."+flag.Name)
 		})
 	}
 
+	for _, sub := range cmd.Commands() {
+		keys = append(keys, configKeys(sub)...)
+	}
+
+	return keys
+}
+
+// resolveFlags sets the flags of the command that weren't given on the command line, from the environment and then
+// from the config values, so flags take precedence over the environment, which takes precedence over the config file.
+// It returns where each flag of the command got its value from.
+func resolveFlags(cmd *cobra.Command, values []configValue) (map[string]flagSource, error) {
+	sources := map[string]flagSource{}
+	cmd.LocalFlags().VisitAll(func(flag *pflag.Flag) {
+		sources[flag.Name] = flagSource{source: sourceDefault}
+		if flag.Changed {
+			sources[flag.Name] = flagSource{source: sourceFlag}
+		}
+	})
+
+	if err := applyEnv(cmd.LocalFlags(), cmd.Flags(), commandPath(cmd), sources); err != nil {
+		return nil, err
+	}
+
+	if err := applyConfigValues(cmd, values, sources); err != nil {
+		return nil, err
+	}
+
+	return sources, nil
+}
+
+// commandPath returns the names of the command and its parents, leaving out the root command
+func commandPath(cmd *cobra.Command) []string {
+	path := []string{}
+	for c := cmd; c.HasParent(); c = c.Parent() {
+		path = append([]string{c.Name()}, path...)
+	}
+
+	return path
+}
+
+// envNames returns the environment variables a flag of the command at path is read from, in order of precedence:
+// WITNESS_<COMMAND>_<FLAG> for the command alone, then WITNESS_<FLAG> for every command with the flag. The command
+// path and flag name are upper cased with - replaced by _, so --signer-kms-ref of witness run is read from
+// WITNESS_RUN_SIGNER_KMS_REF, then WITNESS_SIGNER_KMS_REF.
+func envNames(path []string, flagName string) []string {
+	name := func(parts ...string) string {
+		return strings.ToUpper(strings.ReplaceAll(strings.Join(append([]string{envPrefix}, parts...), "_"), "-", "_"))
+	}
+
+	if len(path) == 0 {
+		return []string{name(flagName)}
+	}
+
+	return []string{name(append(append([]string{}, path...), flagName)...), name(flagName)}
+}
+
+// applyEnv sets the unchanged flags in flags from their environment variables through viper, setting them on target
+// so they count as changed for the command. Slice and array flags take comma separated values, which can be quoted
+// like in a CSV file to contain commas.
+func applyEnv(flags, target *pflag.FlagSet, path []string, sources map[string]flagSource) error {
+	v := viper.New()
+	errs := []error{}
+	flags.VisitAll(func(flag *pflag.Flag) {
+		if flag.Changed {
+			return
+		}
+
+		names := envNames(path, flag.Name)
+		if err := v.BindEnv(append([]string{flag.Name}, names...)...); err != nil {
+			errs = append(errs, err)
+			return
+		}
+
+		if !v.IsSet(flag.Name) {
+			return
+		}
+
+		// viper doesn't say which of the variables it read, the first one set is the one it used
+		env := names[0]
+		for _, name := range names {
+			if os.Getenv(name) != "" {
+				env = name
+				break
+			}
+		}
+
+		if err := setFromEnv(target, flag, v.GetString(flag.Name)); err != nil {
+			errs = append(errs, fmt.Errorf("failed to set --%s from %s: %w", flag.Name, env, err))
+			return
+		}
+
+		sources[flag.Name] = flagSource{source: sourceEnv, key: env}
+	})
+
+	return errors.Join(errs...)
+}
+
+// setFromEnv sets the flag on target from the value of its environment variable. Slice flags split the value on commas
+// themselves, array flags take each value as is, so the value is split for them.
+func setFromEnv(target *pflag.FlagSet, flag *pflag.Flag, value string) error {
+	if flag.Value.Type() != "stringArray" {
+		return target.Set(flag.Name, value)
+	}
+
+	items, err := csv.NewReader(strings.NewReader(value)).Read()
+	if err != nil {
+		return fmt.Errorf("failed to split %q into a list: %w", value, err)
+	}
+
+	for _, item := range items {
+		if err := target.Set(flag.Name, item); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
-func contains(s []string, str string) bool {
-	for _, v := range s {
-		if v == str {
-			return true
+// executingCommand finds the command os.Args runs. Config is read before cobra has picked the command to run.
+func executingCommand(rootCmd *cobra.Command) (*cobra.Command, error) {
+	if len(os.Args) < 2 {
+		return rootCmd, nil
+	}
+
+	cmd, _, err := rootCmd.Find(os.Args[1:])
+	return cmd, err
+}
+
+// walkConfig walks the settings of a config file along the command tree, matching each key to the flag of the
+// command it configures. Sections named after a subcommand configure the subcommand, so `policy: {check: {...}}`
+// configures witness policy check. Within a command's section nested keys are joined with - to form the flag name,
+// so `attestor: {secretscan: {fail-on-detection: true}}` sets --attestor-secretscan-fail-on-detection. Keys that
+// match neither a command nor a flag are added to unknown.
+func walkConfig(rootCmd, cmd *cobra.Command, keyPrefix, flagPrefix string, settings map[string]any, values map[*cobra.Command][]configValue, unknown *[]string) {
+	for name, value := range settings {
+		key := keyPrefix + name
+		section, isSection := value.(map[string]any)
+		if flagPrefix == "" && (isSection || value == nil) {
+			// an empty section, such as one with every key commented out, configures nothing
+			if sub := subcommand(cmd, name); sub != nil {
+				walkConfig(rootCmd, sub, key+".", "", section, values, unknown)
+				continue
+			}
 		}
+
+		// the root command's flags aren't configurable, its sections are the commands
+		var flag *pflag.Flag
+		if cmd != rootCmd {
+			flag = lookupFlag(cmd, flagPrefix+name)
+		}
+
+		switch {
+		case flag != nil && (!isSection || isMapFlag(flag)):
+			values[cmd] = append(values[cmd], configValue{key: key, flag: flag, value: value})
+		case isSection && cmd != rootCmd:
+			walkConfig(rootCmd, cmd, key+".", flagPrefix+name+"-", section, values, unknown)
+		default:
+			*unknown = append(*unknown, key)
+		}
+	}
+}
+
+func subcommand(cmd *cobra.Command, name string) *cobra.Command {
+	for _, sub := range cmd.Commands() {
+		if strings.EqualFold(sub.Name(), name) {
+			return sub
+		}
+
+		for _, alias := range sub.Aliases {
+			if strings.EqualFold(alias, name) {
+				return sub
+			}
+		}
+	}
+
+	return nil
+}
+
+// lookupFlag finds a flag of the command by name, ignoring case since viper lower cases the keys it reads
+func lookupFlag(cmd *cobra.Command, name string) *pflag.Flag {
+	if flag := cmd.LocalFlags().Lookup(name); flag != nil {
+		return flag
+	}
+
+	var found *pflag.Flag
+	cmd.LocalFlags().VisitAll(func(flag *pflag.Flag) {
+		if found == nil && strings.EqualFold(flag.Name, name) {
+			found = flag
+		}
+	})
+
+	return found
+}
+
+func isMapFlag(flag *pflag.Flag) bool {
+	return strings.HasPrefix(flag.Value.Type(), "stringTo")
+}
+
+// applyConfigValues sets the unchanged flags of the command from the config file
+func applyConfigValues(cmd *cobra.Command, values []configValue, sources map[string]flagSource) error {
+	sort.Slice(values, func(i, j int) bool { return values[i].key < values[j].key })
+	for _, cv := range values {
+		if cv.flag.Changed {
+			continue
+		}
+
+		if err := setFlag(cmd.Flags(), cv.flag, cv.value); err != nil {
+			return fmt.Errorf("failed to set --%s from config key %s: %w", cv.flag.Name, cv.key, err)
+		}
+
+		sources[cv.flag.Name] = flagSource{source: sourceConfig, key: cv.key}
+	}
+
+	return nil
+}
+
+// setFlag sets a flag of any pflag type from a value read from the config file. Lists set every item of slice and
+// array flags, maps set the entries of map flags.
+func setFlag(flags *pflag.FlagSet, flag *pflag.Flag, value any) error {
+	switch v := value.(type) {
+	case []any:
+		sliceValue, ok := flag.Value.(pflag.SliceValue)
+		if !ok {
+			return fmt.Errorf("expected a single %s value, got a list", flag.Value.Type())
+		}
+
+		// an empty list clears the flag's default
+		if len(v) == 0 {
+			current := sliceValue.GetSlice()
+			if len(current) == 0 {
+				flag.Changed = true
+				return nil
+			}
+
+			if err := flags.Set(flag.Name, current[0]); err != nil {
+				return err
+			}
+
+			return sliceValue.Replace([]string{})
+		}
+
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			items = append(items, configString(item))
+		}
+
+		// Set marks the flag as changed, Replace then sets the items without splitting them on commas
+		if err := flags.Set(flag.Name, items[0]); err != nil {
+			return err
+		}
+
+		return sliceValue.Replace(items)
+	case map[string]any:
+		if !isMapFlag(flag) {
+			return fmt.Errorf("expected a %s value, got a map", flag.Value.Type())
+		}
+
+		entries := make([]string, 0, len(v))
+		for k, item := range v {
+			entries = append(entries, k+"="+configString(item))
+		}
+
+		sort.Strings(entries)
+		return flags.Set(flag.Name, strings.Join(entries, ","))
+	case nil:
+		return nil
+	default:
+		return flags.Set(flag.Name, configString(v))
+	}
+}
+
+// configString formats a scalar from the config file the way it would be passed on the command line. YAML decodes
+// unquoted timestamps into a time.Time, which is formatted as RFC3339 for flags such as --verify-time.
+func configString(value any) string {
+	if t, ok := value.(time.Time); ok {
+		return t.Format(time.RFC3339Nano)
 	}
 
-	return false
+	return fmt.Sprint(value)
 }
@@ -0,0 +1,191 @@
+// Copyright 2026 The Witness Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/in-toto/witness/options"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+func ConfigCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Create, validate and inspect the witness configuration",
+		Long:  "Create and validate witness config files, and inspect how witness resolves its configuration from flags, WITNESS_* environment variables and the config file",
+	}
+
+	cmd.AddCommand(ConfigInitCmd())
+	cmd.AddCommand(ConfigValidateCmd())
+	cmd.AddCommand(ConfigShowCmd())
+
+	return cmd
+}
+
+func ConfigInitCmd() *cobra.Command {
+	cio := options.ConfigInitOptions{}
+	cmd := &cobra.Command{
+		Use:               "init",
+		Short:             "Write a config file template",
+		Long:              "Writes a commented config file template with sections for witness run, sign and verify. The template holds the options of the selected attestors and signers, commented out with their defaults",
+		Example:           "  witness config init -o witness.yaml --attestors environment,git,slsa --signers kms",
+		Args:              cobra.NoArgs,
+		SilenceErrors:     true,
+		SilenceUsage:      true,
+		DisableAutoGenTag: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runConfigInit(cmd.Root(), cio)
+		},
+	}
+
+	cio.AddFlags(cmd)
+	return cmd
+}
+
+func ConfigValidateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:               "validate [config file]",
+		Short:             "Check a config file for unknown keys and invalid values",
+		Long:              "Checks every key of a config file against the flags of the witness commands, reporting unknown or misspelled keys and values that don't fit the type of their flag. Validates the file given with --config unless one is given as an argument",
+		Args:              cobra.MaximumNArgs(1),
+		SilenceErrors:     true,
+		SilenceUsage:      true,
+		DisableAutoGenTag: true,
+		Annotations:       map[string]string{readsConfigAnnotation: "true"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path, err := cmd.Flags().GetString("config")
+			if err != nil {
+				return err
+			}
+
+			if len(args) > 0 {
+				path = args[0]
+			}
+
+			return runConfigValidate(cmd.OutOrStdout(), cmd.Root(), path)
+		},
+	}
+	return cmd
+}
+
+func ConfigShowCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:               "show <command>",
+		Short:             "Show the resolved configuration of a command",
+		Long:              "Prints the value every flag of a command resolves to from WITNESS_* environment variables, the config file and the defaults, along with where the value came from. Nested commands are given as separate arguments, e.g. witness config show policy check",
+		Example:           "  witness -c witness.yaml config show run",
+		Args:              cobra.MinimumNArgs(1),
+		SilenceErrors:     true,
+		SilenceUsage:      true,
+		DisableAutoGenTag: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runConfigShow(cmd.OutOrStdout(), cmd.Root(), ro, args)
+		},
+	}
+	return cmd
+}
+
+// configTarget finds the command the config subcommands were asked about
+func configTarget(rootCmd *cobra.Command, args []string) (*cobra.Command, error) {
+	target, rest, err := rootCmd.Find(args)
+	if err != nil || target == rootCmd || len(rest) > 0 {
+		return nil, fmt.Errorf("unknown command %q", strings.Join(args, " "))
+	}
+
+	return target, nil
+}
+
+func runConfigShow(w io.Writer, rootCmd *cobra.Command, rootOptions *options.RootOptions, args []string) error {
+	target, err := configTarget(rootCmd, args)
+	if err != nil {
+		return err
+	}
+
+	layers, err := readConfigFile(rootCmd, rootOptions)
+	if err != nil {
+		return err
+	}
+
+	values, err := mergeConfigLayers(rootCmd, layers, rootOptions.Profile)
+	if err != nil {
+		return err
+	}
+
+	sources, err := resolveFlags(target, values[target])
+	if err != nil {
+		return err
+	}
+
+	items := [][]string{}
+	target.LocalFlags().VisitAll(func(flag *pflag.Flag) {
+		if flag.Hidden {
+			return
+		}
+
+		items = append(items, []string{flag.Name, displayValue(flag), sources[flag.Name].String()})
+	})
+
+	table := tablewriter.NewWriter(w)
+	table.Header([]string{"Flag", "Value", "Source"})
+	if err := table.Bulk(items); err != nil {
+		return fmt.Errorf("failed to add items to table: %w", err)
+	}
+
+	if err := table.Render(); err != nil {
+		return fmt.Errorf("failed to render table: %w", err)
+	}
+
+	return nil
+}
+
+// secretFlagSuffixes end the names of flags that take a secret directly, such as --signer-vault-token. Flags that
+// take the path of a secret, such as --signer-fulcio-token-path, end in -path or -file and are shown.
+var secretFlagSuffixes = []string{"-token", "-passphrase", "-password", "-secret", "-pin"}
+
+// secretFlags are flags whose values may hold secrets without their name saying so
+var secretFlags = map[string]bool{
+	// headers commonly carry an Authorization bearer token
+	"archivista-headers": true,
+}
+
+func isSecretFlag(flag *pflag.Flag) bool {
+	if secretFlags[flag.Name] {
+		return true
+	}
+
+	for _, suffix := range secretFlagSuffixes {
+		if strings.HasSuffix(flag.Name, suffix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// displayValue returns the value of a flag to print, redacting secrets that are set since config show output ends
+// up in CI logs
+func displayValue(flag *pflag.Flag) string {
+	value := flag.Value.String()
+	if !isSecretFlag(flag) || value == "" || value == "[]" {
+		return value
+	}
+
+	return "<redacted>"
+}
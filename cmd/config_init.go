@@ -0,0 +1,199 @@
+// Copyright 2026 The Witness Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/in-toto/go-witness/attestation"
+	"github.com/in-toto/go-witness/log"
+	"github.com/in-toto/go-witness/signer"
+	"github.com/in-toto/witness/internal/stdio"
+	"github.com/in-toto/witness/options"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// configTemplateCommands are the commands witness config init writes a section for
+var configTemplateCommands = []string{"run", "sign", "verify"}
+
+// runConfigInit writes a config template for the selected attestors and signers
+func runConfigInit(rootCmd *cobra.Command, cio options.ConfigInitOptions) error {
+	if err := checkNames("attestor", cio.Attestors, attestorNames()); err != nil {
+		return err
+	}
+
+	if err := checkNames("signer", cio.Signers, providerNames()); err != nil {
+		return err
+	}
+
+	if cio.OutFilePath != "" && !stdio.IsStdio(cio.OutFilePath) && !cio.Force {
+		if _, err := os.Stat(cio.OutFilePath); !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("%s already exists, use --force to overwrite it", cio.OutFilePath)
+		}
+	}
+
+	outFile, err := loadOutfile(cio.OutFilePath)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err := outFile.Close(); err != nil {
+			log.Errorf("failed to write config template to disk: %v", err)
+		}
+	}()
+
+	return writeConfigTemplate(outFile, rootCmd, cio.Attestors, cio.Signers)
+}
+
+func attestorNames() []string {
+	names := []string{}
+	for _, entry := range attestation.RegistrationEntries() {
+		names = append(names, entry.Name)
+	}
+
+	return names
+}
+
+// providerNames returns the names of the registered signer and verifier providers
+func providerNames() []string {
+	names := []string{}
+	for _, entry := range signer.RegistryEntries() {
+		names = append(names, entry.Name)
+	}
+
+	for _, entry := range signer.VerifierRegistryEntries() {
+		names = append(names, entry.Name)
+	}
+
+	return names
+}
+
+// checkNames fails if any of names isn't one of the known names, suggesting the closest known name
+func checkNames(kind string, names, known []string) error {
+	for _, name := range names {
+		found := false
+		for _, k := range known {
+			if name == k {
+				found = true
+				break
+			}
+		}
+
+		if found {
+			continue
+		}
+
+		if suggestion := suggest(name, known); suggestion != "" {
+			return fmt.Errorf("unknown %s %q, did you mean %q?", kind, name, suggestion)
+		}
+
+		return fmt.Errorf("unknown %s %q", kind, name)
+	}
+
+	return nil
+}
+
+// writeConfigTemplate writes a config file with a section for each of configTemplateCommands. Every key is commented
+// out with its default value, except the attestors run records. Flags of attestors and providers that weren't
+// selected are left out.
+func writeConfigTemplate(w io.Writer, rootCmd *cobra.Command, attestors, signers []string) error {
+	b := &strings.Builder{}
+	b.WriteString("# Witness configuration generated by witness config init.\n")
+	b.WriteString("# Every key is commented out with its default value. Uncomment the keys to set, then check the file with\n")
+	b.WriteString("# witness config validate and pass it to witness with --config.\n")
+
+	providers := providerNames()
+	for _, name := range configTemplateCommands {
+		cmd, err := configTarget(rootCmd, []string{name})
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(b, "\n%s:\n", name)
+		cmd.LocalFlags().VisitAll(func(flag *pflag.Flag) {
+			if flag.Hidden || flag.Deprecated != "" || !templateFlag(flag.Name, attestors, signers, providers) {
+				return
+			}
+
+			fmt.Fprintf(b, "  # %s\n", strings.ReplaceAll(flag.Usage, "\n", " "))
+			if name == "run" && flag.Name == "attestations" {
+				fmt.Fprintf(b, "  %s: %s\n", flag.Name, yamlList(attestors))
+				return
+			}
+
+			fmt.Fprintf(b, "  # %s: %s\n", flag.Name, templateValue(flag))
+		})
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// templateFlag reports whether a flag belongs in the template. Attestor flags are kept for the selected attestors,
+// signer and verifier flags for the selected providers. Other flags, such as --signer-strict, are always kept.
+func templateFlag(name string, attestors, signers, providers []string) bool {
+	hasPrefix := func(kind string, names []string) bool {
+		for _, n := range names {
+			if strings.HasPrefix(name, kind+"-"+n+"-") {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	switch {
+	case strings.HasPrefix(name, "attestor-"):
+		return hasPrefix("attestor", attestors)
+	case strings.HasPrefix(name, "signer-") && hasPrefix("signer", providers):
+		return hasPrefix("signer", signers)
+	case strings.HasPrefix(name, "verifier-") && hasPrefix("verifier", providers):
+		return hasPrefix("verifier", signers)
+	default:
+		return true
+	}
+}
+
+// templateValue renders the default value of a flag as YAML
+func templateValue(flag *pflag.Flag) string {
+	if sliceValue, ok := flag.Value.(pflag.SliceValue); ok {
+		return yamlList(sliceValue.GetSlice())
+	}
+
+	switch {
+	case flag.Value.Type() == "string":
+		return strconv.Quote(flag.DefValue)
+	case isMapFlag(flag):
+		return "{}"
+	default:
+		return flag.DefValue
+	}
+}
+
+func yamlList(items []string) string {
+	quoted := make([]string, 0, len(items))
+	for _, item := range items {
+		quoted = append(quoted, strconv.Quote(item))
+	}
+
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
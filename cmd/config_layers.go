@@ -0,0 +1,268 @@
+// Copyright 2026 The Witness Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// Top level keys of a config file that don't configure a command
+const (
+	includeKey  = "include"
+	profilesKey = "profiles"
+)
+
+// configLayer is part of the configuration read from a config file: either the base settings of the file or one of
+// the profiles it defines
+type configLayer struct {
+	path string
+	// included is set for layers read from a file included by another config file
+	included bool
+	// profile names the profile the layer defines, it is empty for the base settings
+	profile  string
+	settings map[string]any
+}
+
+// keyPrefix is the dotted path of the layer's settings in its config file
+func (l configLayer) keyPrefix() string {
+	if l.profile == "" {
+		return ""
+	}
+
+	return profilesKey + "." + l.profile + "."
+}
+
+// describe names the key of the layer the way problems with it are reported, with the file it came from if it
+// was included by another config file
+func (l configLayer) describe(key string) string {
+	if l.included {
+		return fmt.Sprintf("%s: %s", l.path, key)
+	}
+
+	return key
+}
+
+// loadConfigLayers reads the config file at path and the files it includes. The layers of included files come
+// first, in the order they are included, followed by the base settings of the file and then its profiles.
+func loadConfigLayers(path string) ([]configLayer, error) {
+	return loadIncludedLayers(path, false, nil)
+}
+
+func loadIncludedLayers(path string, included bool, including []string) ([]configLayer, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve config file %s: %w", path, err)
+	}
+
+	for _, p := range including {
+		if p == absPath {
+			return nil, fmt.Errorf("config file %s includes itself", path)
+		}
+	}
+
+	settings, err := loadConfigFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	includes, err := includePaths(path, settings[includeKey])
+	if err != nil {
+		return nil, err
+	}
+
+	profiles, ok := settings[profilesKey].(map[string]any)
+	if !ok && settings[profilesKey] != nil {
+		return nil, fmt.Errorf("failed to read profiles of config file %s: expected a map of profile names to settings", path)
+	}
+
+	delete(settings, includeKey)
+	delete(settings, profilesKey)
+
+	layers := []configLayer{}
+	for _, include := range includes {
+		includedLayers, err := loadIncludedLayers(include, true, append(including, absPath))
+		if err != nil {
+			return nil, err
+		}
+
+		layers = append(layers, includedLayers...)
+	}
+
+	layers = append(layers, configLayer{path: path, included: included, settings: settings})
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	for _, name := range names {
+		profileSettings, ok := profiles[name].(map[string]any)
+		if !ok && profiles[name] != nil {
+			return nil, fmt.Errorf("failed to read profile %s of config file %s: expected a map of settings", name, path)
+		}
+
+		layers = append(layers, configLayer{path: path, included: included, profile: name, settings: profileSettings})
+	}
+
+	return layers, nil
+}
+
+// includePaths returns the files a config file includes, which are given as a single path or a list of paths.
+// Relative paths are relative to the directory of the including file.
+func includePaths(path string, include any) ([]string, error) {
+	var paths []string
+	switch v := include.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		paths = []string{v}
+	case []any:
+		for _, item := range v {
+			p, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("failed to read includes of config file %s: expected a path, got %v", path, item)
+			}
+
+			paths = append(paths, p)
+		}
+	default:
+		return nil, fmt.Errorf("failed to read includes of config file %s: expected a path or a list of paths", path)
+	}
+
+	for i, p := range paths {
+		if !filepath.IsAbs(p) {
+			paths[i] = filepath.Join(filepath.Dir(path), p)
+		}
+	}
+
+	return paths, nil
+}
+
+// layerValues matches the settings of a layer to the flags they set, returning the keys that are unknown
+func layerValues(rootCmd *cobra.Command, layer configLayer) (map[*cobra.Command][]configValue, []string) {
+	values := map[*cobra.Command][]configValue{}
+	unknown := []string{}
+	walkConfig(rootCmd, rootCmd, layer.keyPrefix(), "", layer.settings, values, &unknown)
+	sort.Strings(unknown)
+	for _, cmdValues := range values {
+		for i := range cmdValues {
+			cmdValues[i].key = layer.describe(cmdValues[i].key)
+		}
+	}
+
+	return values, unknown
+}
+
+// profileNames returns the names of the profiles the layers define
+func profileNames(layers []configLayer) []string {
+	names := []string{}
+	for _, layer := range layers {
+		if layer.profile != "" {
+			names = append(names, layer.profile)
+		}
+	}
+
+	return names
+}
+
+// mergeConfigLayers matches the settings of the layers to the flags they set, failing on unknown keys in any layer.
+// The base settings of every file apply in order, each overriding the flags set before it, and the layers of the
+// selected profile apply over them. Profiles are matched ignoring case, since viper lower cases the keys it reads.
+func mergeConfigLayers(rootCmd *cobra.Command, layers []configLayer, profile string) (map[*cobra.Command][]configValue, error) {
+	profile = strings.ToLower(profile)
+	if profile != "" {
+		names := profileNames(layers)
+		found := false
+		for _, name := range names {
+			found = found || name == profile
+		}
+
+		if !found {
+			if suggestion := suggest(profile, names); suggestion != "" {
+				return nil, fmt.Errorf("profile %s is not defined in the config file, did you mean %s?", profile, suggestion)
+			}
+
+			return nil, fmt.Errorf("profile %s is not defined in the config file", profile)
+		}
+	}
+
+	// the layers of the selected profile apply after every base layer
+	ordered := []configLayer{}
+	for _, layer := range layers {
+		if layer.profile == "" {
+			ordered = append(ordered, layer)
+		}
+	}
+
+	for _, layer := range layers {
+		if profile != "" && layer.profile == profile {
+			ordered = append(ordered, layer)
+		}
+	}
+
+	paths := []string{}
+	unknownKeys := map[string][]string{}
+	for _, layer := range layers {
+		_, unknown := layerValues(rootCmd, layer)
+		if len(unknown) == 0 {
+			continue
+		}
+
+		if _, ok := unknownKeys[layer.path]; !ok {
+			paths = append(paths, layer.path)
+		}
+
+		unknownKeys[layer.path] = append(unknownKeys[layer.path], describeUnknownKeys(rootCmd, layer.keyPrefix(), unknown)...)
+	}
+
+	errs := []error{}
+	for _, path := range paths {
+		errs = append(errs, fmt.Errorf("unknown keys in config file %s: %s", path, strings.Join(unknownKeys[path], ", ")))
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	merged := map[*cobra.Command]map[*pflag.Flag]configValue{}
+	for _, layer := range ordered {
+		values, _ := layerValues(rootCmd, layer)
+		for cmd, cmdValues := range values {
+			if merged[cmd] == nil {
+				merged[cmd] = map[*pflag.Flag]configValue{}
+			}
+
+			for _, cv := range cmdValues {
+				merged[cmd][cv.flag] = cv
+			}
+		}
+	}
+
+	values := map[*cobra.Command][]configValue{}
+	for cmd, flags := range merged {
+		for _, cv := range flags {
+			values[cmd] = append(values[cmd], cv)
+		}
+	}
+
+	return values, nil
+}
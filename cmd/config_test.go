@@ -15,9 +15,12 @@
 package cmd
 
 import (
+	"bytes"
 	"os"
 	"path/filepath"
+	"regexp"
 	"testing"
+	"time"
 
 	"github.com/in-toto/witness/options"
 	"github.com/spf13/cobra"
@@ -187,3 +190,534 @@ run:
 		})
 	}
 }
+
+// configTree returns a witness command tree with the config flag set to a file holding configYaml
+func configTree(t *testing.T, configYaml string) (*cobra.Command, *options.RootOptions) {
+	configPath := filepath.Join(t.TempDir(), "witness.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte(configYaml), 0644))
+
+	rootCmd := &cobra.Command{Use: "witness"}
+	rootOptions := &options.RootOptions{}
+	rootOptions.AddFlags(rootCmd)
+	rootCmd.AddCommand(RunCmd(), SignCmd(), VerifyCmd(), PolicyCmd(), AttestorsCmd())
+	require.NoError(t, rootCmd.PersistentFlags().Set("config", configPath))
+	return rootCmd, rootOptions
+}
+
+func withArgs(t *testing.T, args ...string) {
+	oldArgs := os.Args
+	os.Args = append([]string{"witness"}, args...)
+	t.Cleanup(func() { os.Args = oldArgs })
+}
+
+func Test_initConfigFlagTypes(t *testing.T) {
+	rootCmd, rootOptions := configTree(t, `
+run:
+  step: build
+  trace: true
+  archivista-headers:
+    - "Authorization: Bearer a,b"
+    - "X-Team: release"
+  attestor:
+    secretscan:
+      fail-on-detection: true
+      max-file-size-mb: 20
+  signer:
+    vault:
+      ttl: 30m
+verify:
+  cache-ttl: 2h
+`)
+	withArgs(t, "run")
+
+	runCmd, _, err := rootCmd.Find([]string{"run"})
+	require.NoError(t, err)
+	require.NoError(t, runCmd.Flags().Set("step", "from-flag"))
+	require.NoError(t, initConfig(rootCmd, rootOptions))
+
+	flags := runCmd.Flags()
+	step, _ := flags.GetString("step")
+	assert.Equal(t, "from-flag", step, "flags given on the command line take precedence")
+	trace, _ := flags.GetBool("trace")
+	assert.True(t, trace)
+	headers, _ := flags.GetStringArray("archivista-headers")
+	assert.Equal(t, []string{"Authorization: Bearer a,b", "X-Team: release"}, headers)
+	failOnDetection, _ := flags.GetBool("attestor-secretscan-fail-on-detection")
+	assert.True(t, failOnDetection)
+	maxFileSize, _ := flags.GetInt("attestor-secretscan-max-file-size-mb")
+	assert.Equal(t, 20, maxFileSize)
+	ttl, _ := flags.GetDuration("signer-vault-ttl")
+	assert.Equal(t, 30*time.Minute, ttl)
+	assert.True(t, flags.Lookup("signer-vault-ttl").Changed, "config values count as set for provider selection")
+
+	// only the command being run is configured
+	verifyCmd, _, err := rootCmd.Find([]string{"verify"})
+	require.NoError(t, err)
+	cacheTTL, _ := verifyCmd.Flags().GetDuration("cache-ttl")
+	assert.Equal(t, time.Hour, cacheTTL)
+}
+
+func Test_initConfigTimeFlags(t *testing.T) {
+	// YAML reads an unquoted timestamp as a time rather than a string
+	for _, value := range []string{`2024-01-01T00:00:00Z`, `"2024-01-01T00:00:00Z"`} {
+		rootCmd, rootOptions := configTree(t, `
+verify:
+  verify-time: `+value+`
+`)
+		withArgs(t, "verify")
+		require.NoError(t, initConfig(rootCmd, rootOptions))
+
+		verifyCmd, _, err := rootCmd.Find([]string{"verify"})
+		require.NoError(t, err)
+		verifyTime, _ := verifyCmd.Flags().GetTime("verify-time")
+		assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), verifyTime.UTC(), value)
+	}
+}
+
+func Test_initConfigEmptyLists(t *testing.T) {
+	rootCmd, rootOptions := configTree(t, `
+run:
+  attestations: []
+  hashes: []
+  dirhash-glob: []
+`)
+	withArgs(t, "run")
+
+	runCmd, _, err := rootCmd.Find([]string{"run"})
+	require.NoError(t, err)
+	sources, err := resolveFlagsFromConfig(t, rootCmd, rootOptions, runCmd)
+	require.NoError(t, err)
+
+	// an empty list clears the default
+	flags := runCmd.Flags()
+	attestations, _ := flags.GetStringSlice("attestations")
+	assert.Empty(t, attestations)
+	hashes, _ := flags.GetStringSlice("hashes")
+	assert.Empty(t, hashes)
+	assert.True(t, flags.Lookup("attestations").Changed)
+	assert.Equal(t, "config (run.attestations)", sources["attestations"].String())
+	assert.Equal(t, "config (run.dirhash-glob)", sources["dirhash-glob"].String())
+}
+
+// resolveFlagsFromConfig resolves the flags of cmd from the config file of the tree, returning their sources
+func resolveFlagsFromConfig(t *testing.T, rootCmd *cobra.Command, rootOptions *options.RootOptions, cmd *cobra.Command) (map[string]flagSource, error) {
+	layers, err := readConfigFile(rootCmd, rootOptions)
+	require.NoError(t, err)
+	values, err := mergeConfigLayers(rootCmd, layers, rootOptions.Profile)
+	require.NoError(t, err)
+	return resolveFlags(cmd, values[cmd])
+}
+
+func Test_initConfigNestedCommands(t *testing.T) {
+	rootCmd, rootOptions := configTree(t, `
+policy:
+  check:
+    verbose: true
+`)
+	withArgs(t, "policy", "check", "policy.json")
+	require.NoError(t, initConfig(rootCmd, rootOptions))
+
+	checkCmd, _, err := rootCmd.Find([]string{"policy", "check"})
+	require.NoError(t, err)
+	verbose, _ := checkCmd.Flags().GetBool("verbose")
+	assert.True(t, verbose)
+}
+
+func Test_initConfigMapFlags(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "witness.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte(`
+label:
+  labels:
+    team: release
+    tier: prod
+`), 0644))
+
+	rootCmd := &cobra.Command{Use: "witness"}
+	rootOptions := &options.RootOptions{}
+	rootOptions.AddFlags(rootCmd)
+	labelCmd := &cobra.Command{Use: "label"}
+	labelCmd.Flags().StringToString("labels", nil, "")
+	rootCmd.AddCommand(labelCmd)
+	require.NoError(t, rootCmd.PersistentFlags().Set("config", configPath))
+	withArgs(t, "label")
+
+	require.NoError(t, initConfig(rootCmd, rootOptions))
+	labels, _ := labelCmd.Flags().GetStringToString("labels")
+	assert.Equal(t, map[string]string{"team": "release", "tier": "prod"}, labels)
+}
+
+func Test_initConfigErrors(t *testing.T) {
+	t.Run("unknown keys", func(t *testing.T) {
+		rootCmd, rootOptions := configTree(t, `
+run:
+  stepp: build
+  attestor:
+    secretscan:
+      fail-on-detectoin: true
+sing:
+  outfile: out.json
+log-level: debug
+`)
+		withArgs(t, "run")
+		err := initConfig(rootCmd, rootOptions)
+		assert.ErrorContains(t, err, "unknown keys in config file")
+		assert.ErrorContains(t, err, "log-level, run.attestor.secretscan.fail-on-detectoin (did you mean run.attestor-secretscan-fail-on-detection?), run.stepp (did you mean run.step?), sing (did you mean sign?)")
+	})
+
+	t.Run("wrong type", func(t *testing.T) {
+		rootCmd, rootOptions := configTree(t, `
+run:
+  trace: sometimes
+`)
+		withArgs(t, "run")
+		assert.ErrorContains(t, initConfig(rootCmd, rootOptions), "failed to set --trace from config key run.trace")
+	})
+
+	t.Run("list for a single value", func(t *testing.T) {
+		rootCmd, rootOptions := configTree(t, `
+run:
+  step: [build, test]
+`)
+		withArgs(t, "run")
+		assert.ErrorContains(t, initConfig(rootCmd, rootOptions), "expected a single string value, got a list")
+	})
+}
+
+func Test_exampleConfigs(t *testing.T) {
+	for _, path := range []string{"../test/test.yaml", "../test/test-mac.yaml"} {
+		layers, err := loadConfigLayers(path)
+		require.NoError(t, err)
+
+		rootCmd, _ := configTree(t, "")
+		_, err = mergeConfigLayers(rootCmd, layers, "")
+		assert.NoError(t, err, path)
+	}
+}
+
+func Test_envNames(t *testing.T) {
+	assert.Equal(t, []string{"WITNESS_RUN_SIGNER_KMS_REF", "WITNESS_SIGNER_KMS_REF"}, envNames([]string{"run"}, "signer-kms-ref"))
+	assert.Equal(t, []string{"WITNESS_POLICY_CHECK_VERBOSE", "WITNESS_VERBOSE"}, envNames([]string{"policy", "check"}, "verbose"))
+	assert.Equal(t, []string{"WITNESS_LOG_LEVEL"}, envNames(nil, "log-level"))
+}
+
+func Test_initConfigEnv(t *testing.T) {
+	rootCmd, rootOptions := configTree(t, `
+run:
+  step: from-config
+  outfile: config.json
+  workingdir: /config
+  trace: true
+`)
+	withArgs(t, "run")
+	t.Setenv("WITNESS_LOG_LEVEL", "debug")
+	t.Setenv("WITNESS_RUN_STEP", "from-run-env")
+	t.Setenv("WITNESS_STEP", "from-env")
+	t.Setenv("WITNESS_OUTFILE", "env.json")
+	t.Setenv("WITNESS_WORKINGDIR", "/env")
+	t.Setenv("WITNESS_ATTESTATIONS", "git,environment")
+	t.Setenv("WITNESS_SIGNER_KMS_REF", "awskms:///alias/release")
+
+	runCmd, _, err := rootCmd.Find([]string{"run"})
+	require.NoError(t, err)
+	require.NoError(t, runCmd.Flags().Set("workingdir", "/flag"))
+	require.NoError(t, initConfig(rootCmd, rootOptions))
+
+	assert.Equal(t, "debug", rootOptions.LogLevel)
+	flags := runCmd.Flags()
+	get := func(name string) string { return flags.Lookup(name).Value.String() }
+	assert.Equal(t, "from-run-env", get("step"), "the command's variable takes precedence over the shared one")
+	assert.Equal(t, "env.json", get("outfile"), "the environment takes precedence over the config file")
+	assert.Equal(t, "/flag", get("workingdir"), "flags take precedence over the environment")
+	assert.Equal(t, "true", get("trace"))
+	assert.Equal(t, "[git,environment]", get("attestations"))
+	assert.Equal(t, map[string][]string{"kms": {"signer-kms-ref"}}, providersFromFlags("signer", flags))
+
+	t.Setenv("WITNESS_TRACE", "sometimes")
+	rootCmd, rootOptions = configTree(t, "")
+	assert.ErrorContains(t, initConfig(rootCmd, rootOptions), "failed to set --trace from WITNESS_TRACE")
+}
+
+func Test_initConfigEnvArrays(t *testing.T) {
+	rootCmd, rootOptions := configTree(t, "")
+	withArgs(t, "sign")
+	t.Setenv("WITNESS_SIGN_SUBJECT", "app.tar.gz=sha256:abc123,app.sig=sha256:def456")
+	require.NoError(t, initConfig(rootCmd, rootOptions))
+
+	signCmd, _, err := rootCmd.Find([]string{"sign"})
+	require.NoError(t, err)
+	subjects, err := signCmd.Flags().GetStringArray("subject")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"app.tar.gz=sha256:abc123", "app.sig=sha256:def456"}, subjects)
+
+	rootCmd, rootOptions = configTree(t, "")
+	withArgs(t, "run")
+	t.Setenv("WITNESS_ARCHIVISTA_HEADERS", `"Authorization: Bearer a,b",X-Team: release`)
+	require.NoError(t, initConfig(rootCmd, rootOptions))
+
+	runCmd, _, err := rootCmd.Find([]string{"run"})
+	require.NoError(t, err)
+	headers, err := runCmd.Flags().GetStringArray("archivista-headers")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Authorization: Bearer a,b", "X-Team: release"}, headers)
+
+	t.Setenv("WITNESS_ARCHIVISTA_HEADERS", `"Authorization: Bearer a`)
+	rootCmd, rootOptions = configTree(t, "")
+	assert.ErrorContains(t, initConfig(rootCmd, rootOptions), "failed to set --archivista-headers from WITNESS_ARCHIVISTA_HEADERS")
+}
+
+func Test_runConfigShow(t *testing.T) {
+	rootCmd, rootOptions := configTree(t, `
+run:
+  step: from-config
+  attestor:
+    secretscan:
+      fail-on-detection: true
+`)
+	t.Setenv("WITNESS_OUTFILE", "env.json")
+
+	out := &bytes.Buffer{}
+	require.NoError(t, runConfigShow(out, rootCmd, rootOptions, []string{"run"}))
+	assert.Regexp(t, `step\s+│ from-config\s+│ config \(run.step\)`, out.String())
+	assert.Regexp(t, `attestor-secretscan-fail-on-detection\s+│ true\s+│ config \(run.attestor.secretscan.fail-on-detection\)`, out.String())
+	assert.Regexp(t, `outfile\s+│ env.json\s+│ env \(WITNESS_OUTFILE\)`, out.String())
+	assert.Regexp(t, `trace\s+│ false\s+│ default`, out.String())
+
+	assert.ErrorContains(t, runConfigShow(out, rootCmd, rootOptions, []string{"nope"}), `unknown command "nope"`)
+}
+
+func Test_runConfigShowRedactsSecrets(t *testing.T) {
+	rootCmd, rootOptions := configTree(t, `
+sign:
+  signer-fulcio-token: from-config
+`)
+	t.Setenv("WITNESS_SIGNER_VAULT_TOKEN", "s3cr3t")
+	t.Setenv("WITNESS_RUN_ARCHIVISTA_HEADERS", "Authorization: Bearer abc")
+
+	out := &bytes.Buffer{}
+	require.NoError(t, runConfigShow(out, rootCmd, rootOptions, []string{"sign"}))
+	assert.NotContains(t, out.String(), "s3cr3t")
+	assert.NotContains(t, out.String(), "from-config")
+	assert.Regexp(t, `signer-vault-token\s+│ <redacted>\s+│ env \(WITNESS_SIGNER_VAULT_TOKEN\)`, out.String())
+	assert.Regexp(t, `signer-fulcio-token\s+│ <redacted>\s+│ config \(sign.signer-fulcio-token\)`, out.String())
+
+	// unset secrets and the paths of secrets are shown
+	assert.Regexp(t, `signer-fulcio-token-path\s+│\s+│ default`, out.String())
+
+	out.Reset()
+	require.NoError(t, runConfigShow(out, rootCmd, rootOptions, []string{"run"}))
+	assert.NotContains(t, out.String(), "Bearer")
+	assert.Regexp(t, `archivista-headers\s+│ <redacted>\s+│ env \(WITNESS_RUN_ARCHIVISTA_HEADERS\)`, out.String())
+}
+
+func Test_runConfigInit(t *testing.T) {
+	rootCmd, _ := configTree(t, "")
+	outPath := filepath.Join(t.TempDir(), "witness.yaml")
+	cio := options.ConfigInitOptions{
+		OutFilePath: outPath,
+		Attestors:   []string{"environment", "secretscan"},
+		Signers:     []string{"kms"},
+	}
+
+	require.NoError(t, runConfigInit(rootCmd, cio))
+	template, err := os.ReadFile(outPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(template), `attestations: ["environment", "secretscan"]`)
+	assert.Contains(t, string(template), `# attestor-secretscan-fail-on-detection: false`)
+	assert.Contains(t, string(template), `# signer-kms-ref: ""`)
+	assert.Contains(t, string(template), `# signer-strict: false`)
+	assert.NotContains(t, string(template), "signer-file-key-path")
+	assert.NotContains(t, string(template), "attestor-slsa-export")
+
+	settings, err := loadConfigFile(outPath)
+	require.NoError(t, err)
+	assert.Equal(t, []any{"environment", "secretscan"}, settings["run"].(map[string]any)["attestations"])
+	assert.NoError(t, runConfigValidate(&bytes.Buffer{}, rootCmd, outPath))
+
+	assert.ErrorContains(t, runConfigInit(rootCmd, cio), "already exists, use --force to overwrite it")
+	cio.Force = true
+	assert.NoError(t, runConfigInit(rootCmd, cio))
+
+	cio.Attestors = []string{"gti"}
+	assert.EqualError(t, runConfigInit(rootCmd, cio), `unknown attestor "gti", did you mean "git"?`)
+	cio.Attestors = []string{"git"}
+	cio.Signers = []string{"nope-nope-nope"}
+	assert.EqualError(t, runConfigInit(rootCmd, cio), `unknown signer "nope-nope-nope"`)
+}
+
+func Test_runConfigValidate(t *testing.T) {
+	rootCmd, rootOptions := configTree(t, `
+run:
+  stepp: build
+  trace: sometimes
+  attestations: [environment]
+sing:
+  outfile: policy-signed.json
+`)
+
+	out := &bytes.Buffer{}
+	err := runConfigValidate(out, rootCmd, rootOptions.Config)
+	require.ErrorContains(t, err, "has 3 problems")
+	assert.Contains(t, out.String(), "unknown key run.stepp (did you mean run.step?)\n")
+	assert.Contains(t, out.String(), "unknown key sing (did you mean sign?)\n")
+	assert.Contains(t, out.String(), `invalid value for run.trace: invalid argument "sometimes"`)
+
+	assert.ErrorContains(t, runConfigValidate(out, rootCmd, ""), "no config file to validate")
+}
+
+func Test_suggest(t *testing.T) {
+	candidates := []string{"run", "sign", "verify", "run.attestor-secretscan-fail-on-detection"}
+	assert.Equal(t, "sign", suggest("sing", candidates))
+	assert.Equal(t, "run.attestor-secretscan-fail-on-detection", suggest("run.attestor.secretscan.fail_on_detectoin", candidates))
+	assert.Equal(t, "", suggest("completely-different", candidates))
+	assert.Equal(t, "git", suggest("gti", []string{"oci", "git", "go"}))
+	assert.Equal(t, "ab", suggest("ac", []string{"bc", "ab"}))
+	// short names are only corrected by a single edit, so unrelated ones aren't matched
+	assert.Equal(t, "", suggest("xyz", []string{"aws", "gcp", "git"}))
+	assert.Equal(t, "", suggest("ab", []string{"cd"}))
+	assert.Equal(t, "environment", suggest("envrionmnet", []string{"environment", "git"}))
+	assert.Equal(t, 3, editDistance("kitten", "sitting"))
+	assert.Equal(t, 1, editDistance("detectoin", "detection"))
+}
+
+func Test_initConfigProfiles(t *testing.T) {
+	rootCmd, rootOptions := configTree(t, `
+include: shared.yaml
+run:
+  step: default
+  trace: true
+profiles:
+  build:
+    run:
+      step: build
+      attestor:
+        secretscan:
+          fail-on-detection: true
+`)
+	dir := filepath.Dir(rootOptions.Config)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "shared.yaml"), []byte(`
+run:
+  step: shared
+  archivista-server: https://archivista.example.com
+profiles:
+  build:
+    run:
+      outfile: build.json
+`), 0644))
+	withArgs(t, "run")
+	require.NoError(t, rootCmd.PersistentFlags().Set("profile", "build"))
+
+	require.NoError(t, initConfig(rootCmd, rootOptions))
+	runCmd, _, err := rootCmd.Find([]string{"run"})
+	require.NoError(t, err)
+	flags := runCmd.Flags()
+	assert.Equal(t, "build", flags.Lookup("step").Value.String())
+	assert.Equal(t, "true", flags.Lookup("trace").Value.String())
+	assert.Equal(t, "https://archivista.example.com", flags.Lookup("archivista-server").Value.String())
+	assert.Equal(t, "build.json", flags.Lookup("outfile").Value.String())
+	assert.Equal(t, "true", flags.Lookup("attestor-secretscan-fail-on-detection").Value.String())
+}
+
+func Test_runConfigShowProfiles(t *testing.T) {
+	rootCmd, rootOptions := configTree(t, `
+include: [shared.yaml]
+run:
+  step: default
+profiles:
+  build:
+    run:
+      step: build
+`)
+	dir := filepath.Dir(rootOptions.Config)
+	sharedPath := filepath.Join(dir, "shared.yaml")
+	require.NoError(t, os.WriteFile(sharedPath, []byte("run:\n  outfile: shared.json\n"), 0644))
+
+	out := &bytes.Buffer{}
+	require.NoError(t, runConfigShow(out, rootCmd, rootOptions, []string{"run"}))
+	assert.Regexp(t, `step\s+│ default\s+│ config \(run.step\)`, out.String())
+	assert.Regexp(t, `outfile\s+│ shared.json\s+│ config \(`+regexp.QuoteMeta(sharedPath)+`: run.outfile\)`, out.String())
+
+	rootCmd, _ = configTree(t, "")
+	rootOptions.Profile = "build"
+	out.Reset()
+	require.NoError(t, runConfigShow(out, rootCmd, rootOptions, []string{"run"}))
+	assert.Regexp(t, `step\s+│ build\s+│ config \(profiles.build.run.step\)`, out.String())
+}
+
+func Test_initConfigProfileErrors(t *testing.T) {
+	t.Run("unknown profile", func(t *testing.T) {
+		rootCmd, rootOptions := configTree(t, "profiles:\n  build:\n    run:\n      step: build\n")
+		withArgs(t, "run")
+		require.NoError(t, rootCmd.PersistentFlags().Set("profile", "biuld"))
+		assert.EqualError(t, initConfig(rootCmd, rootOptions), "profile biuld is not defined in the config file, did you mean build?")
+	})
+
+	t.Run("profile without config file", func(t *testing.T) {
+		rootCmd := &cobra.Command{Use: "witness"}
+		rootCmd.Flags().String("config", "", "")
+		rootCmd.AddCommand(RunCmd())
+		rootOptions := &options.RootOptions{Profile: "build"}
+		withArgs(t, "run")
+		assert.EqualError(t, initConfig(rootCmd, rootOptions), "profile build was selected without a config file")
+	})
+
+	t.Run("unknown keys in unselected profile", func(t *testing.T) {
+		rootCmd, rootOptions := configTree(t, "profiles:\n  test:\n    run:\n      stepp: test\n")
+		withArgs(t, "run")
+		assert.ErrorContains(t, initConfig(rootCmd, rootOptions), "profiles.test.run.stepp (did you mean profiles.test.run.step?)")
+	})
+
+	t.Run("include cycle", func(t *testing.T) {
+		rootCmd, rootOptions := configTree(t, "include: other.yaml\n")
+		dir := filepath.Dir(rootOptions.Config)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "other.yaml"), []byte("include: witness.yaml\n"), 0644))
+		withArgs(t, "run")
+		assert.ErrorContains(t, initConfig(rootCmd, rootOptions), "includes itself")
+	})
+
+	t.Run("missing include", func(t *testing.T) {
+		rootCmd, rootOptions := configTree(t, "include: missing.yaml\n")
+		withArgs(t, "run")
+		assert.ErrorContains(t, initConfig(rootCmd, rootOptions), "failed to read config file")
+	})
+}
+
+func Test_runConfigValidateProfiles(t *testing.T) {
+	rootCmd, rootOptions := configTree(t, `
+include: shared.yaml
+profiles:
+  build:
+    run:
+      trace: sometimes
+`)
+	dir := filepath.Dir(rootOptions.Config)
+	sharedPath := filepath.Join(dir, "shared.yaml")
+	require.NoError(t, os.WriteFile(sharedPath, []byte("sing:\n  outfile: a.json\n"), 0644))
+
+	out := &bytes.Buffer{}
+	require.ErrorContains(t, runConfigValidate(out, rootCmd, rootOptions.Config), "has 2 problems")
+	assert.Contains(t, out.String(), "unknown key "+sharedPath+": sing (did you mean sign?)\n")
+	assert.Contains(t, out.String(), `invalid value for profiles.build.run.trace: invalid argument "sometimes"`)
+}
+
+func TestConfigValidateCmdWithConfigFlag(t *testing.T) {
+	rootCmd, rootOptions := configTree(t, `
+run:
+  stepp: build
+  trace: sometimes
+`)
+	rootCmd.AddCommand(ConfigCmd())
+	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		return initConfig(rootCmd, rootOptions)
+	}
+
+	args := []string{"-c", rootOptions.Config, "config", "validate"}
+	withArgs(t, args...)
+	rootCmd.SetArgs(args)
+	out := &bytes.Buffer{}
+	rootCmd.SetOut(out)
+
+	// the config file is left to the validator, which reports every problem instead of failing on the first
+	require.ErrorContains(t, rootCmd.Execute(), "has 2 problems")
+	assert.Contains(t, out.String(), "unknown key run.stepp (did you mean run.step?)")
+	assert.Contains(t, out.String(), `invalid value for run.trace: invalid argument "sometimes"`)
+}
@@ -0,0 +1,72 @@
+// Copyright 2026 The Witness Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/spf13/cobra"
+)
+
+// runConfigValidate checks every key of the config file at path against the flags of the commands and prints the
+// problems found
+func runConfigValidate(w io.Writer, rootCmd *cobra.Command, path string) error {
+	if path == "" {
+		return fmt.Errorf("no config file to validate, pass one as an argument or with --config")
+	}
+
+	layers, err := loadConfigLayers(path)
+	if err != nil {
+		return err
+	}
+
+	problems := configProblems(rootCmd, layers)
+	for _, problem := range problems {
+		fmt.Fprintln(w, problem)
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("config file %s has %d problems", path, len(problems))
+	}
+
+	fmt.Fprintf(w, "config file %s is valid\n", path)
+	return nil
+}
+
+// configProblems returns the unknown keys of every layer and the values that don't fit the type of their flag.
+// Checking the types sets the flags, so the commands must not be run afterwards.
+func configProblems(rootCmd *cobra.Command, layers []configLayer) []string {
+	problems := []string{}
+	invalid := []string{}
+	for _, layer := range layers {
+		values, unknown := layerValues(rootCmd, layer)
+		for _, key := range describeUnknownKeys(rootCmd, layer.keyPrefix(), unknown) {
+			problems = append(problems, "unknown key "+layer.describe(key))
+		}
+
+		for cmd, cmdValues := range values {
+			for _, cv := range cmdValues {
+				if err := setFlag(cmd.Flags(), cv.flag, cv.value); err != nil {
+					invalid = append(invalid, fmt.Sprintf("invalid value for %s: %v", cv.key, err))
+				}
+			}
+		}
+	}
+
+	sort.Strings(invalid)
+	return append(problems, invalid...)
+}
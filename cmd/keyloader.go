@@ -17,19 +17,33 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/in-toto/go-witness/cryptoutil"
 	"github.com/in-toto/go-witness/log"
 	"github.com/in-toto/go-witness/signer"
-	"github.com/in-toto/go-witness/signer/kms"
 	"github.com/in-toto/witness/options"
 	"github.com/spf13/pflag"
 )
 
-// providersFromFlags looks at all flags that were set by the user to determine which providers we should use
-func providersFromFlags(prefix string, flags *pflag.FlagSet) map[string]struct{} {
-	providers := make(map[string]struct{})
+// providersFromFlags looks at all flags that were set by the user to determine which providers we should use. Each
+// provider maps to the flags that requested it. Flags that share the prefix but don't belong to a registered provider,
+// such as --signer-strict, are ignored.
+func providersFromFlags(prefix string, flags *pflag.FlagSet) map[string][]string {
+	registered := map[string]struct{}{}
+	switch prefix {
+	case "signer":
+		for _, entry := range signer.RegistryEntries() {
+			registered[entry.Name] = struct{}{}
+		}
+	case "verifier":
+		for _, entry := range signer.VerifierRegistryEntries() {
+			registered[entry.Name] = struct{}{}
+		}
+	}
+
+	providers := make(map[string][]string)
 	flags.Visit(func(flag *pflag.Flag) {
 		if !strings.HasPrefix(flag.Name, fmt.Sprintf("%s-", prefix)) {
 			return
@@ -40,44 +54,144 @@ func providersFromFlags(prefix string, flags *pflag.FlagSet) map[string]struct{}
 			return
 		}
 
-		providers[parts[1]] = struct{}{}
+		if _, ok := registered[parts[1]]; !ok {
+			return
+		}
+
+		providers[parts[1]] = append(providers[parts[1]], flag.Name)
 	})
 
 	return providers
 }
 
-// loadSigners loads all signers that appear in the signerProviders set and creates their respective signers, using any options provided in so
-func loadSigners(ctx context.Context, so options.SignerOptions, ko options.KMSSignerProviderOptions, signerProviders map[string]struct{}) ([]cryptoutil.Signer, error) {
+// providerError records why a requested provider failed to load
+type providerError struct {
+	provider string
+	flags    []string
+	err      error
+}
+
+func (e providerError) Error() string {
+	if len(e.flags) == 0 {
+		return fmt.Sprintf("%s: %v", e.provider, e.err)
+	}
+
+	return fmt.Sprintf("%s (requested by --%s): %v", e.provider, strings.Join(e.flags, ", --"), e.err)
+}
+
+func (e providerError) Unwrap() error {
+	return e.err
+}
+
+// ProviderLoadError lists every requested signer or verifier provider that failed to load
+type ProviderLoadError struct {
+	Kind      string
+	Requested int
+	Failures  []providerError
+}
+
+func (e *ProviderLoadError) Error() string {
+	lines := []string{fmt.Sprintf("failed to load %d of %d requested %s providers:", len(e.Failures), e.Requested, e.Kind)}
+	for _, failure := range e.Failures {
+		lines = append(lines, "  "+failure.Error())
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func (e *ProviderLoadError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Failures))
+	for _, failure := range e.Failures {
+		errs = append(errs, failure)
+	}
+
+	return errs
+}
+
+// checkProviderFailures decides whether the providers that failed to load abort the command. Any failure does in
+// strict mode, otherwise only a failure of every requested provider does and the rest are logged as warnings.
+func checkProviderFailures(kind string, requested map[string][]string, failures []providerError, loaded int, strict bool) error {
+	if len(failures) == 0 {
+		return nil
+	}
+
+	loadErr := &ProviderLoadError{Kind: kind, Requested: len(requested), Failures: failures}
+	if strict || loaded == 0 {
+		return loadErr
+	}
+
+	for _, failure := range failures {
+		log.Warnf("Ignoring %s provider that failed to load, use --%s-strict to abort instead: %v", kind, kind, failure)
+	}
+
+	return nil
+}
+
+// sortedProviders returns the requested providers in a stable order so errors and loaded keys don't depend on map
+// iteration
+func sortedProviders[V any](providers map[string]V) []string {
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// applyKMSOptions sets the options of every KMS backend on the provider created for a kms reference. The kms provider
+// is both a signer and a verifier provider, so signers and verifiers are both set up through here.
+func applyKMSOptions[T any](provider T, ko map[string][]func(T) (T, error)) (T, error) {
+	for _, name := range sortedProviders(ko) {
+		for _, setter := range ko[name] {
+			var err error
+			if provider, err = setter(provider); err != nil {
+				return provider, fmt.Errorf("failed to set %s option: %w", name, err)
+			}
+		}
+	}
+
+	return provider, nil
+}
+
+// loadSigners loads all signers that appear in the signerProviders set and creates their respective signers, using any
+// options provided in so. Providers that fail to load are reported together, see checkProviderFailures.
+func loadSigners(ctx context.Context, so options.SignerOptions, ko options.KMSSignerProviderOptions, signerProviders map[string][]string, strict bool) ([]cryptoutil.Signer, error) {
 	signers := make([]cryptoutil.Signer, 0)
-	for signerProvider := range signerProviders {
+	failures := []providerError{}
+	for _, signerProvider := range sortedProviders(signerProviders) {
+		fail := func(err error) {
+			failures = append(failures, providerError{provider: signerProvider, flags: signerProviders[signerProvider], err: err})
+		}
+
 		setters := so[signerProvider]
 		sp, err := signer.NewSignerProvider(signerProvider, setters...)
 		if err != nil {
-			log.Errorf("failed to create %v signer provider: %w", signerProvider, err)
+			fail(fmt.Errorf("failed to create signer provider: %w", err))
 			continue
 		}
 
 		// NOTE: We want to initialize the KMS provider specific options if a KMS signer has been invoked
-		if ksp, ok := sp.(*kms.KMSSignerProvider); ok {
-			for _, opt := range ksp.Options {
-				for _, setter := range ko[opt.ProviderName()] {
-					sp, err = setter(ksp)
-					if err != nil {
-						continue
-					}
-				}
+		if signerProvider == kmsProviderName {
+			if sp, err = applyKMSOptions(sp, ko); err != nil {
+				fail(err)
+				continue
 			}
 		}
 
 		s, err := sp.Signer(ctx)
 		if err != nil {
-			log.Errorf("failed to create %v signer: %w", signerProvider, err)
+			fail(fmt.Errorf("failed to create signer: %w", err))
 			continue
 		}
 
 		signers = append(signers, s)
 	}
 
+	if err := checkProviderFailures("signer", signerProviders, failures, len(signers), strict); err != nil {
+		return nil, err
+	}
+
 	if len(signers) == 0 {
 		return signers, fmt.Errorf("failed to load any signers")
 	}
@@ -86,52 +200,65 @@ func loadSigners(ctx context.Context, so options.SignerOptions, ko options.KMSSi
 }
 
 // NOTE: This is a temporary implementation until we have a SignerVerifier interface
-// loadVerifiers loads all verifiers that appear in the verifierProviders set and creates their respective verifiers, using any options provided in so
-func loadVerifiers(ctx context.Context, so options.VerifierOptions, ko options.KMSVerifierProviderOptions, verifierProviders map[string]struct{}) ([]cryptoutil.Verifier, error) {
+// loadVerifiers loads all verifiers that appear in the verifierProviders set and creates their respective verifiers,
+// using any options provided in so. Providers that fail to load are reported together, see checkProviderFailures.
+func loadVerifiers(ctx context.Context, so options.VerifierOptions, ko options.KMSVerifierProviderOptions, verifierProviders map[string][]string, strict bool) ([]cryptoutil.Verifier, error) {
 	verifiers := make([]cryptoutil.Verifier, 0)
-	for verifierProvider := range verifierProviders {
+	failures := []providerError{}
+	for _, verifierProvider := range sortedProviders(verifierProviders) {
+		fail := func(err error) {
+			failures = append(failures, providerError{provider: verifierProvider, flags: verifierProviders[verifierProvider], err: err})
+		}
+
 		setters := so[verifierProvider]
 		sp, err := signer.NewVerifierProvider(verifierProvider, setters...)
 		if err != nil {
-			log.Errorf("failed to create %v verifier provider: %w", verifierProvider, err)
+			fail(fmt.Errorf("failed to create verifier provider: %w", err))
 			continue
 		}
 
-		// NOTE: We want to initialize the KMS provider specific options if a KMS signer has been invoked
-		if ksp, ok := sp.(*kms.KMSSignerProvider); ok {
-			for _, opt := range ksp.Options {
-				pn := opt.ProviderName()
-				for _, setter := range ko[pn] {
-					vp, err := setter(ksp)
-					if err != nil {
-						continue
-					}
-
-					// NOTE: KMS SignerProvider can also be a VerifierProvider. This is a nasty hack to cast things back in a way that we can add to the loaded verifiers.
-					// This must be refactored.
-					kspv, ok := vp.(*kms.KMSSignerProvider)
-					if !ok {
-						return nil, fmt.Errorf("provided verifier provider is not a KMS verifier provider")
-					}
-
-					s, err := kspv.Verifier(ctx)
-					if err != nil {
-						log.Errorf("failed to create %v verifier: %w", verifierProvider, err)
-						continue
-					}
-					verifiers = append(verifiers, s)
-				}
+		// NOTE: We want to initialize the KMS provider specific options if a KMS verifier has been invoked
+		if verifierProvider == kmsProviderName {
+			if sp, err = applyKMSOptions(sp, ko); err != nil {
+				fail(err)
+				continue
 			}
 		}
 
 		s, err := sp.Verifier(ctx)
 		if err != nil {
-			log.Errorf("failed to create %v verifier: %w", verifierProvider, err)
+			fail(fmt.Errorf("failed to create verifier: %w", err))
 			continue
 		}
 
 		verifiers = append(verifiers, s)
 	}
 
-	return verifiers, nil
+	if err := checkProviderFailures("verifier", verifierProviders, failures, len(verifiers), strict); err != nil {
+		return nil, err
+	}
+
+	return dedupeVerifiers(verifiers), nil
+}
+
+// dedupeVerifiers drops verifiers for a key that an earlier verifier already covers. Verifiers whose key ID can't be
+// determined are kept.
+func dedupeVerifiers(verifiers []cryptoutil.Verifier) []cryptoutil.Verifier {
+	seen := map[string]struct{}{}
+	deduped := make([]cryptoutil.Verifier, 0, len(verifiers))
+	for _, verifier := range verifiers {
+		keyID, err := verifier.KeyID()
+		if err == nil {
+			if _, ok := seen[keyID]; ok {
+				log.Debugf("Skipping duplicate verifier for key %s", keyID)
+				continue
+			}
+
+			seen[keyID] = struct{}{}
+		}
+
+		deduped = append(deduped, verifier)
+	}
+
+	return deduped
 }
@@ -0,0 +1,134 @@
+// Copyright 2026 The Witness Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"fmt"
+	"testing"
+
+	"github.com/in-toto/go-witness/cryptoutil"
+	"github.com/in-toto/go-witness/registry"
+	"github.com/in-toto/go-witness/signer"
+	"github.com/in-toto/go-witness/signer/kms"
+	"github.com/in-toto/witness/options"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeKMSOptions stands in for the client options of a KMS backend, recording the option set through its flag
+type fakeKMSOptions struct {
+	name    string
+	profile string
+}
+
+func (o *fakeKMSOptions) ProviderName() string {
+	return o.name
+}
+
+func (o *fakeKMSOptions) Init() []registry.Configurer {
+	return []registry.Configurer{
+		registry.StringConfigOption(
+			"profile",
+			"Profile of the fake backend",
+			"",
+			func(sp signer.SignerProvider, profile string) (signer.SignerProvider, error) {
+				ksp, ok := sp.(*kms.KMSSignerProvider)
+				if !ok {
+					return sp, fmt.Errorf("provided signer provider is not a kms signer provider")
+				}
+
+				opts, ok := ksp.Options[o.name].(*fakeKMSOptions)
+				if !ok {
+					return sp, fmt.Errorf("no fake options for %s", o.name)
+				}
+
+				opts.profile = profile
+				return ksp, nil
+			},
+		),
+	}
+}
+
+// fakeKMSBackend replaces the backend registered for scheme with one serving an in-memory key, restoring the real
+// backend when the test ends. The fake only hands out the key once its profile option has been set.
+func fakeKMSBackend(t *testing.T, scheme string, restore kms.ProviderInit) (*fakeKMSOptions, cryptoutil.Signer) {
+	original, ok := kms.ProviderOptions()[scheme]
+	require.True(t, ok, "no kms backend registered for %s", scheme)
+	t.Cleanup(func() { kms.AddProvider(scheme, original, restore) })
+
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	key := cryptoutil.NewECDSASigner(priv, crypto.SHA256)
+
+	opts := &fakeKMSOptions{name: original.ProviderName()}
+	kms.AddProvider(scheme, opts, func(ctx context.Context, ksp *kms.KMSSignerProvider) (cryptoutil.Signer, error) {
+		if opts.profile == "" {
+			return nil, fmt.Errorf("%s profile was not set", opts.name)
+		}
+
+		return key, nil
+	})
+
+	return opts, key
+}
+
+// testLoadVerifiersKMS checks that loadVerifiers loads a verifier from the backend registered for scheme, passing it the
+// backend's options set through the verifier flags. restore reinstates the real backend when the test ends.
+func testLoadVerifiersKMS(t *testing.T, scheme, ref string, restore kms.ProviderInit) {
+	fake, key := fakeKMSBackend(t, scheme, restore)
+
+	cmd := &cobra.Command{}
+	vo := options.VerifierOptions{}
+	vo.AddFlags(cmd)
+	ko := options.KMSVerifierProviderOptions{}
+	ko.AddFlags(cmd)
+
+	profileFlag := fmt.Sprintf("verifier-%s-profile", fake.name)
+	require.NoError(t, cmd.Flags().Set("verifier-kms-ref", ref))
+	require.NoError(t, cmd.Flags().Set(profileFlag, "release"))
+
+	verifiers, err := loadVerifiers(context.Background(), vo, ko, providersFromFlags("verifier", cmd.Flags()), true)
+	require.NoError(t, err)
+	assert.Equal(t, "release", fake.profile)
+	require.Len(t, verifiers, 1)
+
+	wantKeyID, err := key.KeyID()
+	require.NoError(t, err)
+	gotKeyID, err := verifiers[0].KeyID()
+	require.NoError(t, err)
+	assert.Equal(t, wantKeyID, gotKeyID)
+}
+
+func Test_dedupeVerifiers(t *testing.T) {
+	firstKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	secondKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	first := cryptoutil.NewECDSAVerifier(&firstKey.PublicKey, crypto.SHA256)
+	second := cryptoutil.NewECDSAVerifier(&secondKey.PublicKey, crypto.SHA256)
+	firstAgain := cryptoutil.NewECDSAVerifier(&firstKey.PublicKey, crypto.SHA256)
+
+	deduped := dedupeVerifiers([]cryptoutil.Verifier{first, second, firstAgain})
+	require.Len(t, deduped, 2)
+	assert.Same(t, first, deduped[0])
+	assert.Same(t, second, deduped[1])
+}
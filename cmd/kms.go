@@ -0,0 +1,76 @@
+// Copyright 2026 The Witness Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/in-toto/go-witness/cryptoutil"
+	"github.com/in-toto/go-witness/signer/kms"
+)
+
+// The KMS backends are wired in by the kms_<backend>.go files, which are the only files in witness importing a backend
+// package. Building with the no_kms_<backend> tag swaps a backend for the matching kms_<backend>_disabled.go file
+// instead: the backend has no flags and its references fail with an error naming the build tag. The go-witness root
+// package, which witness needs for witness.Run and witness.Verify, still imports every backend it ships, so the AWS,
+// GCP and Vault SDKs stay linked into the binary under every tag until go-witness stops importing them there. See
+// docs/signers/kms.md.
+
+// kmsProviderName is the name the kms signer and verifier providers are registered under
+const kmsProviderName = "kms"
+
+// disabledKMSBackends maps the reference scheme of each disabled backend to the build tag that disabled it
+var disabledKMSBackends = map[string]string{}
+
+// disableKMSBackend replaces the backend registered for scheme with one that refuses every reference. It is called
+// from package level variable declarations so it runs after the backend registered itself and before any command
+// reads the registered backends.
+func disableKMSBackend(scheme, tag string) struct{} {
+	disabledKMSBackends[scheme] = tag
+	kms.AddProvider(scheme, nil, func(ctx context.Context, ksp *kms.KMSSignerProvider) (cryptoutil.Signer, error) {
+		return nil, fmt.Errorf("the %s kms backend is disabled in this build of witness (built with the %s tag)", scheme, tag)
+	})
+
+	return struct{}{}
+}
+
+// kmsBackend describes a KMS backend compiled into witness
+type kmsBackend struct {
+	Scheme   string
+	Provider string
+	Disabled bool
+}
+
+// kmsBackends returns every KMS backend known to this build, sorted by reference scheme
+func kmsBackends() []kmsBackend {
+	backends := []kmsBackend{}
+	for scheme, opts := range kms.ProviderOptions() {
+		backend := kmsBackend{Scheme: scheme}
+		if opts != nil {
+			backend.Provider = opts.ProviderName()
+		}
+
+		if _, ok := disabledKMSBackends[scheme]; ok || opts == nil {
+			backend.Disabled = true
+		}
+
+		backends = append(backends, backend)
+	}
+
+	sort.Slice(backends, func(i, j int) bool { return backends[i].Scheme < backends[j].Scheme })
+	return backends
+}
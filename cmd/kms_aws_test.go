@@ -0,0 +1,32 @@
+// Copyright 2026 The Witness Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !no_kms_aws
+
+package cmd
+
+import (
+	"context"
+	"testing"
+
+	"github.com/in-toto/go-witness/cryptoutil"
+	"github.com/in-toto/go-witness/signer/kms"
+	"github.com/in-toto/go-witness/signer/kms/aws"
+)
+
+func Test_loadVerifiersKMSAWS(t *testing.T) {
+	testLoadVerifiersKMS(t, aws.ReferenceScheme, "awskms:///alias/release", func(ctx context.Context, ksp *kms.KMSSignerProvider) (cryptoutil.Signer, error) {
+		return aws.LoadSignerVerifier(ctx, ksp)
+	})
+}
@@ -0,0 +1,57 @@
+// Copyright 2026 The Witness Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !no_kms_hashivault
+
+package cmd
+
+import (
+	"context"
+	"testing"
+
+	"github.com/in-toto/go-witness/cryptoutil"
+	"github.com/in-toto/go-witness/signer/kms"
+	"github.com/in-toto/go-witness/signer/kms/hashivault"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_loadVerifiersKMSHashivault(t *testing.T) {
+	testLoadVerifiersKMS(t, hashivault.ReferenceScheme, "hashivault://release", func(ctx context.Context, ksp *kms.KMSSignerProvider) (cryptoutil.Signer, error) {
+		return hashivault.LoadSignerVerifier(ctx, ksp)
+	})
+}
+
+func Test_disableKMSBackend(t *testing.T) {
+	original := kms.ProviderOptions()[hashivault.ReferenceScheme]
+	require.NotNil(t, original)
+	t.Cleanup(func() {
+		delete(disabledKMSBackends, hashivault.ReferenceScheme)
+		kms.AddProvider(hashivault.ReferenceScheme, original, func(ctx context.Context, ksp *kms.KMSSignerProvider) (cryptoutil.Signer, error) {
+			return hashivault.LoadSignerVerifier(ctx, ksp)
+		})
+	})
+
+	assert.Contains(t, kmsBackends(), kmsBackend{Scheme: hashivault.ReferenceScheme, Provider: "kms-hashivault"})
+	disableKMSBackend(hashivault.ReferenceScheme, "no_kms_hashivault")
+	assert.Contains(t, kmsBackends(), kmsBackend{Scheme: hashivault.ReferenceScheme, Disabled: true})
+
+	// a disabled backend has no flags
+	cmd := SignCmd()
+	assert.Nil(t, cmd.Flags().Lookup("signer-kms-hashivault-addr"))
+	assert.NotNil(t, cmd.Flags().Lookup("signer-kms-ref"))
+
+	_, err := kms.New(kms.WithRef("hashivault://release")).Signer(context.Background())
+	assert.ErrorContains(t, err, "the hashivault:// kms backend is disabled in this build of witness (built with the no_kms_hashivault tag)")
+}
@@ -14,7 +14,11 @@
 
 package cmd
 
-import "github.com/spf13/cobra"
+import (
+	"time"
+
+	"github.com/spf13/cobra"
+)
 
 // PolicyCmd has several subcommands for managing policies
 func PolicyCmd() *cobra.Command {
@@ -33,7 +37,7 @@ func PolicyCheckCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:               "check [policy file]",
 		Short:             "Check a policy file",
-		Long:              `Check a policy file for correctness and expiration.`,
+		Long:              `Check a policy file for correctness and expiration. Use - to read the policy from stdin.`,
 		SilenceErrors:     true,
 		SilenceUsage:      true,
 		DisableAutoGenTag: true,
@@ -46,6 +50,15 @@ func PolicyCheckCmd() *cobra.Command {
 	cmd.Flags().BoolP("verbose", "v", false, "Show detailed validation progress")
 	cmd.Flags().BoolP("quiet", "q", false, "Only show errors, no success messages")
 	cmd.Flags().Bool("json", false, "Output results in JSON format")
+	cmd.Flags().Time("verify-time", time.Time{}, []string{time.RFC3339}, "Point in time (RFC3339) to check expiration against instead of the current time")
+	cmd.Flags().Bool("verify-time-from-timestamps", false, "Check expiration as of when the attestations passed with --attestations were produced, taken from their timestamps as verified by the policy's timestamp authorities")
+	cmd.Flags().StringSlice("attestations", []string{}, "Attestation files whose timestamps --verify-time-from-timestamps reads")
+	cmd.Flags().String("trusted-root", "", "Path to a Sigstore trusted_root.json to check the policy's signing certificates and timestamps against")
+	cmd.Flags().StringSlice("crl", []string{}, "Paths to PEM or DER encoded certificate revocation lists to check the policy signer certificate chains against. Requires --trusted-root")
+	cmd.Flags().String("ocsp-responder", "", "URL of an OCSP responder to check the policy signer certificate chains against. Requires --trusted-root")
+	cmd.Flags().Bool("revocation-strict", false, "Fail the check when the revocation status of a policy signer certificate can't be determined, instead of only warning")
+	cmd.MarkFlagsMutuallyExclusive("verify-time", "verify-time-from-timestamps")
+	cmd.MarkFlagsRequiredTogether("verify-time-from-timestamps", "attestations")
 
 	return cmd
 }
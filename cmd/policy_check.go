@@ -16,13 +16,13 @@ package cmd
 
 import (
 	"bytes"
+	"context"
 	"crypto/x509"
 	"encoding/base64"
 	"encoding/json"
 	"encoding/pem"
 	"errors"
 	"fmt"
-	"os"
 	"strings"
 	"time"
 
@@ -30,6 +30,10 @@ import (
 	"github.com/in-toto/go-witness/dsse"
 	"github.com/in-toto/go-witness/log"
 	"github.com/in-toto/go-witness/policy"
+	"github.com/in-toto/witness/internal/detached"
+	"github.com/in-toto/witness/internal/revocation"
+	"github.com/in-toto/witness/internal/stdio"
+	"github.com/in-toto/witness/internal/trust"
 
 	"github.com/open-policy-agent/opa/v1/ast"
 	"github.com/spf13/cobra"
@@ -43,6 +47,7 @@ type ValidationResult struct {
 	ChecksPassed     int               `json:"checks_passed"`
 	PolicyFile       string            `json:"policy_file"`
 	PolicyExpiration string            `json:"policy_expiration,omitempty"`
+	CheckedAt        string            `json:"checked_at"`
 }
 
 type ValidationError struct {
@@ -72,20 +77,40 @@ func NewPolicyCheckError(errors []error) error {
 }
 
 func ReadPolicy(policyFile string, verbose bool) (*policy.Policy, bool, error) {
-	policyBytes, err := os.ReadFile(policyFile)
+	p, _, isDSSE, err := readPolicyEnvelope(policyFile, verbose)
+	return p, isDSSE, err
+}
+
+// readPolicyEnvelope reads the policy like ReadPolicy does, and also returns the envelope it was taken from. The
+// envelope is empty for a policy that isn't signed.
+func readPolicyEnvelope(policyFile string, verbose bool) (*policy.Policy, dsse.Envelope, bool, error) {
+	envelope := dsse.Envelope{}
+	policyBytes, err := stdio.ReadFile(policyFile)
 	if err != nil {
-		return nil, false, fmt.Errorf("failed to read policy file: %w", err)
+		return nil, envelope, false, fmt.Errorf("failed to read policy file: %w", err)
 	}
 
 	isDSSE := false
-	// Attempt to unmarshal as a DSSE envelope
-	e := dsse.Envelope{}
-	if err := json.Unmarshal(policyBytes, &e); err == nil {
+	// Attempt to unmarshal as a DSSE envelope, reattaching the payload of a detached envelope
+	if e, isDetached, err := detached.Load(policyBytes, policyFile, ""); isDetached {
+		if err != nil {
+			return nil, envelope, true, err
+		}
+
+		if verbose {
+			log.Info("Detached DSSE Envelope detected, reattaching payload")
+		}
+
+		policyBytes = e.Payload
+		envelope = e
+		isDSSE = true
+	} else if err == nil {
 		if e.Payload != nil {
 			if verbose {
 				log.Info("DSSE Envelope detected, extracting payload")
 			}
 			policyBytes = e.Payload
+			envelope = e
 			isDSSE = true
 		}
 	} else {
@@ -97,10 +122,10 @@ func ReadPolicy(policyFile string, verbose bool) (*policy.Policy, bool, error) {
 	// Unmarshal into the Policy struct
 	p := &policy.Policy{}
 	if err := json.Unmarshal(policyBytes, p); err != nil {
-		return nil, isDSSE, fmt.Errorf("failed to parse policy JSON: %w\nHint: Ensure the policy is valid JSON and follows the witness policy schema", err)
+		return nil, envelope, isDSSE, fmt.Errorf("failed to parse policy JSON: %w\nHint: Ensure the policy is valid JSON and follows the witness policy schema", err)
 	}
 
-	return p, isDSSE, nil
+	return p, envelope, isDSSE, nil
 }
 
 // CheckPolicy checks the policy file for correctness and expiration
@@ -110,23 +135,33 @@ func checkPolicy(cmd *cobra.Command, args []string) error {
 	verbose, _ := cmd.Flags().GetBool("verbose")
 	quiet, _ := cmd.Flags().GetBool("quiet")
 	jsonOutput, _ := cmd.Flags().GetBool("json")
+	checkTime, _ := cmd.Flags().GetTime("verify-time")
+	fromTimestamps, _ := cmd.Flags().GetBool("verify-time-from-timestamps")
+	attestationPaths, _ := cmd.Flags().GetStringSlice("attestations")
+	if checkTime.IsZero() {
+		checkTime = time.Now()
+	}
 
 	result := &ValidationResult{
 		Valid:           true,
 		PolicyFile:      policyFile,
+		CheckedAt:       checkTime.Format(time.RFC3339),
 		ChecksPerformed: 0,
 		ChecksPassed:    0,
 	}
 
 	if verbose && !jsonOutput {
 		log.Infof("Validating policy: %s", policyFile)
+		if !fromTimestamps {
+			log.Infof("Checking expiration as of %s", checkTime.Format(time.RFC3339))
+		}
 	}
 
 	// Read and parse policy
 	if verbose && !jsonOutput {
 		log.Infof("Reading policy file...")
 	}
-	p, isDSSE, err := ReadPolicy(policyFile, verbose && !jsonOutput)
+	p, envelope, isDSSE, err := readPolicyEnvelope(policyFile, verbose && !jsonOutput)
 	if err != nil {
 		result.Valid = false
 		result.Errors = append(result.Errors, ValidationError{
@@ -140,6 +175,26 @@ func checkPolicy(cmd *cobra.Command, args []string) error {
 	result.ChecksPassed++
 	result.PolicyExpiration = p.Expires.Format(time.RFC3339)
 
+	if fromTimestamps {
+		result.ChecksPerformed++
+		checkTime, err = attestationFilesTime(cmd.Context(), *p, attestationPaths)
+		if err != nil {
+			result.Valid = false
+			result.Errors = append(result.Errors, ValidationError{
+				Category:   "Verification Time",
+				Message:    err.Error(),
+				Suggestion: "Provide attestations timestamped by one of the policy's timestamp authorities with --attestations",
+			})
+			return outputResult(result, jsonOutput, quiet)
+		}
+		result.ChecksPassed++
+		result.CheckedAt = checkTime.Format(time.RFC3339)
+
+		if verbose && !jsonOutput {
+			log.Infof("Checking expiration as of the attestation timestamps, %s", checkTime.Format(time.RFC3339))
+		}
+	}
+
 	if verbose && !jsonOutput {
 		if isDSSE {
 			log.Info("DSSE envelope format")
@@ -153,7 +208,7 @@ func checkPolicy(cmd *cobra.Command, args []string) error {
 		log.Info("Checking policy expiration...")
 	}
 	result.ChecksPerformed++
-	if time.Now().After(p.Expires.Time) {
+	if checkTime.After(p.Expires.Time) {
 		result.Valid = false
 		result.Errors = append(result.Errors, ValidationError{
 			Category:   "Policy Expiration",
@@ -164,7 +219,7 @@ func checkPolicy(cmd *cobra.Command, args []string) error {
 	} else {
 		result.ChecksPassed++
 		if verbose && !jsonOutput {
-			daysUntilExpiry := int(time.Until(p.Expires.Time).Hours() / 24)
+			daysUntilExpiry := int(p.Expires.Sub(checkTime).Hours() / 24)
 			log.Infof("Policy valid until %s (%d days)", p.Expires.Format("2006-01-02"), daysUntilExpiry)
 
 			// Warning for soon-to-expire policies
@@ -360,7 +415,7 @@ func checkPolicy(cmd *cobra.Command, args []string) error {
 
 		// Check expiration
 		result.ChecksPerformed++
-		if time.Now().After(cert.NotAfter) {
+		if checkTime.After(cert.NotAfter) {
 			result.Valid = false
 			result.Errors = append(result.Errors, ValidationError{
 				Category:   "Root Certificate",
@@ -446,7 +501,7 @@ func checkPolicy(cmd *cobra.Command, args []string) error {
 
 			// Validate expiration, CA status, etc.
 			result.ChecksPerformed += 2
-			if cert != nil && time.Now().After(cert.NotAfter) {
+			if cert != nil && checkTime.After(cert.NotAfter) {
 				result.Valid = false
 				result.Errors = append(result.Errors, ValidationError{
 					Category:   "Timestamp Authority",
@@ -476,9 +531,207 @@ func checkPolicy(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	// Validate policy signers against a trusted root (if any)
+	trustedRootPath, _ := cmd.Flags().GetString("trusted-root")
+	crlPaths, _ := cmd.Flags().GetStringSlice("crl")
+	ocspResponder, _ := cmd.Flags().GetString("ocsp-responder")
+	revocationStrict, _ := cmd.Flags().GetBool("revocation-strict")
+	revocationChecker, _, err := loadRevocationChecker(crlPaths, ocspResponder)
+	if err != nil {
+		result.ChecksPerformed++
+		result.Valid = false
+		result.Errors = append(result.Errors, ValidationError{
+			Category:   "Revocation",
+			Message:    err.Error(),
+			Suggestion: "Provide PEM or DER encoded certificate revocation lists with --crl",
+		})
+	} else if revocationChecker != nil && trustedRootPath == "" {
+		result.ChecksPerformed++
+		result.Valid = false
+		result.Errors = append(result.Errors, ValidationError{
+			Category:   "Revocation",
+			Message:    "Revocation of the policy signers can only be checked against a trusted root",
+			Suggestion: "Provide the trusted root the policy signer certificates chain to with --trusted-root",
+		})
+	}
+
+	if trustedRootPath != "" {
+		if verbose && !jsonOutput {
+			log.Info("Validating policy signers against the trusted root...")
+		}
+
+		checkPolicySigners(cmd.Context(), envelope, trustedRootPath, checkTime, result, revocationChecker, revocationStrict)
+	}
+
 	return outputResult(result, jsonOutput, quiet)
 }
 
+// attestationFilesTime reads the attestation files and returns the time they had all been produced by, taken from
+// their timestamps as verified by the policy's timestamp authorities
+func attestationFilesTime(ctx context.Context, p policy.Policy, paths []string) (time.Time, error) {
+	attestations := make([]namedEnvelope, 0, len(paths))
+	for _, path := range paths {
+		env, _, err := detached.LoadFile(path, "")
+		if err != nil {
+			return time.Time{}, fmt.Errorf("failed to load attestation %s: %w", path, err)
+		}
+
+		attestations = append(attestations, namedEnvelope{name: path, envelope: env})
+	}
+
+	tsVerifiers, err := policyTimestampVerifiers(p)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return attestationsTime(ctx, attestations, tsVerifiers)
+}
+
+// verifyPolicySignature verifies a signature of the policy envelope with the public key of its certificate. The
+// certificate itself is checked against the trusted root separately.
+func verifyPolicySignature(envelope dsse.Envelope, sig dsse.Signature, cert *x509.Certificate) error {
+	verifier, err := cryptoutil.NewVerifier(cert.PublicKey)
+	if err != nil {
+		return err
+	}
+
+	// without the certificate the signature is only checked against the verifier, not a chain of trust
+	single := dsse.Envelope{
+		Payload:     envelope.Payload,
+		PayloadType: envelope.PayloadType,
+		Signatures:  []dsse.Signature{{KeyID: sig.KeyID, Signature: sig.Signature}},
+	}
+
+	_, err = single.Verify(dsse.VerifyWithVerifiers(verifier))
+	return err
+}
+
+// checkPolicySigners checks that each certificate signature of the policy verifies, that the certificates were issued
+// by a certificate authority of the trusted root while it was trusted, and that expired certificates are backed by a
+// timestamp from one of its timestamp authorities. With a revocation checker it also checks that none of the
+// certificate chains were revoked at the check time.
+func checkPolicySigners(ctx context.Context, envelope dsse.Envelope, trustedRootPath string, checkTime time.Time, result *ValidationResult, revocationChecker *revocation.Checker, revocationStrict bool) {
+	result.ChecksPerformed++
+	trustedRoot, err := trust.LoadTrustedRoot(trustedRootPath)
+	if err != nil {
+		result.Valid = false
+		result.Errors = append(result.Errors, ValidationError{
+			Category:   "Trusted Root",
+			Message:    err.Error(),
+			Suggestion: "Provide a Sigstore trusted_root.json, e.g. the output of `cosign trusted-root create`",
+		})
+		return
+	}
+	result.ChecksPassed++
+
+	signed := 0
+	for i, sig := range envelope.Signatures {
+		if len(sig.Certificate) == 0 {
+			continue
+		}
+
+		signed++
+		location := fmt.Sprintf("signatures[%d]", i)
+		result.ChecksPerformed++
+		cert, err := cryptoutil.TryParseCertificate(sig.Certificate)
+		if err != nil {
+			result.Valid = false
+			result.Errors = append(result.Errors, ValidationError{
+				Category: "Policy Signer",
+				Message:  fmt.Sprintf("Policy signature certificate is not valid: %v", err),
+				Location: location + ".certificate",
+			})
+			continue
+		}
+
+		if err := verifyPolicySignature(envelope, sig, cert); err != nil {
+			result.Valid = false
+			result.Errors = append(result.Errors, ValidationError{
+				Category:   "Policy Signer",
+				Message:    fmt.Sprintf("Policy signature of %s does not verify against its certificate: %v", cert.Subject, err),
+				Suggestion: "Re-sign the policy, the signature or the policy itself was modified after signing",
+				Location:   location + ".sig",
+			})
+			continue
+		}
+
+		intermediates := []*x509.Certificate{}
+		for _, intermediate := range sig.Intermediates {
+			if intCert, err := cryptoutil.TryParseCertificate(intermediate); err == nil {
+				intermediates = append(intermediates, intCert)
+			}
+		}
+
+		if err := trustedRoot.CheckCertificate(cert, intermediates); err != nil {
+			result.Valid = false
+			result.Errors = append(result.Errors, ValidationError{
+				Category:   "Policy Signer",
+				Message:    fmt.Sprintf("Policy signer %s: %v", cert.Subject, err),
+				Suggestion: "Sign the policy with a certificate issued by one of the trusted root's certificate authorities",
+				Location:   location + ".certificate",
+			})
+			continue
+		}
+		result.ChecksPassed++
+
+		if !checkTime.After(cert.NotAfter) {
+			continue
+		}
+
+		// short lived certificates, such as those from Fulcio, are only valid through a timestamp
+		result.ChecksPerformed++
+		timestamped := false
+		for _, sigTimestamp := range sig.Timestamps {
+			for _, verifier := range trustedRoot.TimestampVerifiers() {
+				ts, err := verifier.Verify(ctx, bytes.NewReader(sigTimestamp.Data), bytes.NewReader(sig.Signature))
+				if err == nil && !ts.Before(cert.NotBefore) && !ts.After(cert.NotAfter) {
+					timestamped = true
+				}
+			}
+		}
+
+		if !timestamped {
+			result.Valid = false
+			result.Errors = append(result.Errors, ValidationError{
+				Category:   "Policy Signer",
+				Message:    fmt.Sprintf("Policy signer certificate %s expired on %s and no trusted timestamp shows it was used while valid", cert.Subject, cert.NotAfter.Format(time.RFC3339)),
+				Suggestion: "Timestamp the policy signature with one of the trusted root's timestamp authorities",
+				Location:   location + ".timestamps",
+			})
+			continue
+		}
+		result.ChecksPassed++
+	}
+
+	if signed == 0 {
+		result.Warnings = append(result.Warnings, "Policy has no certificate signatures to check against the trusted root")
+	}
+
+	if revocationChecker == nil {
+		return
+	}
+
+	result.ChecksPerformed++
+	revocationResults, err := checkPolicySignerRevocation(ctx, revocationChecker, envelope, trustedRoot.Roots(), trustedRoot.Intermediates(), checkTime, revocationStrict)
+	for _, revocationResult := range revocationResults {
+		if revocationResult.Status == revocation.StatusUnknown {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("Revocation status of policy signer certificate is unknown: %s", revocationResult))
+		}
+	}
+
+	if err != nil {
+		result.Valid = false
+		result.Errors = append(result.Errors, ValidationError{
+			Category:   "Revocation",
+			Message:    err.Error(),
+			Suggestion: "Re-sign the policy with a certificate that hasn't been revoked, or provide a CRL or OCSP responder that covers its chain",
+			Location:   "signatures",
+		})
+		return
+	}
+	result.ChecksPassed++
+}
+
 func outputResult(result *ValidationResult, jsonOutput bool, quiet bool) error {
 	if jsonOutput {
 		output, _ := json.MarshalIndent(result, "", "  ")
@@ -21,8 +21,8 @@ import (
 	"runtime/pprof"
 
 	"github.com/in-toto/go-witness/log"
-	_ "github.com/in-toto/go-witness/signer/kms/aws"
-	_ "github.com/in-toto/go-witness/signer/kms/gcp"
+	_ "github.com/in-toto/witness/internal/signer/pkcs11"
+	"github.com/in-toto/witness/internal/stdio"
 	"github.com/in-toto/witness/options"
 	"github.com/spf13/cobra"
 )
@@ -50,7 +50,10 @@ func New() *cobra.Command {
 	cmd.AddCommand(CompletionCmd())
 	cmd.AddCommand(VersionCmd())
 	cmd.AddCommand(AttestorsCmd())
+	cmd.AddCommand(SignersCmd())
 	cmd.AddCommand(PolicyCmd())
+	cmd.AddCommand(TimestampCmd())
+	cmd.AddCommand(ConfigCmd())
 	cobra.OnInitialize(func() { preRoot(cmd, ro, logger) })
 	cobra.OnFinalize((func() { postRoot(ro, logger) }))
 	return cmd
@@ -72,6 +75,11 @@ func preRoot(cmd *cobra.Command, ro *options.RootOptions, logger *logrusLogger)
 		logger.l.Fatal(err)
 	}
 
+	// the log level may have come from the environment
+	if err := logger.SetLevel(ro.LogLevel); err != nil {
+		logger.l.Fatal(err)
+	}
+
 	var err error
 	if len(ro.CpuProfileFile) > 0 {
 		cpuProfileFile, err = os.Create(ro.CpuProfileFile)
@@ -115,7 +123,7 @@ func postRoot(ro *options.RootOptions, logger *logrusLogger) {
 func loadOutfile(outFilePath string) (*os.File, error) {
 	var err error
 	out := os.Stdout
-	if outFilePath != "" {
+	if outFilePath != "" && !stdio.IsStdio(outFilePath) {
 		out, err = os.Create(outFilePath)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create output file: %w", err)
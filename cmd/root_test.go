@@ -21,6 +21,7 @@ import (
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"encoding/pem"
+	"fmt"
 	"math/big"
 	"os"
 	"testing"
@@ -63,7 +64,7 @@ func Test_loadSignersKeyPair(t *testing.T) {
 			},
 		}
 
-		signers, err := loadSigners(context.Background(), signerOptions, options.KMSSignerProviderOptions{}, map[string]struct{}{"file": {}})
+		signers, err := loadSigners(context.Background(), signerOptions, options.KMSSignerProviderOptions{}, map[string][]string{"file": nil}, false)
 		require.NoError(t, err)
 		require.Len(t, signers, 1)
 		assert.IsType(t, &cryptoutil.RSASigner{}, signers[0])
@@ -79,7 +80,7 @@ func Test_loadSignersKeyPair(t *testing.T) {
 			},
 		}
 
-		signers, err := loadSigners(context.Background(), signerOptions, options.KMSSignerProviderOptions{}, map[string]struct{}{"file": {}})
+		signers, err := loadSigners(context.Background(), signerOptions, options.KMSSignerProviderOptions{}, map[string][]string{"file": nil}, false)
 		require.Error(t, err)
 		require.Len(t, signers, 0)
 	})
@@ -99,12 +100,69 @@ func Test_loadSignersCertificate(t *testing.T) {
 		},
 	}
 
-	signers, err := loadSigners(context.Background(), signerOptions, options.KMSSignerProviderOptions{}, map[string]struct{}{"file": {}})
+	signers, err := loadSigners(context.Background(), signerOptions, options.KMSSignerProviderOptions{}, map[string][]string{"file": nil}, false)
 	require.NoError(t, err)
 	require.Len(t, signers, 1)
 	require.IsType(t, &cryptoutil.X509Signer{}, signers[0])
 }
 
+func Test_loadSignersFailures(t *testing.T) {
+	privatePem, _ := rsakeypair(t)
+	signerOptions := options.SignerOptions{}
+	signerOptions["file"] = []func(signer.SignerProvider) (signer.SignerProvider, error){
+		func(sp signer.SignerProvider) (signer.SignerProvider, error) {
+			fsp := sp.(file.FileSignerProvider)
+			fsp.KeyPath = privatePem.Name()
+			return fsp, nil
+		},
+	}
+
+	if _, err := signer.NewSignerProvider("pkcs11"); err != nil {
+		t.Skip("the pkcs11 provider is only registered in cgo builds")
+	}
+
+	providers := map[string][]string{"file": {"signer-file-key-path"}, "pkcs11": {"signer-pkcs11-key-label"}}
+
+	// the pkcs11 provider has no module, which only aborts in strict mode
+	signers, err := loadSigners(context.Background(), signerOptions, options.KMSSignerProviderOptions{}, providers, false)
+	require.NoError(t, err)
+	require.Len(t, signers, 1)
+
+	_, err = loadSigners(context.Background(), signerOptions, options.KMSSignerProviderOptions{}, providers, true)
+	loadErr := &ProviderLoadError{}
+	require.ErrorAs(t, err, &loadErr)
+	require.Len(t, loadErr.Failures, 1)
+	assert.ErrorContains(t, err, "failed to load 1 of 2 requested signer providers")
+	assert.ErrorContains(t, err, "pkcs11 (requested by --signer-pkcs11-key-label): failed to create signer: a pkcs11 module is required")
+
+	// every requested provider failing is reported even without strict mode
+	signerOptions["file"] = append(signerOptions["file"], func(sp signer.SignerProvider) (signer.SignerProvider, error) {
+		return sp, fmt.Errorf("bad option")
+	})
+	_, err = loadSigners(context.Background(), signerOptions, options.KMSSignerProviderOptions{}, providers, false)
+	require.ErrorAs(t, err, &loadErr)
+	require.Len(t, loadErr.Failures, 2)
+	assert.ErrorContains(t, err, "file (requested by --signer-file-key-path): failed to create signer provider")
+	assert.ErrorContains(t, err, "bad option")
+}
+
+func Test_loadVerifiersFailures(t *testing.T) {
+	verifiers, err := loadVerifiers(context.Background(), options.VerifierOptions{}, options.KMSVerifierProviderOptions{}, map[string][]string{}, true)
+	require.NoError(t, err)
+	assert.Empty(t, verifiers)
+
+	_, err = loadVerifiers(context.Background(), options.VerifierOptions{}, options.KMSVerifierProviderOptions{}, map[string][]string{"pkcs11": {"verifier-pkcs11-module"}}, false)
+	assert.ErrorContains(t, err, "pkcs11 (requested by --verifier-pkcs11-module)")
+}
+
+func Test_providersFromFlags(t *testing.T) {
+	cmd := SignCmd()
+	require.NoError(t, cmd.Flags().Set("signer-file-key-path", "key.pem"))
+	require.NoError(t, cmd.Flags().Set("signer-file-cert-path", "cert.pem"))
+	require.NoError(t, cmd.Flags().Set("signer-strict", "true"))
+	assert.Equal(t, map[string][]string{"file": {"signer-file-cert-path", "signer-file-key-path"}}, providersFromFlags("signer", cmd.Flags()))
+}
+
 func rsakeypair(t *testing.T) (privatePem *os.File, publicPem *os.File) {
 	privatekey, err := rsa.GenerateKey(rand.Reader, keybits)
 	if err != nil {
@@ -17,6 +17,7 @@ package cmd
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 
 	"github.com/gobwas/glob"
@@ -29,6 +30,7 @@ import (
 	"github.com/in-toto/go-witness/log"
 	"github.com/in-toto/go-witness/registry"
 	"github.com/in-toto/go-witness/timestamp"
+	"github.com/in-toto/witness/internal/stdio"
 	"github.com/in-toto/witness/options"
 	"github.com/spf13/cobra"
 )
@@ -48,7 +50,16 @@ func RunCmd() *cobra.Command {
 		SilenceErrors: true,
 		SilenceUsage:  true,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			signers, err := loadSigners(cmd.Context(), o.SignerOptions, o.KMSSignerProviderOptions, providersFromFlags("signer", cmd.Flags()))
+			if err := applyRunManifest(&o, cmd.Flags()); err != nil {
+				return err
+			}
+
+			// fail on misspelled attestors before loading signers, which may reach out to a KMS
+			if err := checkAttestations(o.Attestations, len(args) > 0); err != nil {
+				return err
+			}
+
+			signers, err := loadSigners(cmd.Context(), o.SignerOptions, o.KMSSignerProviderOptions, providersFromFlags("signer", cmd.Flags()), o.SignerStrict)
 			if err != nil {
 				return fmt.Errorf("failed to load signers: %w", err)
 			}
@@ -100,7 +111,7 @@ func runRun(ctx context.Context, ro options.RunOptions, args []string, signers .
 		if !duplicate {
 			attestor, err := attestation.GetAttestor(a)
 			if err != nil {
-				return fmt.Errorf("failed to create attestor: %w", err)
+				return fmt.Errorf("failed to create attestor %s: %w", a, err)
 			}
 			attestors = append(attestors, attestor)
 		}
@@ -162,6 +173,10 @@ func runRun(ctx context.Context, ro options.RunOptions, args []string, signers .
 
 		// TODO: Find out explicit way to describe "prefix" in CLI options
 		outfile := ro.OutFilePath
+		if stdio.IsStdio(outfile) {
+			outfile = ""
+		}
+
 		if result.AttestorName != "" {
 			outfile += "-" + result.AttestorName + ".json"
 		}
@@ -196,3 +211,45 @@ func runRun(ctx context.Context, ro options.RunOptions, args []string, signers .
 	}
 	return nil
 }
+
+// checkAttestations makes sure every attestor named in --attestations exists and can be recorded by witness run
+// before anything runs, so a misspelled name doesn't fail the run after a long command. Attestors are named by name
+// or attestation type, like attestation.GetAttestor takes them. Every problem is reported at once.
+func checkAttestations(names []string, hasCommand bool) error {
+	known := attestorNames()
+	errs := []error{}
+	for _, name := range names {
+		// command-run is recorded whenever a command is given, runRun warns about it being listed
+		if name == commandrun.Name {
+			continue
+		}
+
+		factory, ok := attestation.FactoryByName(name)
+		if !ok {
+			factory, ok = attestation.FactoryByType(name)
+		}
+
+		if !ok {
+			errs = append(errs, checkNames("attestor", []string{name}, known))
+			continue
+		}
+
+		switch runType := factory().RunType(); runType {
+		case attestation.VerifyRunType:
+			errs = append(errs, fmt.Errorf("attestor %s runs during witness verify and can't be recorded by witness run", name))
+		case attestation.ExecuteRunType:
+			if !hasCommand {
+				errs = append(errs, fmt.Errorf("attestor %s records the command being run, but no command was given", name))
+			}
+		case attestation.PreMaterialRunType, attestation.MaterialRunType, attestation.ProductRunType, attestation.PostProductRunType:
+		default:
+			errs = append(errs, fmt.Errorf("attestor %s has run type %q, which witness run doesn't record", name, runType))
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("invalid attestations: %w", err)
+	}
+
+	return nil
+}
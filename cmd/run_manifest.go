@@ -0,0 +1,279 @@
+// Copyright 2026 The Witness Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/in-toto/go-witness/attestation"
+	"github.com/in-toto/go-witness/attestation/commandrun"
+	"github.com/in-toto/go-witness/log"
+	"github.com/in-toto/go-witness/registry"
+	"github.com/in-toto/witness/options"
+	"github.com/spf13/pflag"
+	"go.yaml.in/yaml/v3"
+)
+
+// runManifest declares the attestors witness run records along with their options
+type runManifest struct {
+	Attestors []manifestAttestor `yaml:"attestors"`
+}
+
+// manifestAttestor is an attestor of a run manifest. Options are keyed by the names of the attestor's registry
+// options, the same names its --attestor-<name>-<option> flags end in.
+type manifestAttestor struct {
+	Name    string               `yaml:"name"`
+	Options map[string]yaml.Node `yaml:"options"`
+}
+
+// manifestOption is an attestor option set by a run manifest
+type manifestOption struct {
+	attestor string
+	name     string
+	setter   func(attestation.Attestor) (attestation.Attestor, error)
+}
+
+// loadRunManifest reads a run manifest from disk. Unknown fields are rejected so typos aren't silently ignored.
+func loadRunManifest(path string) (runManifest, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return runManifest{}, fmt.Errorf("failed to open run manifest: %w", err)
+	}
+
+	defer func() {
+		if err := f.Close(); err != nil {
+			log.Errorf("failed to close run manifest: %w", err)
+		}
+	}()
+
+	return parseRunManifest(f)
+}
+
+func parseRunManifest(r io.Reader) (runManifest, error) {
+	manifest := runManifest{}
+	decoder := yaml.NewDecoder(r)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&manifest); err != nil && !errors.Is(err, io.EOF) {
+		return runManifest{}, fmt.Errorf("failed to parse run manifest: %w", err)
+	}
+
+	return manifest, nil
+}
+
+// options checks the attestors of the manifest and their options against the registry entries, returning a setter
+// for each option. Every problem found is returned at once.
+func (m runManifest) options(entries []registry.Entry[attestation.Attestor]) ([]manifestOption, error) {
+	byName := map[string]registry.Entry[attestation.Attestor]{}
+	known := []string{}
+	for _, entry := range entries {
+		byName[entry.Name] = entry
+		known = append(known, entry.Name)
+	}
+
+	errs := []error{}
+	seen := map[string]bool{}
+	opts := []manifestOption{}
+	for _, a := range m.Attestors {
+		entry, ok := byName[a.Name]
+		switch {
+		case a.Name == "":
+			errs = append(errs, fmt.Errorf("attestor without a name"))
+			continue
+		case !ok:
+			errs = append(errs, checkNames("attestor", []string{a.Name}, known))
+			continue
+		case seen[a.Name]:
+			errs = append(errs, fmt.Errorf("attestor %s is listed more than once", a.Name))
+			continue
+		}
+
+		seen[a.Name] = true
+		optNames := make([]string, 0, len(a.Options))
+		for name := range a.Options {
+			optNames = append(optNames, name)
+		}
+
+		sort.Strings(optNames)
+		for _, name := range optNames {
+			node := a.Options[name]
+			setter, err := manifestOptionSetter(entry, name, &node)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("line %d: %w", node.Line, err))
+				continue
+			}
+
+			opts = append(opts, manifestOption{attestor: a.Name, name: name, setter: setter})
+		}
+	}
+
+	return opts, errors.Join(errs...)
+}
+
+// manifestOptionSetter decodes the value of an attestor option according to the type of its registry option
+func manifestOptionSetter(entry registry.Entry[attestation.Attestor], name string, node *yaml.Node) (func(attestation.Attestor) (attestation.Attestor, error), error) {
+	optNames := []string{}
+	var opt registry.Configurer
+	for _, o := range entry.Options {
+		optNames = append(optNames, o.Name())
+		if o.Name() == name {
+			opt = o
+		}
+	}
+
+	if opt == nil {
+		if suggestion := suggest(name, optNames); suggestion != "" {
+			return nil, fmt.Errorf("unknown option %s of attestor %s, did you mean %s?", name, entry.Name, suggestion)
+		}
+
+		return nil, fmt.Errorf("unknown option %s of attestor %s", name, entry.Name)
+	}
+
+	invalid := func(kind string) error {
+		got := "a " + nodeKind(node)
+		if node.Kind == yaml.ScalarNode {
+			got = fmt.Sprintf("%q", node.Value)
+		}
+
+		return fmt.Errorf("option %s of attestor %s expects %s, got %s", name, entry.Name, kind, got)
+	}
+
+	switch o := opt.(type) {
+	case *registry.ConfigOption[attestation.Attestor, int]:
+		var v int
+		if err := node.Decode(&v); err != nil {
+			return nil, invalid("an integer")
+		}
+
+		return func(a attestation.Attestor) (attestation.Attestor, error) { return o.Setter()(a, v) }, nil
+	case *registry.ConfigOption[attestation.Attestor, string]:
+		if node.Kind != yaml.ScalarNode {
+			return nil, invalid("a string")
+		}
+
+		v := node.Value
+		return func(a attestation.Attestor) (attestation.Attestor, error) { return o.Setter()(a, v) }, nil
+	case *registry.ConfigOption[attestation.Attestor, *string]:
+		if node.Kind != yaml.ScalarNode {
+			return nil, invalid("a string")
+		}
+
+		v := node.Value
+
+		return func(a attestation.Attestor) (attestation.Attestor, error) { return o.Setter()(a, &v) }, nil
+	case *registry.ConfigOption[attestation.Attestor, []string]:
+		// a single value is taken as a list of one
+		v := []string{}
+		if node.Kind == yaml.ScalarNode {
+			v = append(v, node.Value)
+		} else if err := node.Decode(&v); err != nil {
+			return nil, invalid("a list of strings")
+		}
+
+		return func(a attestation.Attestor) (attestation.Attestor, error) { return o.Setter()(a, v) }, nil
+	case *registry.ConfigOption[attestation.Attestor, bool]:
+		var v bool
+		if err := node.Decode(&v); err != nil {
+			return nil, invalid("true or false")
+		}
+
+		return func(a attestation.Attestor) (attestation.Attestor, error) { return o.Setter()(a, v) }, nil
+	case *registry.ConfigOption[attestation.Attestor, time.Duration]:
+		v, err := time.ParseDuration(node.Value)
+		if node.Kind != yaml.ScalarNode || err != nil {
+			return nil, invalid("a duration")
+		}
+
+		return func(a attestation.Attestor) (attestation.Attestor, error) { return o.Setter()(a, v) }, nil
+	default:
+		return nil, fmt.Errorf("option %s of attestor %s has unsupported type %T", name, entry.Name, opt)
+	}
+}
+
+func nodeKind(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.SequenceNode:
+		return "list"
+	case yaml.MappingNode:
+		return "map"
+	default:
+		return "value"
+	}
+}
+
+// applyRunManifest adds the attestors and options of the run manifest to the run options. The manifest's attestors
+// replace the default --attestations unless the flag was set, in which case both are recorded. Options set through
+// their --attestor-<name>-<option> flag, from the command line, environment or config file, take precedence over
+// the manifest.
+func applyRunManifest(ro *options.RunOptions, flags *pflag.FlagSet) error {
+	if ro.ManifestPath == "" {
+		return nil
+	}
+
+	manifest, err := loadRunManifest(ro.ManifestPath)
+	if err != nil {
+		return err
+	}
+
+	opts, err := manifest.options(attestation.RegistrationEntries())
+	if err != nil {
+		return fmt.Errorf("invalid run manifest %s: %w", ro.ManifestPath, err)
+	}
+
+	if !flags.Changed("attestations") {
+		ro.Attestations = []string{}
+	}
+
+	for _, a := range manifest.Attestors {
+		if isAlwaysRunAttestor(a.Name) || slices.Contains(ro.Attestations, a.Name) {
+			continue
+		}
+
+		ro.Attestations = append(ro.Attestations, a.Name)
+	}
+
+	for _, opt := range opts {
+		flagName := fmt.Sprintf("attestor-%s-%s", opt.attestor, opt.name)
+		if flag := flags.Lookup(flagName); flag != nil && flag.Changed {
+			log.Debugf("--%s takes precedence over option %s of attestor %s in the run manifest", flagName, opt.name, opt.attestor)
+			continue
+		}
+
+		// the manifest's setters run after the ones of the flags, so they override the flag defaults
+		ro.AttestorOptSetters[opt.attestor] = append(ro.AttestorOptSetters[opt.attestor], opt.setter)
+	}
+
+	return nil
+}
+
+// isAlwaysRunAttestor reports whether witness run records the attestor without it being listed in --attestations
+func isAlwaysRunAttestor(name string) bool {
+	if name == commandrun.Name {
+		return true
+	}
+
+	for _, a := range alwaysRunAttestors {
+		if a.Name() == name {
+			return true
+		}
+	}
+
+	return false
+}
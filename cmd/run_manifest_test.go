@@ -0,0 +1,183 @@
+// Copyright 2026 The Witness Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/in-toto/go-witness/attestation"
+	"github.com/in-toto/go-witness/cryptoutil"
+	"github.com/in-toto/go-witness/dsse"
+	"github.com/in-toto/witness/options"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseRunManifest(t *testing.T) {
+	manifest, err := parseRunManifest(strings.NewReader(`
+attestors:
+  - name: git
+  - name: secretscan
+    options:
+      fail-on-detection: true
+`))
+	require.NoError(t, err)
+	require.Len(t, manifest.Attestors, 2)
+	assert.Equal(t, "git", manifest.Attestors[0].Name)
+	assert.Contains(t, manifest.Attestors[1].Options, "fail-on-detection")
+
+	_, err = parseRunManifest(strings.NewReader("attestor:\n  - name: git\n"))
+	assert.ErrorContains(t, err, "field attestor not found")
+
+	manifest, err = parseRunManifest(strings.NewReader(""))
+	require.NoError(t, err)
+	assert.Empty(t, manifest.Attestors)
+}
+
+func Test_runManifestOptions(t *testing.T) {
+	manifest, err := parseRunManifest(strings.NewReader(`
+attestors:
+  - name: product
+    options:
+      include-glob: "*.txt"
+  - name: secretscan
+    options:
+      fail-on-detection: true
+      max-file-size-mb: 20
+      allowlist-regex: single
+`))
+	require.NoError(t, err)
+	opts, err := manifest.options(attestation.RegistrationEntries())
+	require.NoError(t, err)
+	names := []string{}
+	for _, opt := range opts {
+		names = append(names, opt.attestor+"."+opt.name)
+	}
+
+	assert.Equal(t, []string{"product.include-glob", "secretscan.allowlist-regex", "secretscan.fail-on-detection", "secretscan.max-file-size-mb"}, names)
+
+	manifest, err = parseRunManifest(strings.NewReader(`
+attestors:
+  - name: gti
+  - name: secretscan
+    options:
+      fail-on-detectoin: true
+      max-file-size-mb: [20]
+  - name: git
+  - name: git
+  - options: {}
+`))
+	require.NoError(t, err)
+	_, err = manifest.options(attestation.RegistrationEntries())
+	require.Error(t, err)
+	assert.Equal(t, strings.Join([]string{
+		`unknown attestor "gti", did you mean "git"?`,
+		"line 6: unknown option fail-on-detectoin of attestor secretscan, did you mean fail-on-detection?",
+		"line 7: option max-file-size-mb of attestor secretscan expects an integer, got a list",
+		"attestor git is listed more than once",
+		"attestor without a name",
+	}, "\n"), err.Error())
+}
+
+// manifestRunOptions returns run options with their flags registered on a run command, the way RunCmd sets them up
+func manifestRunOptions(manifestPath string) (*options.RunOptions, *cobra.Command) {
+	ro := &options.RunOptions{
+		SignerOptions:            options.SignerOptions{},
+		KMSSignerProviderOptions: options.KMSSignerProviderOptions{},
+	}
+
+	cmd := &cobra.Command{Use: "run"}
+	ro.AddFlags(cmd)
+	ro.ManifestPath = manifestPath
+	return ro, cmd
+}
+
+func Test_applyRunManifest(t *testing.T) {
+	manifestPath := filepath.Join(t.TempDir(), "manifest.yaml")
+	require.NoError(t, os.WriteFile(manifestPath, []byte(`
+attestors:
+  - name: product
+  - name: git
+  - name: secretscan
+    options:
+      fail-on-detection: true
+      max-file-size-mb: 20
+`), 0644))
+
+	ro, cmd := manifestRunOptions(manifestPath)
+	flagSetters := len(ro.AttestorOptSetters["secretscan"])
+	require.NoError(t, applyRunManifest(ro, cmd.Flags()))
+	assert.Equal(t, []string{"git", "secretscan"}, ro.Attestations)
+	assert.Len(t, ro.AttestorOptSetters["secretscan"], flagSetters+2)
+
+	ro, cmd = manifestRunOptions(manifestPath)
+	require.NoError(t, cmd.Flags().Set("attestations", "environment,git"))
+	require.NoError(t, cmd.Flags().Set("attestor-secretscan-max-file-size-mb", "5"))
+	require.NoError(t, applyRunManifest(ro, cmd.Flags()))
+	assert.Equal(t, []string{"environment", "git", "secretscan"}, ro.Attestations)
+	assert.Len(t, ro.AttestorOptSetters["secretscan"], flagSetters+1)
+
+	ro, cmd = manifestRunOptions(manifestPath)
+	ro.ManifestPath = filepath.Join(t.TempDir(), "missing.yaml")
+	assert.ErrorContains(t, applyRunManifest(ro, cmd.Flags()), "failed to open run manifest")
+}
+
+func TestRunManifestProductOptions(t *testing.T) {
+	privatekey, err := rsa.GenerateKey(rand.Reader, keybits)
+	require.NoError(t, err)
+	signer := cryptoutil.NewRSASigner(privatekey, crypto.SHA256)
+
+	workingDir := t.TempDir()
+	manifestPath := filepath.Join(t.TempDir(), "manifest.yaml")
+	require.NoError(t, os.WriteFile(manifestPath, []byte("attestors:\n  - name: product\n    options:\n      include-glob: \"*.txt\"\n"), 0644))
+
+	ro, cmd := manifestRunOptions(manifestPath)
+	ro.WorkingDir = workingDir
+	ro.OutFilePath = filepath.Join(t.TempDir(), "attestation.json")
+	ro.StepName = "teststep"
+	require.NoError(t, applyRunManifest(ro, cmd.Flags()))
+
+	args := []string{"bash", "-c", "echo test > test.txt && echo test > test.bin"}
+	require.NoError(t, runRun(context.Background(), *ro, args, signer))
+
+	attestationBytes, err := os.ReadFile(ro.OutFilePath)
+	require.NoError(t, err)
+	env := dsse.Envelope{}
+	require.NoError(t, json.Unmarshal(attestationBytes, &env))
+	statement := struct {
+		Subject []struct {
+			Name string `json:"name"`
+		} `json:"subject"`
+	}{}
+	require.NoError(t, json.Unmarshal(env.Payload, &statement))
+
+	subjects := []string{}
+	for _, subject := range statement.Subject {
+		if strings.HasPrefix(subject.Name, "https://witness.dev/attestations/product/v0.1/file:") {
+			subjects = append(subjects, strings.TrimPrefix(subject.Name, "https://witness.dev/attestations/product/v0.1/file:"))
+		}
+	}
+
+	assert.Equal(t, []string{"test.txt"}, subjects)
+}
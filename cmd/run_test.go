@@ -83,7 +83,7 @@ func Test_runRunRSACA(t *testing.T) {
 		},
 	}
 
-	signers, err := loadSigners(context.Background(), signerOptions, options.KMSSignerProviderOptions{}, map[string]struct{}{"file": {}})
+	signers, err := loadSigners(context.Background(), signerOptions, options.KMSSignerProviderOptions{}, map[string][]string{"file": nil}, false)
 	require.NoError(t, err)
 
 	workingDir := t.TempDir()
@@ -246,3 +246,16 @@ func TestRunDuplicateAttestors(t *testing.T) {
 		})
 	}
 }
+
+func Test_checkAttestations(t *testing.T) {
+	assert.NoError(t, checkAttestations([]string{"environment", "git", "https://witness.dev/attestations/sbom/v0.1", "command-run"}, true))
+	assert.NoError(t, checkAttestations(nil, false))
+
+	err := checkAttestations([]string{"gti", "environment", "policyverify", "nothing-like-this"}, true)
+	require.Error(t, err)
+	assert.Equal(t, strings.Join([]string{
+		`invalid attestations: unknown attestor "gti", did you mean "git"?`,
+		"attestor policyverify runs during witness verify and can't be recorded by witness run",
+		`unknown attestor "nothing-like-this"`,
+	}, "\n"), err.Error())
+}
@@ -15,15 +15,20 @@
 package cmd
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
-	"os"
+	"io"
+	"strings"
 
-	witness "github.com/in-toto/go-witness"
 	"github.com/in-toto/go-witness/cryptoutil"
 	"github.com/in-toto/go-witness/dsse"
+	"github.com/in-toto/go-witness/intoto"
 	"github.com/in-toto/go-witness/log"
 	"github.com/in-toto/go-witness/timestamp"
+	"github.com/in-toto/witness/internal/detached"
+	"github.com/in-toto/witness/internal/stdio"
 	"github.com/in-toto/witness/options"
 	"github.com/spf13/cobra"
 )
@@ -37,12 +42,12 @@ func SignCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:               "sign [file]",
 		Short:             "Signs a file",
-		Long:              "Signs a file with the provided key source and outputs the signed file to the specified destination",
+		Long:              "Signs a file, or an in-toto statement wrapping a predicate, with the provided key source and outputs the signed envelope to the specified destination",
 		SilenceErrors:     true,
 		SilenceUsage:      true,
 		DisableAutoGenTag: true,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			signers, err := loadSigners(cmd.Context(), so.SignerOptions, so.KMSSignerProviderOptions, providersFromFlags("signer", cmd.Flags()))
+			signers, err := loadSigners(cmd.Context(), so.SignerOptions, so.KMSSignerProviderOptions, providersFromFlags("signer", cmd.Flags()), so.SignerStrict)
 			if err != nil {
 				return fmt.Errorf("failed to load signer: %w", err)
 			}
@@ -58,7 +63,8 @@ func SignCmd() *cobra.Command {
 // todo: this logic should be broken out and moved to pkg/
 // we need to abstract where keys are coming from, etc
 func runSign(ctx context.Context, so options.SignOptions, signers ...cryptoutil.Signer) error {
-	if len(signers) > 1 {
+	// countersigning adds a signature for every signer
+	if len(signers) > 1 && so.AppendPath == "" {
 		return fmt.Errorf("only one signer is supported")
 	}
 
@@ -66,16 +72,93 @@ func runSign(ctx context.Context, so options.SignOptions, signers ...cryptoutil.
 		return fmt.Errorf("no signers found")
 	}
 
+	if err := stdio.CheckSingleStdin(so.InFilePath, so.PredicatePath, so.AppendPath); err != nil {
+		return err
+	}
+
+	if so.AppendPath == "" && (len(so.ExistingKeyPaths) > 0 || len(so.ExistingCARootPaths) > 0 || len(so.ExistingTimestampCertPaths) > 0) {
+		return fmt.Errorf("existing signatures can only be verified when countersigning with --append")
+	}
+
 	timestampers := []timestamp.Timestamper{}
 	for _, url := range so.TimestampServers {
 		timestampers = append(timestampers, timestamp.NewTimestamper(timestamp.TimestampWithUrl(url)))
 	}
 
-	inFile, err := os.Open(so.InFilePath)
-	if err != nil {
-		return fmt.Errorf("failed to open file to sign: %w", err)
+	var (
+		existing         dsse.Envelope
+		existingDetached bool
+	)
+	if so.AppendPath != "" {
+		var err error
+		if existing, existingDetached, err = readEnvelope(so.AppendPath); err != nil {
+			return err
+		}
+
+		if err := verifyExistingSignatures(existing, so); err != nil {
+			return err
+		}
+	}
+
+	// detached signatures of a file are written next to it unless told otherwise
+	if so.Detached && so.OutFilePath == "" && so.InFilePath != "" && !stdio.IsStdio(so.InFilePath) {
+		so.OutFilePath = so.InFilePath + detached.SignatureExtension
+	}
+
+	var (
+		env dsse.Envelope
+		err error
+	)
+	if so.AppendPath != "" {
+		if env, err = appendSignatures(existing, timestampers, signers...); err != nil {
+			return err
+		}
+	} else {
+		var in io.Reader
+		dataType := so.DataType
+		if so.PredicatePath != "" {
+			statement, err := buildStatement(so)
+			if err != nil {
+				return err
+			}
+
+			in, dataType = bytes.NewReader(statement), intoto.PayloadType
+		} else {
+			inFile, err := stdio.Open(so.InFilePath)
+			if err != nil {
+				return fmt.Errorf("failed to open file to sign: %w", err)
+			}
+
+			defer func() {
+				if err := inFile.Close(); err != nil {
+					log.Errorf("failed to close file to sign: %w", err)
+				}
+			}()
+
+			in = inFile
+		}
+
+		if env, err = dsse.Sign(dataType, in, dsse.SignWithSigners(signers[0]), dsse.SignWithTimestampers(timestampers...)); err != nil {
+			return err
+		}
 	}
 
+	if so.VerifyAfter {
+		for _, signer := range signers {
+			if err := verifyAfterSign(env, so, signer); err != nil {
+				return err
+			}
+		}
+	}
+
+	// countersigning a detached envelope keeps it detached
+	var out any = env
+	if so.Detached || existingDetached {
+		out = detached.Detach(env)
+	}
+
+	// nothing is written until the envelope was signed and verified, so a failure doesn't truncate an envelope being
+	// countersigned in place
 	outFile, err := loadOutfile(so.OutFilePath)
 	if err != nil {
 		return err
@@ -87,5 +170,39 @@ func runSign(ctx context.Context, so options.SignOptions, signers ...cryptoutil.
 		}
 	}()
 
-	return witness.Sign(inFile, so.DataType, outFile, dsse.SignWithSigners(signers[0]), dsse.SignWithTimestampers(timestampers...))
+	return json.NewEncoder(outFile).Encode(out)
+}
+
+// verifyAfterSign verifies the signed envelope before it is written and prints the identity of the signer along with
+// the flags witness verify needs to trust it
+func verifyAfterSign(env dsse.Envelope, so options.SignOptions, signer cryptoutil.Signer) error {
+	cert, err := verifySignedEnvelope(env, signer)
+	if err != nil {
+		return err
+	}
+
+	keyID, err := signer.KeyID()
+	if err != nil {
+		return fmt.Errorf("failed to get signer key id: %w", err)
+	}
+
+	log.Infof("Verified signed envelope with signer %s", keyID)
+	if cert != nil {
+		if err := logSignerIdentity(cert); err != nil {
+			return err
+		}
+	}
+
+	flags, err := policyVerifyFlags(cert, signatureTimestamped(env, keyID))
+	if err != nil {
+		return err
+	}
+
+	policyPath := "<signed policy>"
+	if so.OutFilePath != "" && !stdio.IsStdio(so.OutFilePath) {
+		policyPath = shellQuote(so.OutFilePath)
+	}
+
+	log.Infof("Verify with: witness verify -p %s %s", policyPath, strings.Join(flags, " "))
+	return nil
 }
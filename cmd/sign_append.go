@@ -0,0 +1,130 @@
+// Copyright 2026 The Witness Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"github.com/in-toto/go-witness/cryptoutil"
+	"github.com/in-toto/go-witness/dsse"
+	"github.com/in-toto/go-witness/log"
+	"github.com/in-toto/go-witness/timestamp"
+	"github.com/in-toto/witness/internal/detached"
+	"github.com/in-toto/witness/options"
+)
+
+// readEnvelope reads a DSSE envelope from disk, reattaching the payload of a detached envelope from the file it was
+// named after. The returned bool reports whether the envelope was detached.
+func readEnvelope(path string) (dsse.Envelope, bool, error) {
+	return detached.LoadFile(path, "")
+}
+
+// verifyExistingSignatures checks that every signature on the envelope verifies with one of the public keys or
+// chains to one of the roots passed with the --verify-existing-* flags. Nothing is checked when none were passed.
+func verifyExistingSignatures(env dsse.Envelope, so options.SignOptions) error {
+	if len(so.ExistingKeyPaths) == 0 && len(so.ExistingCARootPaths) == 0 {
+		return nil
+	}
+
+	verifiers := []cryptoutil.Verifier{}
+	for _, path := range so.ExistingKeyPaths {
+		keyBytes, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read key file: %w", err)
+		}
+
+		verifier, err := cryptoutil.NewVerifierFromReader(bytes.NewReader(keyBytes))
+		if err != nil {
+			return fmt.Errorf("failed to create verifier: %w", err)
+		}
+
+		verifiers = append(verifiers, verifier)
+	}
+
+	roots, err := loadCertificates(so.ExistingCARootPaths)
+	if err != nil {
+		return fmt.Errorf("failed to load root CA certificates: %w", err)
+	}
+
+	tsaCerts, err := loadCertificates(so.ExistingTimestampCertPaths)
+	if err != nil {
+		return fmt.Errorf("failed to load timestamp authority certificates: %w", err)
+	}
+
+	opts := []dsse.VerificationOption{dsse.VerifyWithVerifiers(verifiers...), dsse.VerifyWithRoots(roots...)}
+	if len(tsaCerts) > 0 {
+		opts = append(opts, dsse.VerifyWithTimestampVerifiers(timestamp.NewVerifier(timestamp.VerifyWithCerts(tsaCerts))))
+	}
+
+	for i, sig := range env.Signatures {
+		single := env
+		single.Signatures = []dsse.Signature{sig}
+		if _, err := single.Verify(opts...); err != nil {
+			return fmt.Errorf("existing signature %d with key id %s failed verification: %w", i, sig.KeyID, err)
+		}
+	}
+
+	return nil
+}
+
+// appendSignatures signs the payload of the envelope as is and adds a signature for each signer. A signature made by
+// the same key as one already on the envelope replaces it.
+func appendSignatures(env dsse.Envelope, timestampers []timestamp.Timestamper, signers ...cryptoutil.Signer) (dsse.Envelope, error) {
+	signed, err := dsse.Sign(env.PayloadType, bytes.NewReader(env.Payload), dsse.SignWithSigners(signers...), dsse.SignWithTimestampers(timestampers...))
+	if err != nil {
+		return env, fmt.Errorf("failed to sign envelope payload: %w", err)
+	}
+
+	signatures := append([]dsse.Signature{}, env.Signatures...)
+	for _, sig := range signed.Signatures {
+		replaced := false
+		for i := range signatures {
+			if signatures[i].KeyID == sig.KeyID {
+				log.Infof("Replacing existing signature with key id %s", sig.KeyID)
+				signatures[i], replaced = sig, true
+				break
+			}
+		}
+
+		if !replaced {
+			signatures = append(signatures, sig)
+		}
+	}
+
+	env.Signatures = signatures
+	return env, nil
+}
+
+func loadCertificates(paths []string) ([]*x509.Certificate, error) {
+	certs := []*x509.Certificate{}
+	for _, path := range paths {
+		certBytes, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read certificate file: %w", err)
+		}
+
+		cert, err := cryptoutil.TryParseCertificate(certBytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse certificate %s: %w", path, err)
+		}
+
+		certs = append(certs, cert)
+	}
+
+	return certs, nil
+}
@@ -0,0 +1,185 @@
+// Copyright 2026 The Witness Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"crypto"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	attestationv1 "github.com/in-toto/attestation/go/v1"
+	"github.com/in-toto/go-witness/attestation"
+	"github.com/in-toto/go-witness/cryptoutil"
+	"github.com/in-toto/go-witness/log"
+	"github.com/in-toto/witness/internal/stdio"
+	"github.com/in-toto/witness/options"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// buildStatement wraps the predicate in an in-toto v1 statement about the subjects of the sign options
+func buildStatement(so options.SignOptions) ([]byte, error) {
+	predicateBytes, err := stdio.ReadFile(so.PredicatePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read predicate: %w", err)
+	}
+
+	predicate := &structpb.Struct{}
+	if err := protojson.Unmarshal(predicateBytes, predicate); err != nil {
+		return nil, fmt.Errorf("failed to parse predicate, it must be a JSON object: %w", err)
+	}
+
+	if err := validatePredicate(so.PredicateType, predicateBytes); err != nil {
+		return nil, err
+	}
+
+	subjects, err := statementSubjects(so.Subjects, so.SubjectFiles)
+	if err != nil {
+		return nil, err
+	}
+
+	statement := &attestationv1.Statement{
+		Type:          attestationv1.StatementTypeUri,
+		Subject:       subjects,
+		PredicateType: so.PredicateType,
+		Predicate:     predicate,
+	}
+
+	if err := statement.Validate(); err != nil {
+		return nil, fmt.Errorf("failed to build statement: %w", err)
+	}
+
+	return protojson.Marshal(statement)
+}
+
+// statementSubjects parses name=algorithm:digest subjects and digests the subject files. Digests of subjects that
+// share a name are merged into a single subject.
+func statementSubjects(subjectFlags, subjectFiles []string) ([]*attestationv1.ResourceDescriptor, error) {
+	subjects := []*attestationv1.ResourceDescriptor{}
+	byName := map[string]*attestationv1.ResourceDescriptor{}
+	add := func(name string, digests map[string]string) {
+		subject, ok := byName[name]
+		if !ok {
+			subject = &attestationv1.ResourceDescriptor{Name: name, Digest: map[string]string{}}
+			byName[name] = subject
+			subjects = append(subjects, subject)
+		}
+
+		for alg, digest := range digests {
+			subject.Digest[alg] = digest
+		}
+	}
+
+	for _, s := range subjectFlags {
+		i := strings.LastIndex(s, "=")
+		if i <= 0 {
+			return nil, fmt.Errorf("invalid subject %q, expected name=algorithm:digest", s)
+		}
+
+		alg, digest, ok := strings.Cut(s[i+1:], ":")
+		if !ok || alg == "" || digest == "" {
+			return nil, fmt.Errorf("invalid subject digest %q, expected algorithm:digest", s[i+1:])
+		}
+
+		add(s[:i], map[string]string{alg: digest})
+	}
+
+	for _, path := range subjectFiles {
+		ds, err := cryptoutil.CalculateDigestSetFromFile(path, []cryptoutil.DigestValue{{Hash: crypto.SHA256}})
+		if err != nil {
+			return nil, fmt.Errorf("failed to digest subject file %s: %w", path, err)
+		}
+
+		digests, err := ds.ToNameMap()
+		if err != nil {
+			return nil, fmt.Errorf("failed to digest subject file %s: %w", path, err)
+		}
+
+		add(path, digests)
+	}
+
+	return subjects, nil
+}
+
+// validatePredicate validates the predicate against the schema of the attestor registered for the predicate type.
+// Predicate types without a registered attestor aren't validated.
+func validatePredicate(predicateType string, predicate []byte) error {
+	factory, ok := attestation.FactoryByType(predicateType)
+	if !ok {
+		log.Debugf("no attestor is registered for predicate type %s, skipping schema validation", predicateType)
+		return nil
+	}
+
+	attestor := factory()
+	schema := attestor.Schema()
+	if schema == nil {
+		return nil
+	}
+
+	schemaBytes, err := json.Marshal(schema)
+	if err != nil {
+		return fmt.Errorf("failed to marshal schema of the %s attestor: %w", attestor.Name(), err)
+	}
+
+	// attestor schemas are draft 2020-12, which is also what the compiler assumes for schemas not declaring a draft
+	schemaDoc, err := jsonschema.UnmarshalJSON(bytes.NewReader(schemaBytes))
+	if err != nil {
+		return fmt.Errorf("failed to parse schema of the %s attestor: %w", attestor.Name(), err)
+	}
+
+	compiler := jsonschema.NewCompiler()
+	schemaURL := attestor.Type()
+	if err := compiler.AddResource(schemaURL, schemaDoc); err != nil {
+		return fmt.Errorf("failed to load schema of the %s attestor: %w", attestor.Name(), err)
+	}
+
+	compiled, err := compiler.Compile(schemaURL)
+	if err != nil {
+		return fmt.Errorf("failed to compile schema of the %s attestor: %w", attestor.Name(), err)
+	}
+
+	predicateDoc, err := jsonschema.UnmarshalJSON(bytes.NewReader(predicate))
+	if err != nil {
+		return fmt.Errorf("failed to parse predicate: %w", err)
+	}
+
+	err = compiled.Validate(predicateDoc)
+	validationErr := &jsonschema.ValidationError{}
+	if errors.As(err, &validationErr) {
+		problems := []string{}
+		for _, unit := range validationErr.BasicOutput().Errors {
+			if unit.Error == nil {
+				continue
+			}
+
+			location := unit.InstanceLocation
+			if location == "" {
+				location = "(root)"
+			}
+
+			problems = append(problems, fmt.Sprintf("%s: %s", location, unit.Error))
+		}
+
+		return fmt.Errorf("predicate does not match the schema of the %s attestor: %s", attestor.Name(), strings.Join(problems, "; "))
+	} else if err != nil {
+		return fmt.Errorf("failed to validate predicate against the schema of the %s attestor: %w", attestor.Name(), err)
+	}
+
+	return nil
+}
@@ -15,15 +15,29 @@
 package cmd
 
 import (
+	"bytes"
 	"context"
 	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/url"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/in-toto/go-witness/cryptoutil"
+	"github.com/in-toto/go-witness/dsse"
+	"github.com/in-toto/go-witness/timestamp"
 	"github.com/in-toto/witness/options"
+	"github.com/sigstore/fulcio/pkg/certificate"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -73,3 +87,379 @@ func Test_SignCmd(t *testing.T) {
 	require.NotNil(t, cmd2)
 	assert.NotSame(t, cmd, cmd2, "Each call to SignCmd should create a new command")
 }
+
+func Test_runSignVerifyAfter(t *testing.T) {
+	privatekey, err := rsa.GenerateKey(rand.Reader, keybits)
+	require.NoError(t, err)
+	signer := cryptoutil.NewRSASigner(privatekey, crypto.SHA256)
+
+	workingDir := t.TempDir()
+	require.NoError(t, os.WriteFile(workingDir+"test.txt", []byte("test"), 0644))
+	signOptions := options.SignOptions{
+		DataType:    "text",
+		OutFilePath: workingDir + "outfile.txt",
+		InFilePath:  workingDir + "test.txt",
+		VerifyAfter: true,
+	}
+
+	require.NoError(t, runSign(context.Background(), signOptions, signer))
+	signedBytes, err := os.ReadFile(workingDir + "outfile.txt")
+	require.NoError(t, err)
+	assert.True(t, len(signedBytes) > 0)
+}
+
+// keylessSigner returns a signer with a certificate shaped like the ones fulcio issues
+func keylessSigner(t *testing.T, withRoots bool) *cryptoutil.X509Signer {
+	newKey := func() *ecdsa.PrivateKey {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		return key
+	}
+
+	create := func(template, parent *x509.Certificate, pub, priv any) *x509.Certificate {
+		der, err := x509.CreateCertificate(rand.Reader, template, parent, pub, priv)
+		require.NoError(t, err)
+		cert, err := x509.ParseCertificate(der)
+		require.NoError(t, err)
+		return cert
+	}
+
+	rootKey, intermediateKey, leafKey := newKey(), newKey(), newKey()
+	ca := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "sigstore"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+
+	root := create(ca, ca, rootKey.Public(), rootKey)
+	ca.SerialNumber, ca.Subject = big.NewInt(2), pkix.Name{CommonName: "sigstore-intermediate"}
+	intermediate := create(ca, root, intermediateKey.Public(), rootKey)
+
+	extensions, err := certificate.Extensions{
+		Issuer:              "https://token.actions.githubusercontent.com",
+		SourceRepositoryRef: "refs/heads/main",
+	}.Render()
+	require.NoError(t, err)
+	uri, err := url.Parse("https://github.com/in-toto/witness/.github/workflows/release.yml@refs/heads/main")
+	require.NoError(t, err)
+	leaf := create(&x509.Certificate{
+		SerialNumber:    big.NewInt(3),
+		NotBefore:       time.Now().Add(-time.Minute),
+		NotAfter:        time.Now().Add(10 * time.Minute),
+		KeyUsage:        x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:     []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+		URIs:            []*url.URL{uri},
+		ExtraExtensions: extensions,
+	}, intermediate, leafKey.Public(), intermediateKey)
+
+	roots := []*x509.Certificate{}
+	if withRoots {
+		roots = append(roots, root)
+	}
+
+	signer, err := cryptoutil.NewX509Signer(cryptoutil.NewECDSASigner(leafKey, crypto.SHA256), leaf, []*x509.Certificate{intermediate}, roots)
+	require.NoError(t, err)
+	return signer
+}
+
+func Test_verifySignedEnvelope(t *testing.T) {
+	for name, withRoots := range map[string]bool{"with roots": true, "without roots": false} {
+		t.Run(name, func(t *testing.T) {
+			signer := keylessSigner(t, withRoots)
+			env, err := dsse.Sign("text", bytes.NewReader([]byte("test")), dsse.SignWithSigners(signer))
+			require.NoError(t, err)
+
+			cert, err := verifySignedEnvelope(env, signer)
+			require.NoError(t, err)
+			assert.Equal(t, signer.Certificate().Raw, cert.Raw)
+
+			// an envelope without the bundled chain can't be verified without extra flags
+			env.Signatures[0].Intermediates = nil
+			_, err = verifySignedEnvelope(env, signer)
+			require.ErrorContains(t, err, "contains 0 intermediates")
+		})
+	}
+}
+
+func Test_policyVerifyFlags(t *testing.T) {
+	flags, err := policyVerifyFlags(keylessSigner(t, false).Certificate(), false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"--policy-ca-roots <root certificate of CN=sigstore-intermediate>",
+		"--policy-uris https://github.com/in-toto/witness/.github/workflows/release.yml@refs/heads/main",
+		"--policy-fulcio-oidc-issuer https://token.actions.githubusercontent.com",
+		"--policy-fulcio-source-repository-ref refs/heads/main",
+	}, flags)
+
+	flags, err = policyVerifyFlags(nil, false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"--publickey <signer public key>"}, flags)
+
+	assert.Equal(t, `'Witness Testing'`, shellQuote("Witness Testing"))
+	assert.Equal(t, `'it'\''s'`, shellQuote("it's"))
+}
+
+func Test_policyVerifyFlagsTimestamped(t *testing.T) {
+	signer := keylessSigner(t, false)
+	env, err := dsse.Sign("https://witness.testifysec.com/policy/v0.1", bytes.NewReader([]byte("{}")),
+		dsse.SignWithSigners(signer),
+		dsse.SignWithTimestampers(timestamp.FakeTimestamper{T: time.Now()}),
+	)
+	require.NoError(t, err)
+
+	keyID, err := signer.KeyID()
+	require.NoError(t, err)
+	require.True(t, signatureTimestamped(env, keyID))
+	require.False(t, signatureTimestamped(env, "other"))
+
+	flags, err := policyVerifyFlags(signer.Certificate(), signatureTimestamped(env, keyID))
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"--policy-ca-roots <root certificate of CN=sigstore-intermediate>",
+		"--policy-timestamp-servers <certificate of the timestamp authority>",
+		"--policy-uris https://github.com/in-toto/witness/.github/workflows/release.yml@refs/heads/main",
+		"--policy-fulcio-oidc-issuer https://token.actions.githubusercontent.com",
+		"--policy-fulcio-source-repository-ref refs/heads/main",
+	}, flags)
+
+	flags, err = policyVerifyFlags(nil, true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"--publickey <signer public key>", "--policy-timestamp-servers <certificate of the timestamp authority>"}, flags)
+}
+
+func Test_runSignPredicate(t *testing.T) {
+	privatekey, err := rsa.GenerateKey(rand.Reader, keybits)
+	require.NoError(t, err)
+	signer := cryptoutil.NewRSASigner(privatekey, crypto.SHA256)
+
+	workingDir := t.TempDir()
+	predicatePath := filepath.Join(workingDir, "predicate.json")
+	subjectPath := filepath.Join(workingDir, "app.tar.gz")
+	require.NoError(t, os.WriteFile(predicatePath, []byte(`{"os": "linux", "hostname": "builder", "username": "ci"}`), 0644))
+	require.NoError(t, os.WriteFile(subjectPath, []byte("app"), 0644))
+
+	digest := "2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae"
+	signOptions := options.SignOptions{
+		OutFilePath:   filepath.Join(workingDir, "statement.json"),
+		PredicatePath: predicatePath,
+		PredicateType: "https://witness.dev/attestations/environment/v0.1",
+		Subjects:      []string{"foo=sha256:" + digest, "foo=gitCommit:0beec7b5ea3f0fdbc95d0dd47f3c5bc275da8a33"},
+		SubjectFiles:  []string{subjectPath},
+	}
+
+	require.NoError(t, runSign(context.Background(), signOptions, signer))
+	signedBytes, err := os.ReadFile(signOptions.OutFilePath)
+	require.NoError(t, err)
+	env := dsse.Envelope{}
+	require.NoError(t, json.Unmarshal(signedBytes, &env))
+	assert.Equal(t, "application/vnd.in-toto+json", env.PayloadType)
+
+	statement := struct {
+		Type    string `json:"_type"`
+		Subject []struct {
+			Name   string            `json:"name"`
+			Digest map[string]string `json:"digest"`
+		} `json:"subject"`
+		PredicateType string         `json:"predicateType"`
+		Predicate     map[string]any `json:"predicate"`
+	}{}
+	require.NoError(t, json.Unmarshal(env.Payload, &statement))
+	assert.Equal(t, "https://in-toto.io/Statement/v1", statement.Type)
+	assert.Equal(t, signOptions.PredicateType, statement.PredicateType)
+	assert.Equal(t, "linux", statement.Predicate["os"])
+	require.Len(t, statement.Subject, 2)
+	assert.Equal(t, "foo", statement.Subject[0].Name)
+	assert.Equal(t, map[string]string{"sha256": digest, "gitCommit": "0beec7b5ea3f0fdbc95d0dd47f3c5bc275da8a33"}, statement.Subject[0].Digest)
+	assert.Equal(t, subjectPath, statement.Subject[1].Name)
+	assert.Equal(t, "a172cedcae47474b615c54d510a5d84a8dea3032e958587430b413538be3f333", statement.Subject[1].Digest["sha256"])
+
+	// predicates of a registered attestor type have to match its schema
+	require.NoError(t, os.WriteFile(predicatePath, []byte(`{"os": "linux"}`), 0644))
+	require.ErrorContains(t, runSign(context.Background(), signOptions, signer), "does not match the schema of the environment attestor")
+
+	// other predicate types are signed as they are
+	signOptions.PredicateType = "https://example.com/predicate/v1"
+	require.NoError(t, runSign(context.Background(), signOptions, signer))
+
+	require.NoError(t, os.WriteFile(predicatePath, []byte(`["not", "an", "object"]`), 0644))
+	require.ErrorContains(t, runSign(context.Background(), signOptions, signer), "must be a JSON object")
+}
+
+func Test_statementSubjects(t *testing.T) {
+	for _, subject := range []string{"foo", "=sha256:abc", "foo=abc", "foo=sha256:"} {
+		_, err := statementSubjects([]string{subject}, nil)
+		require.Error(t, err, subject)
+	}
+
+	_, err := statementSubjects(nil, []string{filepath.Join(t.TempDir(), "missing")})
+	require.ErrorContains(t, err, "failed to digest subject file")
+}
+
+func Test_runSignAppend(t *testing.T) {
+	newSigner := func() (cryptoutil.Signer, string) {
+		privatekey, err := rsa.GenerateKey(rand.Reader, keybits)
+		require.NoError(t, err)
+		der, err := x509.MarshalPKIXPublicKey(&privatekey.PublicKey)
+		require.NoError(t, err)
+		pubPath := filepath.Join(t.TempDir(), "pub.pem")
+		require.NoError(t, os.WriteFile(pubPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0644))
+		return cryptoutil.NewRSASigner(privatekey, crypto.SHA256), pubPath
+	}
+
+	security, securityPub := newSigner()
+	release, releasePub := newSigner()
+	workingDir := t.TempDir()
+	policyPath := filepath.Join(workingDir, "policy-signed.json")
+	require.NoError(t, os.WriteFile(filepath.Join(workingDir, "policy.json"), []byte("{}"), 0644))
+	require.NoError(t, runSign(context.Background(), options.SignOptions{
+		DataType:    "https://witness.testifysec.com/policy/v0.1",
+		InFilePath:  filepath.Join(workingDir, "policy.json"),
+		OutFilePath: policyPath,
+	}, security))
+	original, _, err := readEnvelope(policyPath)
+	require.NoError(t, err)
+
+	// countersign in place after checking the existing signature
+	appendOptions := options.SignOptions{
+		AppendPath:       policyPath,
+		OutFilePath:      policyPath,
+		ExistingKeyPaths: []string{securityPub},
+		VerifyAfter:      true,
+	}
+	require.NoError(t, runSign(context.Background(), appendOptions, release))
+	countersigned, _, err := readEnvelope(policyPath)
+	require.NoError(t, err)
+	assert.Equal(t, original.Payload, countersigned.Payload)
+	assert.Equal(t, original.PayloadType, countersigned.PayloadType)
+	require.Len(t, countersigned.Signatures, 2)
+	assert.Equal(t, original.Signatures[0], countersigned.Signatures[0])
+
+	securityVerifier, err := security.Verifier()
+	require.NoError(t, err)
+	releaseVerifier, err := release.Verifier()
+	require.NoError(t, err)
+	_, err = countersigned.Verify(dsse.VerifyWithVerifiers(securityVerifier, releaseVerifier), dsse.VerifyWithThreshold(2))
+	require.NoError(t, err)
+
+	// signing again with the same key replaces its signature
+	appendOptions.ExistingKeyPaths = []string{securityPub, releasePub}
+	require.NoError(t, runSign(context.Background(), appendOptions, release))
+	resigned, _, err := readEnvelope(policyPath)
+	require.NoError(t, err)
+	assert.Len(t, resigned.Signatures, 2)
+
+	// the release signature can't be verified with only the security key
+	appendOptions.ExistingKeyPaths = []string{securityPub}
+	require.ErrorContains(t, runSign(context.Background(), appendOptions, release), "existing signature 1")
+
+	// a failed countersignature leaves the envelope being countersigned in place untouched
+	before, err := os.ReadFile(policyPath)
+	require.NoError(t, err)
+	failingOptions := options.SignOptions{
+		AppendPath:       policyPath,
+		OutFilePath:      policyPath,
+		TimestampServers: []string{"http://127.0.0.1:1"},
+	}
+	require.Error(t, runSign(context.Background(), failingOptions, release))
+	after, err := os.ReadFile(policyPath)
+	require.NoError(t, err)
+	assert.Equal(t, before, after)
+
+	appendOptions.AppendPath = ""
+	appendOptions.InFilePath = filepath.Join(workingDir, "policy.json")
+	require.ErrorContains(t, runSign(context.Background(), appendOptions, release), "only be verified when countersigning")
+}
+
+func Test_runSignDetached(t *testing.T) {
+	privatekey, err := rsa.GenerateKey(rand.Reader, keybits)
+	require.NoError(t, err)
+	signer := cryptoutil.NewRSASigner(privatekey, crypto.SHA256)
+
+	workingDir := t.TempDir()
+	policyPath := filepath.Join(workingDir, "policy.json")
+	require.NoError(t, os.WriteFile(policyPath, []byte("{}"), 0644))
+	require.NoError(t, runSign(context.Background(), options.SignOptions{
+		DataType:    "https://witness.testifysec.com/policy/v0.1",
+		InFilePath:  policyPath,
+		Detached:    true,
+		VerifyAfter: true,
+	}, signer))
+
+	sigBytes, err := os.ReadFile(policyPath + ".sig")
+	require.NoError(t, err)
+	assert.NotContains(t, string(sigBytes), `"payload":`)
+
+	env, isDetached, err := readEnvelope(policyPath + ".sig")
+	require.NoError(t, err)
+	assert.True(t, isDetached)
+	assert.Equal(t, []byte("{}"), env.Payload)
+
+	verifier, err := signer.Verifier()
+	require.NoError(t, err)
+	_, err = env.Verify(dsse.VerifyWithVerifiers(verifier))
+	require.NoError(t, err)
+
+	// countersigning keeps the envelope detached
+	require.NoError(t, runSign(context.Background(), options.SignOptions{
+		AppendPath:  policyPath + ".sig",
+		OutFilePath: policyPath + ".sig",
+	}, signer))
+	_, isDetached, err = readEnvelope(policyPath + ".sig")
+	require.NoError(t, err)
+	assert.True(t, isDetached)
+}
+
+// withStdio replaces stdin with the input and stdout with a file, returning a function that restores them and
+// returns what was written to stdout
+func withStdio(t *testing.T, input []byte) func() []byte {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "stdin"), input, 0644))
+	stdin, err := os.Open(filepath.Join(dir, "stdin"))
+	require.NoError(t, err)
+	stdout, err := os.Create(filepath.Join(dir, "stdout"))
+	require.NoError(t, err)
+
+	originalStdin, originalStdout := os.Stdin, os.Stdout
+	os.Stdin, os.Stdout = stdin, stdout
+	return func() []byte {
+		os.Stdin, os.Stdout = originalStdin, originalStdout
+		require.NoError(t, stdin.Close())
+		output, err := os.ReadFile(filepath.Join(dir, "stdout"))
+		require.NoError(t, err)
+		return output
+	}
+}
+
+func Test_runSignPipeline(t *testing.T) {
+	privatekey, err := rsa.GenerateKey(rand.Reader, keybits)
+	require.NoError(t, err)
+	signer := cryptoutil.NewRSASigner(privatekey, crypto.SHA256)
+
+	policyBytes, err := os.ReadFile("../test/policy.json")
+	require.NoError(t, err)
+
+	restore := withStdio(t, policyBytes)
+	err = runSign(context.Background(), options.SignOptions{
+		DataType:    "https://witness.testifysec.com/policy/v0.1",
+		InFilePath:  "-",
+		OutFilePath: "-",
+	}, signer)
+	signed := restore()
+	require.NoError(t, err)
+
+	restore = withStdio(t, signed)
+	p, isDSSE, err := ReadPolicy("-", false)
+	restore()
+	require.NoError(t, err)
+	assert.True(t, isDSSE)
+	assert.NotEmpty(t, p.Steps)
+
+	require.ErrorContains(t, runSign(context.Background(), options.SignOptions{
+		AppendPath:    "-",
+		PredicatePath: "-",
+	}, signer), "only one input")
+}
@@ -0,0 +1,227 @@
+// Copyright 2026 The Witness Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"crypto/x509"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/in-toto/go-witness/cryptoutil"
+	"github.com/in-toto/go-witness/dsse"
+	"github.com/in-toto/go-witness/log"
+	"github.com/sigstore/fulcio/pkg/certificate"
+)
+
+// fulcioPolicyFlags maps the fields of the fulcio certificate extensions to the verify flags that constrain them
+var fulcioPolicyFlags = []struct {
+	field string
+	flag  string
+}{
+	{"Issuer", "policy-fulcio-oidc-issuer"},
+	{"BuildTrigger", "policy-fulcio-build-trigger"},
+	{"SourceRepositoryDigest", "policy-fulcio-source-repository-digest"},
+	{"RunInvocationURI", "policy-fulcio-run-invocation-uri"},
+	{"SourceRepositoryIdentifier", "policy-fulcio-source-repository-identifier"},
+	{"SourceRepositoryRef", "policy-fulcio-source-repository-ref"},
+}
+
+// verifySignedEnvelope verifies an envelope produced by witness sign with the signer that produced it. Certificate
+// signers must have bundled their certificate and intermediates into the signature, and the returned certificate is
+// the bundled one.
+func verifySignedEnvelope(env dsse.Envelope, signer cryptoutil.Signer) (*x509.Certificate, error) {
+	keyID, err := signer.KeyID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get signer key id: %w", err)
+	}
+
+	// only the signature made by the signer is verified, countersigned envelopes carry others as well
+	var sig *dsse.Signature
+	for i := range env.Signatures {
+		if env.Signatures[i].KeyID == keyID {
+			sig = &env.Signatures[i]
+		}
+	}
+
+	if sig == nil {
+		return nil, fmt.Errorf("signed envelope has no signature with key id %s", keyID)
+	}
+
+	env.Signatures = []dsse.Signature{*sig}
+
+	bundler, ok := signer.(cryptoutil.TrustBundler)
+	if !ok || bundler.Certificate() == nil {
+		verifier, err := signer.Verifier()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get verifier from signer: %w", err)
+		}
+
+		if _, err := env.Verify(dsse.VerifyWithVerifiers(verifier)); err != nil {
+			return nil, fmt.Errorf("failed to verify signed envelope: %w", err)
+		}
+
+		return nil, nil
+	}
+
+	cert, err := cryptoutil.TryParseCertificate(sig.Certificate)
+	if err != nil {
+		return nil, fmt.Errorf("signed envelope does not contain the signer's certificate: %w", err)
+	}
+
+	if !bytes.Equal(cert.Raw, bundler.Certificate().Raw) {
+		return nil, fmt.Errorf("certificate in the signed envelope does not match the signer's certificate")
+	}
+
+	if len(sig.Intermediates) != len(bundler.Intermediates()) {
+		return nil, fmt.Errorf("signed envelope contains %d intermediates, the signer has %d", len(sig.Intermediates), len(bundler.Intermediates()))
+	}
+
+	// without roots only the signature can be checked, the bundled chain is verified against the roots otherwise
+	opts := []dsse.VerificationOption{dsse.VerifyWithRoots(bundler.Roots()...)}
+	if len(bundler.Roots()) == 0 {
+		log.Warn("Signer has no root certificates, the certificate chain in the signed envelope was not verified")
+		verifier, err := cryptoutil.NewVerifier(cert.PublicKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create verifier from signer certificate: %w", err)
+		}
+
+		opts = []dsse.VerificationOption{dsse.VerifyWithVerifiers(verifier)}
+	}
+
+	if _, err := env.Verify(opts...); err != nil {
+		return nil, fmt.Errorf("failed to verify signed envelope: %w", err)
+	}
+
+	return cert, nil
+}
+
+// signatureTimestamped reports whether the signature made by the key has any timestamps
+func signatureTimestamped(env dsse.Envelope, keyID string) bool {
+	for _, sig := range env.Signatures {
+		if sig.KeyID == keyID && len(sig.Timestamps) > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// logSignerIdentity prints the identity in the signer's certificate
+func logSignerIdentity(cert *x509.Certificate) error {
+	log.Infof("Signer certificate subject: %s", cert.Subject)
+	log.Infof("Signer certificate issuer: %s", cert.Issuer)
+	for _, san := range certificateSANs(cert) {
+		log.Infof("Signer certificate SAN: %s", san)
+	}
+
+	extensions, err := certificate.ParseExtensions(cert.Extensions)
+	if err != nil {
+		return fmt.Errorf("failed to parse fulcio certificate extensions: %w", err)
+	}
+
+	v := reflect.ValueOf(extensions)
+	for _, field := range reflect.VisibleFields(v.Type()) {
+		if value := v.FieldByIndex(field.Index).String(); value != "" {
+			log.Infof("Signer certificate fulcio extension %s: %s", field.Name, value)
+		}
+	}
+
+	return nil
+}
+
+func certificateSANs(cert *x509.Certificate) []string {
+	sans := []string{}
+	for _, name := range cert.DNSNames {
+		sans = append(sans, "DNS:"+name)
+	}
+
+	for _, email := range cert.EmailAddresses {
+		sans = append(sans, "email:"+email)
+	}
+
+	for _, ip := range cert.IPAddresses {
+		sans = append(sans, "IP:"+ip.String())
+	}
+
+	for _, uri := range cert.URIs {
+		sans = append(sans, "URI:"+uri.String())
+	}
+
+	return sans
+}
+
+// policyVerifyFlags returns the witness verify flags that constrain the policy signer to the identity in the
+// certificate, or to the public key if the signer has no certificate. A timestamped signature also needs the
+// certificates of the timestamp authorities, which verify the signer's certificate at the time of the timestamp.
+func policyVerifyFlags(cert *x509.Certificate, timestamped bool) ([]string, error) {
+	flags := []string{}
+	if timestamped {
+		flags = append(flags, "--policy-timestamp-servers <certificate of the timestamp authority>")
+	}
+
+	if cert == nil {
+		return append([]string{"--publickey <signer public key>"}, flags...), nil
+	}
+
+	flags = append([]string{fmt.Sprintf("--policy-ca-roots <root certificate of %s>", cert.Issuer)}, flags...)
+	if cert.Subject.CommonName != "" {
+		flags = append(flags, "--policy-commonname "+shellQuote(cert.Subject.CommonName))
+	}
+
+	uris := []string{}
+	for _, uri := range cert.URIs {
+		uris = append(uris, uri.String())
+	}
+
+	for _, list := range []struct {
+		flag   string
+		values []string
+	}{
+		{"policy-dns-names", cert.DNSNames},
+		{"policy-emails", cert.EmailAddresses},
+		{"policy-organizations", cert.Subject.Organization},
+		{"policy-uris", uris},
+	} {
+		if len(list.values) > 0 {
+			flags = append(flags, fmt.Sprintf("--%s %s", list.flag, shellQuote(strings.Join(list.values, ","))))
+		}
+	}
+
+	extensions, err := certificate.ParseExtensions(cert.Extensions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse fulcio certificate extensions: %w", err)
+	}
+
+	for _, f := range fulcioPolicyFlags {
+		if value := reflect.ValueOf(extensions).FieldByName(f.field).String(); value != "" {
+			flags = append(flags, fmt.Sprintf("--%s %s", f.flag, shellQuote(value)))
+		}
+	}
+
+	return flags, nil
+}
+
+var shellSafe = regexp.MustCompile(`^[A-Za-z0-9@%+=:,./_-]+$`)
+
+func shellQuote(s string) string {
+	if shellSafe.MatchString(s) {
+		return s
+	}
+
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,250 @@
+// Copyright 2026 The Witness Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/in-toto/go-witness/cryptoutil"
+	"github.com/in-toto/go-witness/dsse"
+	"github.com/in-toto/go-witness/log"
+	"github.com/in-toto/go-witness/registry"
+	"github.com/in-toto/go-witness/signer"
+	"github.com/in-toto/go-witness/signer/kms"
+	"github.com/in-toto/witness/options"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+// signerTestPayloadType is the payload type of the envelope witness signers test signs
+const signerTestPayloadType = "https://witness.dev/signer-test/v0.1"
+
+func SignersCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "signers",
+		Short: "Get information about available signers and verifiers",
+		Long:  "Get information about the signer and verifier providers available in Witness, and test that a signer can sign",
+	}
+
+	cmd.AddCommand(SignersListCmd())
+	cmd.AddCommand(SignersTestCmd())
+
+	return cmd
+}
+
+func SignersListCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:               "list",
+		Short:             "List all available signer and verifier providers",
+		Long:              "Lists the signer providers, verifier providers and KMS backends available in Witness along with the flags that configure them",
+		SilenceErrors:     true,
+		SilenceUsage:      true,
+		DisableAutoGenTag: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSignersList(cmd.OutOrStdout())
+		},
+	}
+	return cmd
+}
+
+func SignersTestCmd() *cobra.Command {
+	sto := options.SignersTestOptions{
+		SignerOptions:              options.SignerOptions{},
+		KMSSignerProviderOptions:   options.KMSSignerProviderOptions{},
+		VerifierOptions:            options.VerifierOptions{},
+		KMSVerifierProviderOptions: options.KMSVerifierProviderOptions{},
+	}
+
+	cmd := &cobra.Command{
+		Use:               "test",
+		Short:             "Test the configured signer",
+		Long:              "Loads the signer configured with the --signer-* flags, signs a random nonce with it and verifies the signature with the verifiers configured with --publickey and the --verifier-* flags, the way witness verify loads them. Signers with a certificate are verified against their certificate chain as well and don't need a verifier. Prints the key ID and the identity in the signer's certificate, which helps debugging signer and verifier credentials before a real build",
+		SilenceErrors:     true,
+		SilenceUsage:      true,
+		DisableAutoGenTag: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			signers, err := loadSigners(cmd.Context(), sto.SignerOptions, sto.KMSSignerProviderOptions, providersFromFlags("signer", cmd.Flags()), sto.SignerStrict)
+			if err != nil {
+				return fmt.Errorf("failed to load signer: %w", err)
+			}
+
+			return runSignersTest(cmd.Context(), sto, providersFromFlags("verifier", cmd.Flags()), signers...)
+		},
+	}
+
+	sto.AddFlags(cmd)
+	return cmd
+}
+
+func runSignersList(w io.Writer) error {
+	items := [][]string{}
+	for _, entry := range sortedEntries(signer.RegistryEntries()) {
+		items = append(items, []string{entry.Name, "signer", strings.Join(providerFlags("signer", entry.Name, entry.Options), "\n")})
+	}
+
+	for _, entry := range sortedEntries(signer.VerifierRegistryEntries()) {
+		items = append(items, []string{entry.Name, "verifier", strings.Join(providerFlags("verifier", entry.Name, entry.Options), "\n")})
+	}
+
+	for _, backend := range kmsBackends() {
+		if backend.Disabled {
+			items = append(items, []string{backend.Scheme, "kms backend (disabled)", ""})
+			continue
+		}
+
+		opts := kms.ProviderOptions()[backend.Scheme].Init()
+		flags := append(providerFlags("signer", backend.Provider, opts), providerFlags("verifier", backend.Provider, opts)...)
+		items = append(items, []string{backend.Scheme, "kms backend", strings.Join(flags, "\n")})
+	}
+
+	table := tablewriter.NewWriter(w)
+	table.Header([]string{"Name", "Kind", "Flags"})
+	if err := table.Bulk(items); err != nil {
+		return fmt.Errorf("failed to add items to table: %w", err)
+	}
+
+	if err := table.Render(); err != nil {
+		return fmt.Errorf("failed to render table: %w", err)
+	}
+
+	return nil
+}
+
+func sortedEntries[T any](entries []registry.Entry[T]) []registry.Entry[T] {
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	return entries
+}
+
+// providerFlags returns the flags options.addFlags creates for the options of a provider
+func providerFlags(prefix, name string, opts []registry.Configurer) []string {
+	flags := make([]string, 0, len(opts))
+	for _, opt := range opts {
+		flags = append(flags, fmt.Sprintf("--%s-%s-%s", prefix, name, opt.Name()))
+	}
+
+	return flags
+}
+
+// runSignersTest signs a random nonce with each signer and verifies the signature, printing the key ID and the
+// identity of the signer
+func runSignersTest(ctx context.Context, sto options.SignersTestOptions, verifierProviders map[string][]string, signers ...cryptoutil.Signer) error {
+	if len(signers) == 0 {
+		return fmt.Errorf("no signers found, configure one with the --signer-* flags")
+	}
+
+	verifiers := []cryptoutil.Verifier{}
+	if len(verifierProviders) > 0 {
+		var err error
+		verifiers, err = loadVerifiers(ctx, sto.VerifierOptions, sto.KMSVerifierProviderOptions, verifierProviders, sto.VerifierStrict)
+		if err != nil {
+			return fmt.Errorf("failed to load verifiers: %w", err)
+		}
+	}
+
+	if sto.KeyPath != "" {
+		keyFile, err := os.Open(sto.KeyPath)
+		if err != nil {
+			return fmt.Errorf("failed to open key file: %w", err)
+		}
+
+		defer func() {
+			if err := keyFile.Close(); err != nil {
+				log.Errorf("failed to close key file: %w", err)
+			}
+		}()
+
+		v, err := cryptoutil.NewVerifierFromReader(keyFile)
+		if err != nil {
+			return fmt.Errorf("failed to create verifier: %w", err)
+		}
+
+		verifiers = append(verifiers, v)
+	}
+
+	for _, s := range signers {
+		if err := testSigner(s, verifiers); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// testSigner signs a nonce with the signer and verifies it with the verifiers. A signer with a certificate is verified
+// against the certificate chain it bundles as well, and only needs verifiers if any were configured.
+func testSigner(s cryptoutil.Signer, verifiers []cryptoutil.Verifier) error {
+	keyID, err := s.KeyID()
+	if err != nil {
+		return fmt.Errorf("failed to get signer key id: %w", err)
+	}
+
+	var cert *x509.Certificate
+	if bundler, ok := s.(cryptoutil.TrustBundler); ok {
+		cert = bundler.Certificate()
+	}
+
+	if cert == nil && len(verifiers) == 0 {
+		return fmt.Errorf("no verifiers found for signer %s, configure the ones witness verify will use with --publickey or the --verifier-* flags", keyID)
+	}
+
+	nonce := make([]byte, 32)
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	env, err := dsse.Sign(signerTestPayloadType, bytes.NewReader(nonce), dsse.SignWithSigners(s))
+	if err != nil {
+		return fmt.Errorf("failed to sign nonce with signer %s: %w", keyID, err)
+	}
+
+	if cert != nil {
+		if cert, err = verifySignedEnvelope(env, s); err != nil {
+			return fmt.Errorf("signer %s: %w", keyID, err)
+		}
+	}
+
+	if len(verifiers) > 0 {
+		checked, err := env.Verify(dsse.VerifyWithVerifiers(verifiers...))
+		if err != nil {
+			return fmt.Errorf("signer %s: failed to verify signed nonce with the configured verifiers: %w", keyID, err)
+		}
+
+		for _, c := range checked {
+			if c.Error != nil {
+				continue
+			}
+
+			if verifierID, err := c.Verifier.KeyID(); err == nil {
+				log.Infof("Signer %s verified with verifier %s", keyID, verifierID)
+			}
+		}
+	}
+
+	log.Infof("Signed and verified a nonce with signer %s", keyID)
+	if cert == nil {
+		log.Infof("Signer %s has no certificate", keyID)
+		return nil
+	}
+
+	return logSignerIdentity(cert)
+}
@@ -0,0 +1,139 @@
+// Copyright 2026 The Witness Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/in-toto/go-witness/cryptoutil"
+	"github.com/in-toto/go-witness/signer"
+	"github.com/in-toto/witness/options"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_SignersCmd(t *testing.T) {
+	cmd := SignersCmd()
+	require.NotNil(t, cmd)
+	require.Equal(t, "signers", cmd.Use)
+	require.Equal(t, 2, len(cmd.Commands()))
+}
+
+func Test_runSignersList(t *testing.T) {
+	out := &bytes.Buffer{}
+	require.NoError(t, runSignersList(out))
+
+	assert.Contains(t, out.String(), "--signer-file-key-path")
+	assert.Contains(t, out.String(), "awskms://")
+	assert.Contains(t, out.String(), "hashivault://")
+
+	// backends switched off with their no_kms_<backend> tag are listed without flags
+	for scheme, flags := range map[string][]string{
+		"awskms://":     {"--signer-kms-aws-config-file", "--verifier-kms-aws-config-file"},
+		"hashivault://": {"--verifier-kms-hashivault-addr"},
+	} {
+		_, disabled := disabledKMSBackends[scheme]
+		for _, flag := range flags {
+			if disabled {
+				assert.NotContains(t, out.String(), flag)
+			} else {
+				assert.Contains(t, out.String(), flag)
+			}
+		}
+	}
+
+	// pkcs11 is only registered, and its flags only offered, in cgo builds
+	_, err := signer.NewSignerProvider("pkcs11")
+	for _, flag := range []string{"--signer-pkcs11-module", "--verifier-pkcs11-module"} {
+		if err == nil {
+			assert.Contains(t, out.String(), flag)
+		} else {
+			assert.NotContains(t, out.String(), flag)
+		}
+	}
+}
+
+func Test_runSignersTest(t *testing.T) {
+	newRSASigner := func() cryptoutil.Signer {
+		key, err := rsa.GenerateKey(rand.Reader, keybits)
+		require.NoError(t, err)
+		return cryptoutil.NewRSASigner(key, crypto.SHA256)
+	}
+
+	// publicKeyFile writes the public key of the signer where --publickey can read it
+	publicKeyFile := func(s cryptoutil.Signer) string {
+		verifier, err := s.Verifier()
+		require.NoError(t, err)
+		pemBytes, err := verifier.Bytes()
+		require.NoError(t, err)
+		path := filepath.Join(t.TempDir(), "key.pub")
+		require.NoError(t, os.WriteFile(path, pemBytes, 0600))
+		return path
+	}
+
+	t.Run("key", func(t *testing.T) {
+		s := newRSASigner()
+		require.NoError(t, runSignersTest(context.Background(), options.SignersTestOptions{KeyPath: publicKeyFile(s)}, nil, s))
+	})
+
+	t.Run("key without verifier", func(t *testing.T) {
+		err := runSignersTest(context.Background(), options.SignersTestOptions{}, nil, newRSASigner())
+		assert.ErrorContains(t, err, "no verifiers found")
+	})
+
+	t.Run("certificate", func(t *testing.T) {
+		require.NoError(t, runSignersTest(context.Background(), options.SignersTestOptions{}, nil, keylessSigner(t, true)))
+	})
+
+	t.Run("certificate without roots", func(t *testing.T) {
+		require.NoError(t, runSignersTest(context.Background(), options.SignersTestOptions{}, nil, keylessSigner(t, false)))
+	})
+
+	t.Run("no signers", func(t *testing.T) {
+		assert.ErrorContains(t, runSignersTest(context.Background(), options.SignersTestOptions{}, nil), "no signers found")
+	})
+
+	t.Run("mismatched verifier", func(t *testing.T) {
+		err := runSignersTest(context.Background(), options.SignersTestOptions{KeyPath: publicKeyFile(newRSASigner())}, nil, newRSASigner())
+		assert.ErrorContains(t, err, "failed to verify signed nonce with the configured verifiers")
+	})
+
+	t.Run("verifier provider that fails to load", func(t *testing.T) {
+		err := runSignersTest(context.Background(), options.SignersTestOptions{}, map[string][]string{"kms": {"verifier-kms-ref"}}, newRSASigner())
+		assert.ErrorContains(t, err, "failed to load verifiers")
+	})
+}
+
+func Test_SignersTestCmd(t *testing.T) {
+	privatePem, publicPem := rsakeypair(t)
+	cmd := SignersTestCmd()
+	cmd.SetArgs([]string{"--signer-file-key-path", privatePem.Name(), "--publickey", publicPem.Name()})
+	require.NoError(t, cmd.Execute())
+
+	cmd = SignersTestCmd()
+	cmd.SetArgs([]string{"--signer-file-key-path", privatePem.Name()})
+	assert.ErrorContains(t, cmd.Execute(), "no verifiers found")
+
+	cmd = SignersTestCmd()
+	cmd.SetArgs([]string{})
+	assert.ErrorContains(t, cmd.Execute(), "failed to load any signers")
+}
@@ -0,0 +1,71 @@
+// Copyright 2026 The Witness Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import "strings"
+
+// suggestionDistance is the largest edit distance at which a candidate is suggested for a misspelled name. It grows
+// with the length of the name, as a few edits turn a short name into almost anything.
+func suggestionDistance(name string) int {
+	return max(1, len([]rune(name))/3)
+}
+
+// suggest returns the candidate closest to name, or "" if none is close enough to be a likely misspelling. Names are
+// compared ignoring case, and with . and _ treated like -. Ties go to the candidate that sorts first, so the
+// suggestion doesn't depend on the order of the candidates.
+func suggest(name string, candidates []string) string {
+	normalize := strings.NewReplacer(".", "-", "_", "-")
+	target := strings.ToLower(normalize.Replace(name))
+	best, bestDistance := "", suggestionDistance(target)+1
+	for _, candidate := range candidates {
+		d := editDistance(target, strings.ToLower(normalize.Replace(candidate)))
+		if d < bestDistance || (d == bestDistance && candidate < best) {
+			best, bestDistance = candidate, d
+		}
+	}
+
+	return best
+}
+
+// editDistance returns the number of single character insertions, deletions, substitutions and transpositions of
+// adjacent characters that turn a into b
+func editDistance(a, b string) int {
+	ar, br := []rune(a), []rune(b)
+	rows := make([][]int, len(ar)+1)
+	for i := range rows {
+		rows[i] = make([]int, len(br)+1)
+		rows[i][0] = i
+	}
+
+	for j := range rows[0] {
+		rows[0][j] = j
+	}
+
+	for i := 1; i <= len(ar); i++ {
+		for j := 1; j <= len(br); j++ {
+			cost := 1
+			if ar[i-1] == br[j-1] {
+				cost = 0
+			}
+
+			rows[i][j] = min(rows[i-1][j]+1, rows[i][j-1]+1, rows[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ar[i-1] == br[j-2] && ar[i-2] == br[j-1] {
+				rows[i][j] = min(rows[i][j], rows[i-2][j-2]+1)
+			}
+		}
+	}
+
+	return rows[len(ar)][len(br)]
+}
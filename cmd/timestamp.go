@@ -0,0 +1,154 @@
+// Copyright 2026 The Witness Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/in-toto/go-witness/dsse"
+	"github.com/in-toto/go-witness/log"
+	"github.com/in-toto/go-witness/timestamp"
+	"github.com/in-toto/witness/internal/detached"
+	"github.com/in-toto/witness/internal/stdio"
+	"github.com/in-toto/witness/options"
+	"github.com/spf13/cobra"
+)
+
+func TimestampCmd() *cobra.Command {
+	to := options.TimestampOptions{}
+	cmd := &cobra.Command{
+		Use:               "timestamp [envelope]",
+		Short:             "Timestamps the signatures of a signed envelope",
+		Long:              "Requests RFC3161 timestamps for each signature of an existing envelope and adds them to the envelope without re-signing it. Timestamps are verified against the provided Timestamp Authority certificates before they are written. Use - to read the envelope from stdin",
+		Args:              cobra.ExactArgs(1),
+		SilenceErrors:     true,
+		SilenceUsage:      true,
+		DisableAutoGenTag: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runTimestamp(cmd.Context(), to, args[0])
+		},
+	}
+
+	to.AddFlags(cmd)
+	return cmd
+}
+
+func runTimestamp(ctx context.Context, to options.TimestampOptions, envelopePath string) error {
+	if len(to.TimestampServers) == 0 || len(to.TimestampCertPaths) == 0 {
+		return fmt.Errorf("must supply timestamp servers and the certificates to verify their timestamps with")
+	}
+
+	// timestamps only cover the signatures, so a detached envelope doesn't need its payload
+	envBytes, err := stdio.ReadFile(envelopePath)
+	if err != nil {
+		return fmt.Errorf("failed to read envelope: %w", err)
+	}
+
+	env, detachedEnv, err := detached.Parse(envBytes)
+	if err != nil {
+		return err
+	}
+
+	certs, err := loadCertificates(to.TimestampCertPaths)
+	if err != nil {
+		return fmt.Errorf("failed to load timestamp authority certificates: %w", err)
+	}
+
+	timestampers := []timestamp.Timestamper{}
+	for _, url := range to.TimestampServers {
+		timestampers = append(timestampers, timestamp.NewTimestamper(timestamp.TimestampWithUrl(url)))
+	}
+
+	env, err = timestampSignatures(ctx, env, timestampers, timestamp.NewVerifier(timestamp.VerifyWithCerts(certs)))
+	if err != nil {
+		return err
+	}
+
+	outFilePath := to.OutFilePath
+	if outFilePath == "" {
+		outFilePath = envelopePath
+	}
+
+	// nothing is written until every timestamp was fetched and verified
+	outFile, err := loadOutfile(outFilePath)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err := outFile.Close(); err != nil {
+			log.Errorf("failed to write result to disk: %v", err)
+		}
+	}()
+
+	var out any = env
+	if detachedEnv != nil {
+		detachedEnv.Signatures = env.Signatures
+		out = detachedEnv
+	}
+
+	return json.NewEncoder(outFile).Encode(out)
+}
+
+// timestampSignatures adds a timestamp from every timestamper to each signature of the envelope. Signatures that
+// already carry a timestamp the verifier accepts are left alone.
+func timestampSignatures(ctx context.Context, env dsse.Envelope, timestampers []timestamp.Timestamper, verifier timestamp.TimestampVerifier) (dsse.Envelope, error) {
+	signatures := []dsse.Signature{}
+	for _, sig := range env.Signatures {
+		if hasVerifiedTimestamp(ctx, sig, verifier) {
+			log.Infof("Signature with key id %s already has a verified timestamp", sig.KeyID)
+			signatures = append(signatures, sig)
+			continue
+		}
+
+		sig.Timestamps = append([]dsse.SignatureTimestamp{}, sig.Timestamps...)
+		for _, timestamper := range timestampers {
+			token, err := timestamper.Timestamp(ctx, bytes.NewReader(sig.Signature))
+			if err != nil {
+				return env, fmt.Errorf("failed to timestamp signature with key id %s: %w", sig.KeyID, err)
+			}
+
+			ts, err := verifier.Verify(ctx, bytes.NewReader(token), bytes.NewReader(sig.Signature))
+			if err != nil {
+				return env, fmt.Errorf("failed to verify timestamp for signature with key id %s: %w", sig.KeyID, err)
+			}
+
+			log.Infof("Timestamped signature with key id %s at %s", sig.KeyID, ts.Format(time.RFC3339))
+			sig.Timestamps = append(sig.Timestamps, dsse.SignatureTimestamp{
+				Type: dsse.TimestampRFC3161,
+				Data: token,
+			})
+		}
+
+		signatures = append(signatures, sig)
+	}
+
+	env.Signatures = signatures
+	return env, nil
+}
+
+func hasVerifiedTimestamp(ctx context.Context, sig dsse.Signature, verifier timestamp.TimestampVerifier) bool {
+	for _, ts := range sig.Timestamps {
+		if _, err := verifier.Verify(ctx, bytes.NewReader(ts.Data), bytes.NewReader(sig.Signature)); err == nil {
+			return true
+		}
+	}
+
+	return false
+}
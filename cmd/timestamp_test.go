@@ -0,0 +1,133 @@
+// Copyright 2026 The Witness Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/digitorus/timestamp"
+	"github.com/in-toto/go-witness/cryptoutil"
+	"github.com/in-toto/go-witness/dsse"
+	"github.com/in-toto/witness/options"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// localTSA starts a stand-in RFC3161 timestamp authority and returns its url and the path to its certificate
+func localTSA(t *testing.T) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "local tsa"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageTimeStamping},
+		BasicConstraintsValid: true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	certPath := filepath.Join(t.TempDir(), "tsa.pem")
+	require.NoError(t, os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		req, err := timestamp.ParseRequest(body)
+		require.NoError(t, err)
+		ts := timestamp.Timestamp{
+			HashAlgorithm:     req.HashAlgorithm,
+			HashedMessage:     req.HashedMessage,
+			Time:              time.Now(),
+			Nonce:             req.Nonce,
+			Policy:            asn1.ObjectIdentifier{1, 2, 3, 4, 1},
+			AddTSACertificate: req.Certificates,
+		}
+
+		resp, err := ts.CreateResponseWithOpts(cert, key, crypto.SHA256)
+		require.NoError(t, err)
+		w.Header().Set("Content-Type", "application/timestamp-reply")
+		_, err = w.Write(resp)
+		require.NoError(t, err)
+	}))
+
+	t.Cleanup(server.Close)
+	return server.URL, certPath
+}
+
+func TestRunTimestamp(t *testing.T) {
+	privatekey, err := rsa.GenerateKey(rand.Reader, keybits)
+	require.NoError(t, err)
+	signer := cryptoutil.NewRSASigner(privatekey, crypto.SHA256)
+	env, err := dsse.Sign("text", bytes.NewReader([]byte("test")), dsse.SignWithSigners(signer))
+	require.NoError(t, err)
+	envBytes, err := json.Marshal(env)
+	require.NoError(t, err)
+	envPath := filepath.Join(t.TempDir(), "envelope.json")
+	require.NoError(t, os.WriteFile(envPath, envBytes, 0644))
+
+	tsaURL, tsaCertPath := localTSA(t)
+	to := options.TimestampOptions{
+		TimestampServers:   []string{tsaURL},
+		TimestampCertPaths: []string{tsaCertPath},
+	}
+
+	require.NoError(t, runTimestamp(context.Background(), to, envPath))
+	timestamped, _, err := readEnvelope(envPath)
+	require.NoError(t, err)
+	assert.Equal(t, env.Payload, timestamped.Payload)
+	require.Len(t, timestamped.Signatures, 1)
+	assert.Equal(t, env.Signatures[0].Signature, timestamped.Signatures[0].Signature)
+	require.Len(t, timestamped.Signatures[0].Timestamps, 1)
+	assert.Equal(t, dsse.TimestampRFC3161, timestamped.Signatures[0].Timestamps[0].Type)
+
+	// signatures with a verified timestamp aren't stamped again
+	outPath := filepath.Join(t.TempDir(), "out.json")
+	to.OutFilePath = outPath
+	require.NoError(t, runTimestamp(context.Background(), to, envPath))
+	again, _, err := readEnvelope(outPath)
+	require.NoError(t, err)
+	assert.Len(t, again.Signatures[0].Timestamps, 1)
+
+	// tokens from an authority that isn't trusted are rejected and nothing is written
+	_, otherCertPath := localTSA(t)
+	to.TimestampCertPaths = []string{otherCertPath}
+	to.OutFilePath = filepath.Join(t.TempDir(), "rejected.json")
+	require.ErrorContains(t, runTimestamp(context.Background(), to, envPath), "failed to verify timestamp")
+	assert.NoFileExists(t, to.OutFilePath)
+}
@@ -18,17 +18,26 @@ import (
 	"context"
 	"crypto"
 	"crypto/x509"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
+	"time"
 
 	witness "github.com/in-toto/go-witness"
 	"github.com/in-toto/go-witness/archivista"
 	"github.com/in-toto/go-witness/cryptoutil"
+	"github.com/in-toto/go-witness/dsse"
 	"github.com/in-toto/go-witness/log"
 	"github.com/in-toto/go-witness/source"
 	"github.com/in-toto/go-witness/timestamp"
+	"github.com/in-toto/witness/internal/cache"
+	"github.com/in-toto/witness/internal/detached"
+	"github.com/in-toto/witness/internal/oci"
 	"github.com/in-toto/witness/internal/policy"
+	"github.com/in-toto/witness/internal/revocation"
+	"github.com/in-toto/witness/internal/stdio"
+	"github.com/in-toto/witness/internal/trust"
 	"github.com/in-toto/witness/options"
 	"github.com/spf13/cobra"
 )
@@ -38,6 +47,8 @@ func VerifyCmd() *cobra.Command {
 		ArchivistaOptions:          options.ArchivistaOptions{},
 		KMSVerifierProviderOptions: options.KMSVerifierProviderOptions{},
 		VerifierOptions:            options.VerifierOptions{},
+		SignerOptions:              options.SignerOptions{},
+		KMSSignerProviderOptions:   options.KMSSignerProviderOptions{},
 	}
 	cmd := &cobra.Command{
 		Use:               "verify",
@@ -51,11 +62,21 @@ func VerifyCmd() *cobra.Command {
 				log.Warn("The flag `--policy-ca` is deprecated and will be removed in a future release. Please use `--policy-ca-root` and `--policy-ca-intermediate` instead.")
 			}
 
-			verifiers, err := loadVerifiers(cmd.Context(), vo.VerifierOptions, vo.KMSVerifierProviderOptions, providersFromFlags("verifier", cmd.Flags()))
+			verifiers, err := loadVerifiers(cmd.Context(), vo.VerifierOptions, vo.KMSVerifierProviderOptions, providersFromFlags("verifier", cmd.Flags()), vo.VerifierStrict)
 			if err != nil {
-				return fmt.Errorf("failed to load signer: %w", err)
+				return fmt.Errorf("failed to load verifiers: %w", err)
 			}
-			return runVerify(cmd.Context(), vo, verifiers...)
+
+			// signing the verification summary is optional, so only load signers if one was requested
+			var signers []cryptoutil.Signer
+			if signerProviders := providersFromFlags("signer", cmd.Flags()); len(signerProviders) > 0 {
+				signers, err = loadSigners(cmd.Context(), vo.SignerOptions, vo.KMSSignerProviderOptions, signerProviders, vo.SignerStrict)
+				if err != nil {
+					return fmt.Errorf("failed to load signers: %w", err)
+				}
+			}
+
+			return runVerify(cmd.Context(), vo, signers, verifiers...)
 		},
 	}
 	vo.AddFlags(cmd)
@@ -68,7 +89,7 @@ const (
 
 // todo: this logic should be broken out and moved to pkg/
 // we need to abstract where keys are coming from, etc
-func runVerify(ctx context.Context, vo options.VerifyOptions, verifiers ...cryptoutil.Verifier) error {
+func runVerify(ctx context.Context, vo options.VerifyOptions, signers []cryptoutil.Signer, verifiers ...cryptoutil.Verifier) error {
 	var (
 		collectionSource source.Sourcer
 		archivistaClient *archivista.Client
@@ -77,7 +98,8 @@ func runVerify(ctx context.Context, vo options.VerifyOptions, verifiers ...crypt
 
 	collectionSource = memSource
 	if vo.ArchivistaOptions.Enable {
-		archivistaClient, err := vo.ArchivistaOptions.Client()
+		var err error
+		archivistaClient, err = vo.ArchivistaOptions.Client()
 		if err != nil {
 			return fmt.Errorf("failed to create archivista client: %w", err)
 		}
@@ -85,14 +107,22 @@ func runVerify(ctx context.Context, vo options.VerifyOptions, verifiers ...crypt
 		collectionSource = source.NewMultiSource(collectionSource, source.NewArchvistSource(archivistaClient))
 	}
 
-	if vo.KeyPath == "" && len(vo.PolicyCARootPaths) == 0 && len(verifiers) == 0 {
-		return fmt.Errorf("must supply either a public key, CA certificates or a verifier")
+	if len(signers) > 1 {
+		return fmt.Errorf("only one signer is supported")
+	}
+
+	if vo.KeyPath == "" && len(vo.PolicyCARootPaths) == 0 && len(verifiers) == 0 && vo.TrustConfigPath == "" && vo.TrustedRootPath == "" {
+		return fmt.Errorf("must supply either a public key, CA certificates, a verifier, a trust config or a trusted root")
 	}
 
 	if !vo.ArchivistaOptions.Enable && len(vo.AttestationFilePaths) == 0 {
 		return fmt.Errorf("must either specify attestation file paths or enable archivista as an attestation source")
 	}
 
+	if err := stdio.CheckSingleStdin(append([]string{vo.PolicyFilePath, vo.PolicyPayloadPath}, vo.AttestationFilePaths...)...); err != nil {
+		return err
+	}
+
 	if vo.KeyPath != "" {
 		keyFile, err := os.Open(vo.KeyPath)
 		if err != nil {
@@ -148,6 +178,7 @@ func runVerify(ctx context.Context, vo options.VerifyOptions, verifiers ...crypt
 	}
 
 	ptsVerifiers := make([]timestamp.TimestampVerifier, 0)
+	ptsCerts := make([]*x509.Certificate, 0)
 	if len(vo.PolicyTimestampServers) > 0 {
 		for _, server := range vo.PolicyTimestampServers {
 			f, err := os.ReadFile(server)
@@ -160,15 +191,102 @@ func runVerify(ctx context.Context, vo options.VerifyOptions, verifiers ...crypt
 				return fmt.Errorf("failed to parse Timestamp Server CA certificate: %w", err)
 			}
 
+			ptsCerts = append(ptsCerts, cert)
+			ptsVerifiers = append(ptsVerifiers, timestamp.NewVerifier(timestamp.VerifyWithCerts([]*x509.Certificate{cert})))
+		}
+	}
+
+	if vo.TrustConfigPath != "" {
+		material, err := loadTrustProfile(&vo)
+		if err != nil {
+			return fmt.Errorf("failed to load trust profile: %w", err)
+		}
+
+		verifiers = append(verifiers, material.Verifiers...)
+		policyRoots = append(policyRoots, material.Roots...)
+		policyIntermediates = append(policyIntermediates, material.Intermediates...)
+		for _, cert := range material.TimestampCerts {
+			ptsCerts = append(ptsCerts, cert)
 			ptsVerifiers = append(ptsVerifiers, timestamp.NewVerifier(timestamp.VerifyWithCerts([]*x509.Certificate{cert})))
 		}
 	}
 
-	policyEnvelope, err := policy.LoadPolicy(ctx, vo.PolicyFilePath, archivistaClient)
+	signatureChecks := []policySignatureCheck{}
+	if vo.TrustedRootPath != "" {
+		trustedRoot, err := trust.LoadTrustedRoot(vo.TrustedRootPath)
+		if err != nil {
+			return fmt.Errorf("failed to load trusted root: %w", err)
+		}
+
+		policyRoots = append(policyRoots, trustedRoot.Roots()...)
+		policyIntermediates = append(policyIntermediates, trustedRoot.Intermediates()...)
+		for _, tsa := range trustedRoot.TimestampAuthorities {
+			ptsCerts = append(ptsCerts, tsa.Root)
+			ptsCerts = append(ptsCerts, tsa.Intermediates...)
+		}
+
+		ptsVerifiers = append(ptsVerifiers, trustedRoot.TimestampVerifiers()...)
+		signatureChecks = append(signatureChecks, trustedRootCheck(trustedRoot))
+	}
+
+	if !vo.PolicyIdentityRegexp.IsEmpty() {
+		signatureChecks = append(signatureChecks, identityCheck(vo.PolicyIdentityRegexp))
+	}
+
+	revocationChecker, crls, err := loadRevocationChecker(vo.CRLPaths, vo.OCSPResponder)
+	if err != nil {
+		return fmt.Errorf("failed to load revocation checks: %w", err)
+	}
+
+	policyEnvelope, err := policy.LoadPolicy(ctx, vo.PolicyFilePath, vo.PolicyPayloadPath, archivistaClient)
 	if err != nil {
 		return fmt.Errorf("failed to open policy file: %w", err)
 	}
 
+	attestations := make([]namedEnvelope, 0, len(vo.AttestationFilePaths))
+	for _, path := range vo.AttestationFilePaths {
+		env, err := loadAttestationFile(memSource, path)
+		if err != nil {
+			return fmt.Errorf("failed to load attestation file: %w", err)
+		}
+
+		attestations = append(attestations, namedEnvelope{name: path, envelope: env})
+	}
+
+	verifyTime, err := verificationTime(ctx, vo, policyEnvelope, attestations)
+	if err != nil {
+		return fmt.Errorf("failed to determine verification time: %w", err)
+	}
+
+	if !verifyTime.IsZero() {
+		log.Infof("Verifying as of %s", verifyTime.Format(time.RFC3339))
+	}
+
+	atOpts := verifyAtOptions{
+		policyTimestampVerifiers: ptsVerifiers,
+		policyRoots:              policyRoots,
+		policyIntermediates:      policyIntermediates,
+		policyCertConstraint:     policyCertConstraint(vo),
+		policySignatureChecks:    signatureChecks,
+	}
+
+	// go-witness can't enforce the signature checks, so the policy signers are checked against them up front
+	if len(signatureChecks) > 0 {
+		if err := verifyPolicySigners(policyEnvelope, verifiers, verifyTime, atOpts); err != nil {
+			return fmt.Errorf("failed to verify policy signature: %w", err)
+		}
+	}
+
+	var signerRevocation []revocation.Result
+	if revocationChecker != nil {
+		signerRevocation, err = checkPolicySignerRevocation(ctx, revocationChecker, policyEnvelope, policyRoots, policyIntermediates, verifyTime, vo.RevocationStrict)
+		if err != nil {
+			log.Error("Verification failed")
+			logRevocation("policy signer", signerRevocation)
+			return fmt.Errorf("failed to verify policy: %w", err)
+		}
+	}
+
 	subjects := []cryptoutil.DigestSet{}
 	if len(vo.ArtifactDirectoryPath) > 0 {
 		artifactDigestSet, err := cryptoutil.CalculateDigestSetFromDir(vo.ArtifactDirectoryPath, []cryptoutil.DigestValue{{Hash: crypto.SHA256, GitOID: false}})
@@ -188,24 +306,71 @@ func runVerify(ctx context.Context, vo options.VerifyOptions, verifiers ...crypt
 		subjects = append(subjects, artifactDigestSet)
 	}
 
+	if len(vo.ImagePath) > 0 {
+		imageSubjects, err := oci.ImageSubjects(vo.ImagePath, []cryptoutil.DigestValue{{Hash: crypto.SHA256, GitOID: false}})
+		if err != nil {
+			return fmt.Errorf("failed to calculate image digests: %w", err)
+		}
+
+		for _, s := range imageSubjects {
+			log.Debugf("using image subject %s", s.Name)
+			subjects = append(subjects, s.Digest)
+		}
+	}
+
 	for _, subDigest := range vo.AdditionalSubjects {
 		subjects = append(subjects, cryptoutil.DigestSet{cryptoutil.DigestValue{Hash: crypto.SHA256, GitOID: false}: subDigest})
 	}
 
 	if len(subjects) == 0 {
-		return errors.New("at least one subject is required, provide an artifact file or subject")
+		return errors.New("at least one subject is required, provide an artifact file, image or subject")
 	}
 
-	for _, path := range vo.AttestationFilePaths {
-		if err := memSource.LoadFile(path); err != nil {
-			return fmt.Errorf("failed to load attestation file: %w", err)
+	var (
+		verifyCache   *cache.Cache
+		cacheKey      cache.Key
+		cacheNotAfter time.Time
+	)
+	if reason := cacheBypassReason(vo, signers); vo.CacheDir != "" && reason != "" {
+		log.Warnf("Ignoring --cache-dir, %s", reason)
+	} else if vo.CacheDir != "" {
+		verifyCache, err = cache.New(vo.CacheDir, vo.CacheTTL)
+		if err != nil {
+			return fmt.Errorf("failed to open verification cache: %w", err)
+		}
+
+		inputs := verifyTrustInputs{
+			verifiers:           verifiers,
+			policyRoots:         policyRoots,
+			policyIntermediates: policyIntermediates,
+			timestampCerts:      ptsCerts,
+			crls:                crls,
+		}
+
+		cacheKey, cacheNotAfter, err = verifyCacheKey(ctx, vo, policyEnvelope, subjects, inputs, archivistaClient)
+		if err != nil {
+			return fmt.Errorf("failed to build verification cache key: %w", err)
+		}
+
+		entry, ok, err := verifyCache.Get(cacheKey, time.Now())
+		if err != nil {
+			log.Warnf("failed to read verification cache: %v", err)
+		} else if ok {
+			log.Infof("Verification succeeded (cached result from %s)", entry.CreatedAt.Format(time.RFC3339))
+			log.Info("Evidence:")
+			num := 0
+			for step, references := range entry.Evidence {
+				log.Info("Step: ", step)
+				for _, reference := range references {
+					log.Info(fmt.Sprintf("%d: %s", num, reference))
+					num++
+				}
+			}
+			return nil
 		}
 	}
 
-	verifiedEvidence, err := witness.Verify(
-		ctx,
-		policyEnvelope,
-		verifiers,
+	verifyOpts := []witness.VerifyOption{
 		witness.VerifyWithSubjectDigests(subjects),
 		witness.VerifyWithCollectionSource(collectionSource),
 		witness.VerifyWithPolicyTimestampAuthorities(ptsVerifiers),
@@ -213,7 +378,51 @@ func runVerify(ctx context.Context, vo options.VerifyOptions, verifiers ...crypt
 		witness.VerifyWithPolicyCAIntermediates(policyIntermediates),
 		witness.VerifyWithPolicyCertConstraints(vo.PolicyCommonName, vo.PolicyDNSNames, vo.PolicyEmails, vo.PolicyOrganizations, vo.PolicyURIs),
 		witness.VerifyWithPolicyFulcioCertExtensions(vo.PolicyFulcioCertExtensions),
+	}
+
+	timestampers := []timestamp.Timestamper{}
+	if len(signers) > 0 {
+		for _, url := range vo.TimestampServers {
+			timestampers = append(timestampers, timestamp.NewTimestamper(timestamp.TimestampWithUrl(url)))
+		}
+
+		verifyOpts = append(verifyOpts,
+			witness.VerifyWithSigners(signers...),
+			witness.VerifyWithRunOptions(witness.RunWithTimestampers(timestampers...)),
+		)
+	}
+
+	var verifiedEvidence witness.VerifyResult
+	if verifyTime.IsZero() {
+		verifiedEvidence, err = witness.Verify(ctx, policyEnvelope, verifiers, verifyOpts...)
+	} else {
+		atOpts.subjects = subjects
+		atOpts.collectionSource = collectionSource
+		atOpts.signers = signers
+		atOpts.timestampers = timestampers
+		verifiedEvidence, err = verifyAt(ctx, policyEnvelope, verifiers, verifyTime, atOpts)
+	}
+
+	var (
+		functionaryRevocation []revocation.Result
+		revocationErr         error
 	)
+	if err == nil && revocationChecker != nil {
+		functionaryRevocation, revocationErr = checkFunctionaryRevocation(ctx, revocationChecker, verifiedEvidence.StepResults, verifyTime, vo.RevocationStrict)
+	}
+
+	// the signed summary records failed verifications as well, so export it before looking at the result. The summary
+	// is signed before the revocation check runs and would record a pass, so it's dropped if that check fails.
+	if revocationErr == nil && len(verifiedEvidence.SignedEnvelope.Signatures) > 0 {
+		if exportErr := exportVerificationSummary(ctx, vo, verifiedEvidence.SignedEnvelope, archivistaClient); exportErr != nil {
+			return errors.Join(err, exportErr)
+		}
+	}
+
+	if revocationErr != nil {
+		err = revocationErr
+	}
+
 	if err != nil {
 		if verifiedEvidence.StepResults != nil {
 			log.Error("Verification failed")
@@ -233,18 +442,87 @@ func runVerify(ctx context.Context, vo options.VerifyOptions, verifiers ...crypt
 				}
 			}
 		}
+
+		logRevocation("policy signer", signerRevocation)
+		logRevocation("functionary", functionaryRevocation)
 		return fmt.Errorf("failed to verify policy: %w", err)
 	} else {
 		log.Info("Verification succeeded")
 		log.Info("Evidence:")
 		num := 0
+		evidence := make(map[string][]string)
 		for step, result := range verifiedEvidence.StepResults {
 			log.Info("Step: ", step)
 			for _, p := range result.Passed {
 				log.Info(fmt.Sprintf("%d: %s", num, p.Reference))
+				evidence[step] = append(evidence[step], p.Reference)
 				num++
 			}
 		}
+
+		logRevocation("policy signer", signerRevocation)
+		logRevocation("functionary", functionaryRevocation)
+
+		if verifyCache != nil {
+			if err := verifyCache.Put(cacheKey, time.Now(), cacheNotAfter, evidence); err != nil {
+				log.Warnf("failed to write verification cache: %v", err)
+			}
+		}
+
 		return nil
 	}
 }
+
+// loadAttestationFile loads an attestation envelope into the memory source, reattaching the payload of a detached
+// envelope from the file it was named after. The loaded envelope is returned.
+func loadAttestationFile(memSource *source.MemorySource, path string) (dsse.Envelope, error) {
+	data, err := stdio.ReadFile(path)
+	if err != nil {
+		return dsse.Envelope{}, err
+	}
+
+	env, isDetached, err := detached.Load(data, path, "")
+	if err != nil {
+		return dsse.Envelope{}, err
+	}
+
+	if isDetached {
+		return env, memSource.LoadEnvelope(path, env)
+	}
+
+	return env, memSource.LoadBytes(path, data)
+}
+
+// exportVerificationSummary writes the signed verification summary to the out file and stores it in Archivista if enabled
+func exportVerificationSummary(ctx context.Context, vo options.VerifyOptions, env dsse.Envelope, archivistaClient *archivista.Client) error {
+	signedBytes, err := json.Marshal(&env)
+	if err != nil {
+		return fmt.Errorf("failed to marshal verification summary envelope: %w", err)
+	}
+
+	out, err := loadOutfile(vo.OutFilePath)
+	if err != nil {
+		return fmt.Errorf("failed to open out file: %w", err)
+	}
+
+	defer func() {
+		if err := out.Close(); err != nil {
+			log.Errorf("failed to write verification summary to disk: %v", err)
+		}
+	}()
+
+	if _, err := out.Write(signedBytes); err != nil {
+		return fmt.Errorf("failed to write verification summary to out file: %w", err)
+	}
+
+	if archivistaClient != nil {
+		gitoid, err := archivistaClient.Store(ctx, env)
+		if err != nil {
+			return fmt.Errorf("failed to store verification summary in archivista: %w", err)
+		}
+
+		log.Infof("Stored verification summary in archivista as %v", gitoid)
+	}
+
+	return nil
+}
@@ -0,0 +1,264 @@
+// Copyright 2026 The Witness Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/in-toto/go-witness/archivista"
+	"github.com/in-toto/go-witness/cryptoutil"
+	"github.com/in-toto/go-witness/dsse"
+	"github.com/in-toto/go-witness/policy"
+	"github.com/in-toto/witness/internal/cache"
+	"github.com/in-toto/witness/internal/stdio"
+	"github.com/in-toto/witness/internal/trust"
+	"github.com/in-toto/witness/options"
+)
+
+// verifyTrustInputs are the inputs used to establish trust in a policy, and therefore part of a cache key
+type verifyTrustInputs struct {
+	verifiers           []cryptoutil.Verifier
+	policyRoots         []*x509.Certificate
+	policyIntermediates []*x509.Certificate
+	timestampCerts      []*x509.Certificate
+	crls                []*x509.RevocationList
+}
+
+// cacheBypassReason returns why the verification can't use the cache, or an empty string if it can
+func cacheBypassReason(vo options.VerifyOptions, signers []cryptoutil.Signer) string {
+	switch {
+	case len(signers) > 0:
+		return "a cached result can't produce a signed verification summary"
+	case vo.OCSPResponder != "":
+		return "OCSP responses may change at any time, so they are always checked"
+	default:
+		return ""
+	}
+}
+
+// verifyCacheKey builds the cache key for a verification and returns the latest time a cached outcome may be used
+func verifyCacheKey(ctx context.Context, vo options.VerifyOptions, policyEnvelope dsse.Envelope, subjects []cryptoutil.DigestSet, inputs verifyTrustInputs, archivistaClient *archivista.Client) (cache.Key, time.Time, error) {
+	key := cache.Key{}
+	envelopeBytes, err := json.Marshal(policyEnvelope)
+	if err != nil {
+		return key, time.Time{}, fmt.Errorf("failed to marshal policy envelope: %w", err)
+	}
+
+	key.PolicyDigest = sha256Hex(envelopeBytes)
+
+	p := policy.Policy{}
+	if err := json.Unmarshal(policyEnvelope.Payload, &p); err != nil {
+		return key, time.Time{}, fmt.Errorf("failed to parse policy: %w", err)
+	}
+
+	subjectDigests := []string{}
+	for _, subject := range subjects {
+		nameMap, err := subject.ToNameMap()
+		if err != nil {
+			return key, time.Time{}, fmt.Errorf("failed to encode subject digest: %w", err)
+		}
+
+		for hash, digest := range nameMap {
+			subjectDigests = append(subjectDigests, fmt.Sprintf("%s:%s", hash, digest))
+		}
+	}
+
+	key.SubjectDigests = subjectDigests
+
+	key.TrustDigest, err = trustDigest(vo, inputs)
+	if err != nil {
+		return key, time.Time{}, err
+	}
+
+	for _, path := range vo.AttestationFilePaths {
+		b, err := stdio.ReadFile(path)
+		if err != nil {
+			return key, time.Time{}, fmt.Errorf("failed to read attestation file: %w", err)
+		}
+
+		key.AttestationDigests = append(key.AttestationDigests, sha256Hex(b))
+	}
+
+	// We can't enumerate everything stored in Archivista without downloading it, so we settle for the collections
+	// each step of the policy starts its search with, the same search the verification makes. Only successful results
+	// are cached, and further attestations can't turn a pass into a failure, so collections that are only found by
+	// following back-references don't need to be part of the key.
+	if archivistaClient != nil {
+		searchDigests := []string{}
+		for _, subject := range subjects {
+			for _, digest := range subject {
+				searchDigests = append(searchDigests, digest)
+			}
+		}
+
+		stepNames := make([]string, 0, len(p.Steps))
+		for stepName := range p.Steps {
+			stepNames = append(stepNames, stepName)
+		}
+
+		sort.Strings(stepNames)
+		for _, stepName := range stepNames {
+			attestations := []string{}
+			for _, attestation := range p.Steps[stepName].Attestations {
+				attestations = append(attestations, attestation.Type)
+			}
+
+			gitoids, err := archivistaClient.SearchGitoids(ctx, archivista.SearchGitoidVariables{
+				CollectionName: stepName,
+				SubjectDigests: searchDigests,
+				Attestations:   attestations,
+				ExcludeGitoids: []string{},
+			})
+			if err != nil {
+				return key, time.Time{}, fmt.Errorf("failed to search archivista for attestations: %w", err)
+			}
+
+			for _, gitoid := range gitoids {
+				key.AttestationDigests = append(key.AttestationDigests, fmt.Sprintf("%s/%s", vo.ArchivistaOptions.Url, gitoid))
+			}
+		}
+	}
+
+	return key, verifyNotAfter(p, inputs), nil
+}
+
+func trustDigest(vo options.VerifyOptions, inputs verifyTrustInputs) (string, error) {
+	keyIDs := []string{}
+	for _, v := range inputs.verifiers {
+		keyID, err := v.KeyID()
+		if err != nil {
+			return "", fmt.Errorf("failed to get verifier key id: %w", err)
+		}
+
+		keyIDs = append(keyIDs, keyID)
+	}
+
+	sort.Strings(keyIDs)
+	rawCerts := func(certs []*x509.Certificate) []string {
+		digests := []string{}
+		for _, cert := range certs {
+			digests = append(digests, sha256Hex(cert.Raw))
+		}
+
+		return digests
+	}
+
+	crlDigests := []string{}
+	for _, crl := range inputs.crls {
+		crlDigests = append(crlDigests, sha256Hex(crl.Raw))
+	}
+
+	// the trusted root also carries the validity windows of its authorities
+	trustedRootDigest := ""
+	if vo.TrustedRootPath != "" {
+		trustedRoot, err := os.ReadFile(vo.TrustedRootPath)
+		if err != nil {
+			return "", fmt.Errorf("failed to read trusted root: %w", err)
+		}
+
+		trustedRootDigest = sha256Hex(trustedRoot)
+	}
+
+	b, err := json.Marshal(struct {
+		KeyIDs              []string
+		PolicyRoots         []string
+		PolicyIntermediates []string
+		TimestampCerts      []string
+		CRLs                []string
+		RevocationStrict    bool
+		TrustedRoot         string
+		CommonName          string
+		DNSNames            []string
+		Emails              []string
+		Organizations       []string
+		URIs                []string
+		FulcioExtensions    any
+		IdentityRegexp      trust.IdentityRegexp
+		VerifyTime          time.Time
+		FromTimestamps      bool
+	}{
+		KeyIDs:              keyIDs,
+		PolicyRoots:         rawCerts(inputs.policyRoots),
+		PolicyIntermediates: rawCerts(inputs.policyIntermediates),
+		TimestampCerts:      rawCerts(inputs.timestampCerts),
+		CRLs:                crlDigests,
+		RevocationStrict:    vo.RevocationStrict,
+		TrustedRoot:         trustedRootDigest,
+		CommonName:          vo.PolicyCommonName,
+		DNSNames:            vo.PolicyDNSNames,
+		Emails:              vo.PolicyEmails,
+		Organizations:       vo.PolicyOrganizations,
+		URIs:                vo.PolicyURIs,
+		FulcioExtensions:    vo.PolicyFulcioCertExtensions,
+		IdentityRegexp:      vo.PolicyIdentityRegexp,
+		VerifyTime:          vo.VerifyTime,
+		FromTimestamps:      vo.VerifyTimeFromTimestamps,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal trust inputs: %w", err)
+	}
+
+	return sha256Hex(b), nil
+}
+
+// verifyNotAfter returns the earliest expiry of the policy and any certificate or CRL involved in trusting it
+func verifyNotAfter(p policy.Policy, inputs verifyTrustInputs) time.Time {
+	notAfter := p.Expires.Time
+	earliest := func(t time.Time) {
+		if notAfter.IsZero() || t.Before(notAfter) {
+			notAfter = t
+		}
+	}
+
+	for _, certs := range [][]*x509.Certificate{inputs.policyRoots, inputs.policyIntermediates, inputs.timestampCerts} {
+		for _, cert := range certs {
+			earliest(cert.NotAfter)
+		}
+	}
+
+	// a cached outcome is only as fresh as the CRLs it was checked against
+	for _, crl := range inputs.crls {
+		if !crl.NextUpdate.IsZero() {
+			earliest(crl.NextUpdate)
+		}
+	}
+
+	for _, root := range p.Roots {
+		if cert, err := cryptoutil.TryParseCertificate(root.Certificate); err == nil {
+			earliest(cert.NotAfter)
+		}
+	}
+
+	for _, tsa := range p.TimestampAuthorities {
+		if cert, err := cryptoutil.TryParseCertificate(tsa.Certificate); err == nil {
+			earliest(cert.NotAfter)
+		}
+	}
+
+	return notAfter
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,157 @@
+// Copyright 2026 The Witness Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"time"
+
+	"github.com/in-toto/go-witness/cryptoutil"
+	"github.com/in-toto/go-witness/dsse"
+	"github.com/in-toto/go-witness/log"
+	"github.com/in-toto/go-witness/policy"
+	"github.com/in-toto/witness/internal/revocation"
+)
+
+// loadRevocationChecker creates a revocation checker from the CRL paths and OCSP responder. It returns nil if neither
+// was configured.
+func loadRevocationChecker(crlPaths []string, ocspResponder string) (*revocation.Checker, []*x509.RevocationList, error) {
+	if len(crlPaths) == 0 && ocspResponder == "" {
+		return nil, nil, nil
+	}
+
+	crls := []*x509.RevocationList{}
+	for _, path := range crlPaths {
+		crl, err := revocation.LoadCRL(path)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		crls = append(crls, crl)
+	}
+
+	opts := []revocation.Option{revocation.WithCRLs(crls...)}
+	if ocspResponder != "" {
+		opts = append(opts, revocation.WithOCSPResponder(ocspResponder))
+	}
+
+	return revocation.New(opts...), crls, nil
+}
+
+// checkPolicySignerRevocation checks the certificate chains of the policy signatures that chain to the provided roots
+// and returns the results along with the outcome of the check
+func checkPolicySignerRevocation(ctx context.Context, checker *revocation.Checker, policyEnvelope dsse.Envelope, roots, intermediates []*x509.Certificate, at time.Time, strict bool) ([]revocation.Result, error) {
+	if len(roots) == 0 {
+		return nil, nil
+	}
+
+	results := []revocation.Result{}
+	for _, sig := range policyEnvelope.Signatures {
+		if len(sig.Certificate) == 0 {
+			continue
+		}
+
+		cert, err := cryptoutil.TryParseCertificate(sig.Certificate)
+		if err != nil {
+			return results, fmt.Errorf("failed to parse policy signature certificate: %w", err)
+		}
+
+		sigIntermediates := append([]*x509.Certificate{}, intermediates...)
+		for _, intermediate := range sig.Intermediates {
+			intCert, err := cryptoutil.TryParseCertificate(intermediate)
+			if err != nil {
+				return results, fmt.Errorf("failed to parse policy signature intermediate: %w", err)
+			}
+
+			sigIntermediates = append(sigIntermediates, intCert)
+		}
+
+		sigResults, err := checker.CheckChain(ctx, cert, sigIntermediates, roots, at)
+		if err != nil {
+			// signatures that don't chain to the roots are rejected during verification
+			log.Debugf("skipping revocation check for policy signature: %v", err)
+			continue
+		}
+
+		results = append(results, sigResults...)
+	}
+
+	return results, revocationError("policy signer", results, strict)
+}
+
+// checkFunctionaryRevocation checks the certificate chains of every x.509 functionary that passed verification and
+// returns the results along with the outcome of the check
+func checkFunctionaryRevocation(ctx context.Context, checker *revocation.Checker, stepResults map[string]policy.StepResult, at time.Time, strict bool) ([]revocation.Result, error) {
+	results := []revocation.Result{}
+	for _, step := range stepResults {
+		for _, passed := range step.Passed {
+			for _, verifier := range passed.ValidFunctionaries {
+				x509Verifier, ok := verifier.(*cryptoutil.X509Verifier)
+				if !ok {
+					continue
+				}
+
+				functionaryResults, err := checker.CheckChain(ctx, x509Verifier.Certificate(), x509Verifier.Intermediates(), x509Verifier.Roots(), at)
+				if err != nil {
+					return results, fmt.Errorf("failed to check revocation for functionary in step %s: %w", step.Step, err)
+				}
+
+				results = append(results, functionaryResults...)
+			}
+		}
+	}
+
+	return results, revocationError("functionary", results, strict)
+}
+
+// revocationError fails if any certificate was revoked. Certificates with an unknown status, including those no CRL
+// or OCSP responder covers, only fail verification in strict mode. Otherwise the check fails open and they are only
+// reported as a warning by logRevocation.
+func revocationError(kind string, results []revocation.Result, strict bool) error {
+	if revoked := revocation.Revoked(results); len(revoked) > 0 {
+		return fmt.Errorf("%s certificate %s has been revoked", kind, revoked[0].Subject)
+	}
+
+	if strict {
+		for _, result := range results {
+			if result.Status == revocation.StatusUnknown {
+				return fmt.Errorf("revocation status of %s certificate is unknown: %s", kind, result)
+			}
+		}
+	}
+
+	return nil
+}
+
+// logRevocation adds the revocation results to the verification report
+func logRevocation(kind string, results []revocation.Result) {
+	if len(results) == 0 {
+		return
+	}
+
+	log.Infof("Revocation of %s certificates:", kind)
+	for _, result := range results {
+		switch result.Status {
+		case revocation.StatusRevoked:
+			log.Errorf("%s", result)
+		case revocation.StatusUnknown:
+			log.Warnf("%s", result)
+		default:
+			log.Infof("%s", result)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+// Copyright 2026 The Witness Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"testing"
+
+	"github.com/in-toto/witness/internal/revocation"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_revocationError(t *testing.T) {
+	good := revocation.Result{Subject: "CN=good", Serial: "1", Status: revocation.StatusGood, Source: revocation.SourceCRL}
+	unknown := revocation.Result{Subject: "CN=unknown", Serial: "2", Status: revocation.StatusUnknown, Source: revocation.SourceOCSP, Reason: "ocsp responder returned 500 Internal Server Error"}
+	revoked := revocation.Result{Subject: "CN=revoked", Serial: "3", Status: revocation.StatusRevoked, Source: revocation.SourceCRL}
+
+	assert.NoError(t, revocationError("functionary", []revocation.Result{good}, true))
+	assert.ErrorContains(t, revocationError("functionary", []revocation.Result{good, revoked}, false), "functionary certificate CN=revoked has been revoked")
+
+	// unknown statuses fail open unless strict
+	assert.NoError(t, revocationError("functionary", []revocation.Result{good, unknown}, false))
+	assert.ErrorContains(t, revocationError("functionary", []revocation.Result{good, unknown}, true), "revocation status of functionary certificate is unknown: CN=unknown")
+}
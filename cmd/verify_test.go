@@ -23,20 +23,28 @@ import (
 	"crypto/x509"
 	"encoding/json"
 	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"testing"
 	"time"
 
 	witness "github.com/in-toto/go-witness"
+	"github.com/in-toto/go-witness/archivista"
+	"github.com/in-toto/go-witness/attestation"
 	"github.com/in-toto/go-witness/attestation/commandrun"
 	"github.com/in-toto/go-witness/cryptoutil"
 	"github.com/in-toto/go-witness/dsse"
+	"github.com/in-toto/go-witness/intoto"
 	"github.com/in-toto/go-witness/log"
 	"github.com/in-toto/go-witness/policy"
 	"github.com/in-toto/go-witness/signer"
 	"github.com/in-toto/go-witness/signer/file"
+	"github.com/in-toto/go-witness/slsa"
 	"github.com/in-toto/witness/options"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -71,7 +79,7 @@ func TestVerifyPolicyWithFulcio(t *testing.T) {
 		PolicyOrganizations:    []string{"*"},
 	}
 
-	require.NoError(t, runVerify(context.Background(), vo))
+	require.NoError(t, runVerify(context.Background(), vo, nil))
 }
 
 // Same test but deliberately missing the CA file path for verifying the policy
@@ -101,7 +109,7 @@ func TestVerifyPolicyWrongCAFile(t *testing.T) {
 		ArtifactFilePath:       filepath.Join(cwd, "../test/test.txt"),
 	}
 
-	require.ErrorContains(t, runVerify(context.Background(), vo), "failed to verify policy: attestors failed with error messages\nattestor policyverify failed: failed to verify policy signature: could not verify policy: no valid signatures for the provided verifiers found for keyids:\n")
+	require.ErrorContains(t, runVerify(context.Background(), vo, nil), "failed to verify policy: attestors failed with error messages\nattestor policyverify failed: failed to verify policy signature: could not verify policy: no valid signatures for the provided verifiers found for keyids:\n")
 }
 
 func TestRunVerifyCA(t *testing.T) {
@@ -118,7 +126,7 @@ func TestRunVerifyCA(t *testing.T) {
 		},
 	}
 
-	signers, err := loadSigners(context.Background(), so, options.KMSSignerProviderOptions{}, map[string]struct{}{"file": {}})
+	signers, err := loadSigners(context.Background(), so, options.KMSSignerProviderOptions{}, map[string][]string{"file": nil}, false)
 	require.NoError(t, err)
 
 	caBytes, err := os.ReadFile(ca.Name())
@@ -189,7 +197,7 @@ func TestRunVerifyCA(t *testing.T) {
 		AdditionalSubjects:   subjects,
 	}
 
-	require.NoError(t, runVerify(context.Background(), vo))
+	require.NoError(t, runVerify(context.Background(), vo, nil))
 
 	// test that verify works without artifactfilepath but the subject of the modified articact also provided
 	artifactDigest, err = cryptoutil.CalculateDigestSetFromFile(artifactPath, []cryptoutil.DigestValue{{Hash: crypto.SHA256}})
@@ -205,7 +213,15 @@ func TestRunVerifyCA(t *testing.T) {
 		AdditionalSubjects:   subjects,
 	}
 
-	require.NoError(t, runVerify(context.Background(), vo))
+	require.NoError(t, runVerify(context.Background(), vo, nil))
+
+	// the summary was signed as passed before the functionary revocation check failed, so it isn't exported
+	vo.OCSPResponder = "http://127.0.0.1:1"
+	vo.RevocationStrict = true
+	vo.OutFilePath = filepath.Join(workingDir, "summary.json")
+	err = runVerify(context.Background(), vo, signers)
+	require.ErrorContains(t, err, "revocation status of functionary certificate is unknown")
+	assert.NoFileExists(t, vo.OutFilePath)
 }
 
 func TestRunVerifyKeyPair(t *testing.T) {
@@ -234,7 +250,7 @@ func TestRunVerifyKeyPair(t *testing.T) {
 		},
 	}
 
-	signers, err := loadSigners(context.Background(), so, options.KMSSignerProviderOptions{}, map[string]struct{}{"file": {}})
+	signers, err := loadSigners(context.Background(), so, options.KMSSignerProviderOptions{}, map[string][]string{"file": nil}, false)
 	require.NoError(t, err)
 
 	artifactPath := filepath.Join(workingDir, "test.txt")
@@ -290,7 +306,7 @@ func TestRunVerifyKeyPair(t *testing.T) {
 		AdditionalSubjects:   subjects,
 	}
 
-	require.NoError(t, runVerify(context.Background(), vo))
+	require.NoError(t, runVerify(context.Background(), vo, nil))
 
 	// test that verify works without artifactfilepath but the subject of the modified articact also provided
 	artifactDigest, err = cryptoutil.CalculateDigestSetFromFile(artifactPath, []cryptoutil.DigestValue{{Hash: crypto.SHA256}})
@@ -306,7 +322,140 @@ func TestRunVerifyKeyPair(t *testing.T) {
 		AdditionalSubjects:   subjects,
 	}
 
-	require.NoError(t, runVerify(context.Background(), vo))
+	require.NoError(t, runVerify(context.Background(), vo, nil))
+
+	// successful verifications are cached and reused until the attestation set changes
+	cacheDir := t.TempDir()
+	vo.CacheDir = cacheDir
+	vo.CacheTTL = time.Hour
+	require.NoError(t, runVerify(context.Background(), vo, nil))
+	require.NoError(t, runVerify(context.Background(), vo, nil))
+	entries, err := os.ReadDir(cacheDir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+
+	s1Bytes, err := os.ReadFile(s1FilePath)
+	require.NoError(t, err)
+	s1CopyPath := filepath.Join(attestationDir, "step01-copy.json")
+	require.NoError(t, os.WriteFile(s1CopyPath, s1Bytes, 0644))
+	vo.AttestationFilePaths = []string{s2FilePath, s1FilePath, s1CopyPath}
+	require.NoError(t, runVerify(context.Background(), vo, nil))
+	entries, err = os.ReadDir(cacheDir)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	// verifications reading attestations from Archivista are cached under the collections it returned
+	archivistaServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"data":{"dsses":{"edges":[]}}}`))
+	}))
+	defer archivistaServer.Close()
+	archivistaCacheDir := t.TempDir()
+	archivistaVo := vo
+	archivistaVo.CacheDir = archivistaCacheDir
+	archivistaVo.ArchivistaOptions = options.ArchivistaOptions{Enable: true, Url: archivistaServer.URL}
+	require.NoError(t, runVerify(context.Background(), archivistaVo, nil))
+	require.NoError(t, runVerify(context.Background(), archivistaVo, nil))
+	entries, err = os.ReadDir(archivistaCacheDir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	// the cache is skipped when an OCSP responder is configured, as its answers may change at any time
+	ocspCacheDir := t.TempDir()
+	ocspVo := vo
+	ocspVo.CacheDir = ocspCacheDir
+	ocspVo.OCSPResponder = archivistaServer.URL
+	require.NoError(t, runVerify(context.Background(), ocspVo, nil))
+	entries, err = os.ReadDir(ocspCacheDir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	// and when signing a verification summary, which a cached result can't produce
+	signerCacheDir := t.TempDir()
+	signerVo := vo
+	signerVo.CacheDir = signerCacheDir
+	signerVo.OutFilePath = filepath.Join(workingDir, "cached-summary.json")
+	require.NoError(t, runVerify(context.Background(), signerVo, signers))
+	entries, err = os.ReadDir(signerCacheDir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	// providing a signer produces a signed verification summary
+	summaryPath := filepath.Join(workingDir, "verification-summary.json")
+	vo.CacheDir = ""
+	vo.OutFilePath = summaryPath
+	require.NoError(t, runVerify(context.Background(), vo, signers))
+
+	summaryBytes, err := os.ReadFile(summaryPath)
+	require.NoError(t, err)
+	summaryEnv := dsse.Envelope{}
+	require.NoError(t, json.Unmarshal(summaryBytes, &summaryEnv))
+	require.Len(t, summaryEnv.Signatures, 1)
+
+	funcVerifier, err := signers[0].Verifier()
+	require.NoError(t, err)
+	_, err = summaryEnv.Verify(dsse.VerifyWithVerifiers(funcVerifier))
+	require.NoError(t, err)
+
+	statement := intoto.Statement{}
+	require.NoError(t, json.Unmarshal(summaryEnv.Payload, &statement))
+	collection := attestation.Collection{}
+	require.NoError(t, json.Unmarshal(statement.Predicate, &collection))
+	require.Len(t, collection.Attestations, 1)
+	require.Equal(t, slsa.VerificationSummaryPredicate, collection.Attestations[0].Type)
+
+	// the policy key can come from a trust config instead of a flag
+	trustConfigPath := filepath.Join(workingDir, "trust.yaml")
+	require.NoError(t, os.WriteFile(trustConfigPath, []byte("profiles:\n  release:\n    public-keys:\n      - policy-pub.pem\n"), 0644))
+	vo = options.VerifyOptions{
+		TrustConfigPath:      trustConfigPath,
+		AttestationFilePaths: []string{s1FilePath, s2FilePath},
+		PolicyFilePath:       policyFilePath,
+		AdditionalSubjects:   subjects,
+	}
+
+	require.NoError(t, runVerify(context.Background(), vo, nil))
+}
+
+func TestCacheBypassReason(t *testing.T) {
+	signer, _, _, _, err := createTestRSAKey()
+	require.NoError(t, err)
+
+	assert.Empty(t, cacheBypassReason(options.VerifyOptions{}, nil))
+	assert.Contains(t, cacheBypassReason(options.VerifyOptions{}, []cryptoutil.Signer{signer}), "signed verification summary")
+	assert.Contains(t, cacheBypassReason(options.VerifyOptions{OCSPResponder: "http://ocsp.example.com"}, nil), "OCSP")
+}
+
+func TestVerifyCacheKeyArchivista(t *testing.T) {
+	gitoid := "a"
+	searched := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := struct {
+			Variables archivista.SearchGitoidVariables `json:"variables"`
+		}{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		searched = append(searched, body.Variables.CollectionName)
+		assert.Equal(t, []string{commandrun.Type}, body.Variables.Attestations)
+		_, _ = fmt.Fprintf(w, `{"data":{"dsses":{"edges":[{"node":{"gitoidSha256":%q}}]}}}`, gitoid)
+	}))
+	defer server.Close()
+
+	p, _ := makepolicyRSAPub(t)
+	policyEnvelope := dsse.Envelope{Payload: p, PayloadType: "https://witness.testifysec.com/policy/v0.1"}
+	subjects := []cryptoutil.DigestSet{{cryptoutil.DigestValue{Hash: crypto.SHA256}: "abc"}}
+	vo := options.VerifyOptions{ArchivistaOptions: options.ArchivistaOptions{Enable: true, Url: server.URL}}
+	client, err := vo.ArchivistaOptions.Client()
+	require.NoError(t, err)
+
+	key, _, err := verifyCacheKey(context.Background(), vo, policyEnvelope, subjects, verifyTrustInputs{}, client)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"step01", "step02"}, searched)
+	assert.Equal(t, []string{server.URL + "/a", server.URL + "/a"}, key.AttestationDigests)
+
+	// a change in the collections Archivista returns changes the key
+	gitoid = "b"
+	other, _, err := verifyCacheKey(context.Background(), vo, policyEnvelope, subjects, verifyTrustInputs{}, client)
+	require.NoError(t, err)
+	assert.NotEqual(t, key.AttestationDigests, other.AttestationDigests)
 }
 
 func signPolicyRSA(t *testing.T, p []byte) (signedPolicy []byte, pub []byte) {
@@ -0,0 +1,433 @@
+// Copyright 2026 The Witness Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	witness "github.com/in-toto/go-witness"
+	"github.com/in-toto/go-witness/attestation"
+	"github.com/in-toto/go-witness/attestation/policyverify"
+	"github.com/in-toto/go-witness/cryptoutil"
+	"github.com/in-toto/go-witness/dsse"
+	"github.com/in-toto/go-witness/log"
+	"github.com/in-toto/go-witness/policy"
+	"github.com/in-toto/go-witness/slsa"
+	"github.com/in-toto/go-witness/source"
+	"github.com/in-toto/go-witness/timestamp"
+	"github.com/in-toto/witness/options"
+	"github.com/invopop/jsonschema"
+)
+
+// verificationTime determines the point in time a verification should be evaluated at.
+// A zero time means the verification should be evaluated at the current time.
+func verificationTime(ctx context.Context, vo options.VerifyOptions, policyEnvelope dsse.Envelope, attestations []namedEnvelope) (time.Time, error) {
+	if !vo.VerifyTimeFromTimestamps {
+		return vo.VerifyTime, nil
+	}
+
+	if !vo.VerifyTime.IsZero() {
+		return time.Time{}, fmt.Errorf("a verification time can't be provided when using timestamps as the verification time")
+	}
+
+	if vo.ArchivistaOptions.Enable {
+		return time.Time{}, fmt.Errorf("timestamps can only be used as the verification time for attestations passed with --attestations, not ones read from archivista")
+	}
+
+	p := policy.Policy{}
+	if err := json.Unmarshal(policyEnvelope.Payload, &p); err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse policy: %w", err)
+	}
+
+	tsVerifiers, err := policyTimestampVerifiers(p)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return attestationsTime(ctx, attestations, tsVerifiers)
+}
+
+// namedEnvelope is an attestation envelope along with where it was read from
+type namedEnvelope struct {
+	name     string
+	envelope dsse.Envelope
+}
+
+// policyTimestampVerifiers creates verifiers for the timestamp authorities the policy trusts to timestamp attestations
+func policyTimestampVerifiers(p policy.Policy) ([]timestamp.TimestampVerifier, error) {
+	bundles, err := p.TimestampAuthorityTrustBundles()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load policy timestamp authorities: %w", err)
+	}
+
+	verifiers := make([]timestamp.TimestampVerifier, 0, len(bundles))
+	for _, bundle := range bundles {
+		certs := append([]*x509.Certificate{bundle.Root}, bundle.Intermediates...)
+		verifiers = append(verifiers, timestamp.NewVerifier(timestamp.VerifyWithCerts(certs)))
+	}
+
+	return verifiers, nil
+}
+
+// attestationsTime returns the time by which all of the attestations had been produced, taken from the RFC3161
+// timestamps on their signatures. Each attestation is placed at the earliest of its timestamps that can be verified,
+// and the latest of those is returned. Every attestation must have a verifiable timestamp.
+func attestationsTime(ctx context.Context, attestations []namedEnvelope, tsVerifiers []timestamp.TimestampVerifier) (time.Time, error) {
+	if len(tsVerifiers) == 0 {
+		return time.Time{}, fmt.Errorf("the policy must have timestamp authorities to use timestamps as the verification time")
+	}
+
+	if len(attestations) == 0 {
+		return time.Time{}, fmt.Errorf("attestations must be provided to use their timestamps as the verification time")
+	}
+
+	var latest time.Time
+	for _, attestation := range attestations {
+		var signedAt time.Time
+		for _, sig := range attestation.envelope.Signatures {
+			for _, sigTimestamp := range sig.Timestamps {
+				if sigTimestamp.Type != dsse.TimestampRFC3161 {
+					continue
+				}
+
+				for _, tsVerifier := range tsVerifiers {
+					ts, err := tsVerifier.Verify(ctx, bytes.NewReader(sigTimestamp.Data), bytes.NewReader(sig.Signature))
+					if err != nil {
+						log.Debugf("failed to verify timestamp of attestation %s: %v", attestation.name, err)
+						continue
+					}
+
+					if signedAt.IsZero() || ts.Before(signedAt) {
+						signedAt = ts
+					}
+				}
+			}
+		}
+
+		if signedAt.IsZero() {
+			return time.Time{}, fmt.Errorf("attestation %s has no timestamps that can be verified with the policy's timestamp authorities", attestation.name)
+		}
+
+		if signedAt.After(latest) {
+			latest = signedAt
+		}
+	}
+
+	return latest, nil
+}
+
+// envelopeVerifiersAt creates verifiers for any x.509 certificates that signed the envelope. The certificates are
+// validated against the provided roots and intermediates at the verification time instead of the current time.
+func envelopeVerifiersAt(env dsse.Envelope, roots, intermediates []*x509.Certificate, verifyTime time.Time) []cryptoutil.Verifier {
+	verifiers := []cryptoutil.Verifier{}
+	for _, sig := range env.Signatures {
+		if len(sig.Certificate) == 0 {
+			continue
+		}
+
+		cert, err := cryptoutil.TryParseCertificate(sig.Certificate)
+		if err != nil {
+			log.Debugf("failed to parse signature certificate: %v", err)
+			continue
+		}
+
+		sigIntermediates := append([]*x509.Certificate{}, intermediates...)
+		for _, intermediate := range sig.Intermediates {
+			intCert, err := cryptoutil.TryParseCertificate(intermediate)
+			if err != nil {
+				continue
+			}
+
+			sigIntermediates = append(sigIntermediates, intCert)
+		}
+
+		verifier, err := cryptoutil.NewX509Verifier(cert, sigIntermediates, roots, verifyTime)
+		if err != nil {
+			log.Debugf("failed to create verifier for signature certificate: %v", err)
+			continue
+		}
+
+		verifiers = append(verifiers, verifier)
+	}
+
+	return verifiers
+}
+
+// verifyAtOptions holds what verifyAt needs from witness verify. It mirrors the options witness.Verify is called with.
+type verifyAtOptions struct {
+	subjects                 []cryptoutil.DigestSet
+	collectionSource         source.Sourcer
+	policyTimestampVerifiers []timestamp.TimestampVerifier
+	policyRoots              []*x509.Certificate
+	policyIntermediates      []*x509.Certificate
+	policyCertConstraint     policy.CertConstraint
+	policySignatureChecks    []policySignatureCheck
+	signers                  []cryptoutil.Signer
+	timestampers             []timestamp.Timestamper
+}
+
+// policyCertConstraint is the constraint the flags put on certificates that sign the policy
+func policyCertConstraint(vo options.VerifyOptions) policy.CertConstraint {
+	return policy.CertConstraint{
+		CommonName:    vo.PolicyCommonName,
+		DNSNames:      vo.PolicyDNSNames,
+		Emails:        vo.PolicyEmails,
+		Organizations: vo.PolicyOrganizations,
+		URIs:          vo.PolicyURIs,
+		Extensions:    vo.PolicyFulcioCertExtensions,
+	}
+}
+
+// verifyAt verifies the attestations against the policy like witness.Verify does, but evaluates the policy expiry and
+// the functionary certificates at the verification time. go-witness still compares the policy expiry to the current
+// time as well, so a policy that has expired since can't be verified.
+func verifyAt(ctx context.Context, policyEnvelope dsse.Envelope, policyVerifiers []cryptoutil.Verifier, verifyTime time.Time, opts verifyAtOptions) (witness.VerifyResult, error) {
+	attestor := &policyVerifyAtAttestor{
+		policyEnvelope:  policyEnvelope,
+		policyVerifiers: policyVerifiers,
+		verifyTime:      verifyTime,
+		opts:            opts,
+	}
+
+	for _, set := range opts.subjects {
+		for _, digest := range set {
+			attestor.subjectDigests = append(attestor.subjectDigests, digest)
+		}
+	}
+
+	runOpts := []witness.RunOption{witness.RunWithAttestors([]attestation.Attestor{attestor})}
+	if len(opts.signers) > 0 {
+		runOpts = append(runOpts, witness.RunWithSigners(opts.signers...), witness.RunWithTimestampers(opts.timestampers...))
+	} else {
+		runOpts = append(runOpts, witness.RunWithInsecure(true))
+	}
+
+	runResult, err := witness.Run("policyverify", runOpts...)
+	if err != nil {
+		return witness.VerifyResult{}, err
+	}
+
+	vr := witness.VerifyResult{
+		RunResult:           runResult,
+		VerificationSummary: attestor.VerificationSummary,
+		StepResults:         attestor.stepResults,
+	}
+
+	if vr.VerificationSummary.VerificationResult != slsa.PassedVerificationResult {
+		return vr, fmt.Errorf("policy verification failed")
+	}
+
+	return vr, nil
+}
+
+// policyVerifyAtAttestor produces the same verification summary as the policyverify attestor of go-witness, with the
+// policy expiry and the functionary certificates evaluated at the verification time.
+type policyVerifyAtAttestor struct {
+	slsa.VerificationSummary
+
+	policyEnvelope  dsse.Envelope
+	policyVerifiers []cryptoutil.Verifier
+	verifyTime      time.Time
+	opts            verifyAtOptions
+	subjectDigests  []string
+	stepResults     map[string]policy.StepResult
+}
+
+func (a *policyVerifyAtAttestor) Name() string {
+	return policyverify.Name
+}
+
+func (a *policyVerifyAtAttestor) Type() string {
+	return policyverify.Type
+}
+
+func (a *policyVerifyAtAttestor) RunType() attestation.RunType {
+	return policyverify.RunType
+}
+
+func (a *policyVerifyAtAttestor) Schema() *jsonschema.Schema {
+	return jsonschema.Reflect(&a)
+}
+
+func (a *policyVerifyAtAttestor) Subjects() map[string]cryptoutil.DigestSet {
+	subjects := map[string]cryptoutil.DigestSet{}
+	for _, digest := range a.subjectDigests {
+		subjects[fmt.Sprintf("artifact:%v", digest)] = cryptoutil.DigestSet{
+			cryptoutil.DigestValue{Hash: crypto.SHA256, GitOID: false}: digest,
+		}
+	}
+
+	subjects[fmt.Sprintf("policy:%v", a.Policy.URI)] = a.Policy.Digest
+	return subjects
+}
+
+func (a *policyVerifyAtAttestor) Attest(ctx *attestation.AttestationContext) error {
+	if err := verifyPolicySigners(a.policyEnvelope, a.policyVerifiers, a.verifyTime, a.opts); err != nil {
+		return fmt.Errorf("failed to verify policy signature: %w", err)
+	}
+
+	log.Info("policy signature verified")
+
+	pol := policy.Policy{}
+	if err := json.Unmarshal(a.policyEnvelope.Payload, &pol); err != nil {
+		return fmt.Errorf("failed to unmarshal policy from envelope: %w", err)
+	}
+
+	if a.verifyTime.After(pol.Expires.Time) {
+		return policy.ErrPolicyExpired(pol.Expires.Time)
+	}
+
+	pubKeysByID, err := pol.PublicKeyVerifiers(nil)
+	if err != nil {
+		return fmt.Errorf("failed to get public keys from policy: %w", err)
+	}
+
+	pubKeys := make([]cryptoutil.Verifier, 0, len(pubKeysByID))
+	for _, pubKey := range pubKeysByID {
+		pubKeys = append(pubKeys, pubKey)
+	}
+
+	trustBundlesByID, err := pol.TrustBundles()
+	if err != nil {
+		return fmt.Errorf("failed to load policy trust bundles: %w", err)
+	}
+
+	roots := make([]*x509.Certificate, 0, len(trustBundlesByID))
+	intermediates := make([]*x509.Certificate, 0)
+	for _, trustBundle := range trustBundlesByID {
+		roots = append(roots, trustBundle.Root)
+		intermediates = append(intermediates, trustBundle.Intermediates...)
+	}
+
+	verifiedSource := &verifiedSourceAt{
+		source:        a.opts.collectionSource,
+		pubKeys:       pubKeys,
+		roots:         roots,
+		intermediates: intermediates,
+		verifyTime:    a.verifyTime,
+	}
+
+	accepted, stepResults, err := pol.Verify(ctx.Context(), policy.WithSubjectDigests(a.subjectDigests), policy.WithVerifiedSource(verifiedSource))
+	if err != nil {
+		return fmt.Errorf("failed to verify policy: %w", err)
+	}
+
+	a.stepResults = stepResults
+	a.VerificationSummary, err = verificationSummary(ctx, a.policyEnvelope, stepResults, accepted)
+	if err != nil {
+		return fmt.Errorf("failed to generate verification summary: %w", err)
+	}
+
+	return nil
+}
+
+// verifiedSourceAt verifies the collections found in a source like source.VerifiedSource does, but validates the
+// signing certificates at the verification time instead of the current time or the time of their timestamps.
+type verifiedSourceAt struct {
+	source        source.Sourcer
+	pubKeys       []cryptoutil.Verifier
+	roots         []*x509.Certificate
+	intermediates []*x509.Certificate
+	verifyTime    time.Time
+}
+
+func (s *verifiedSourceAt) Search(ctx context.Context, collectionName string, subjectDigests, attestations []string) ([]source.CollectionVerificationResult, error) {
+	unverified, err := s.source.Search(ctx, collectionName, subjectDigests, attestations)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]source.CollectionVerificationResult, 0, len(unverified))
+	for _, toVerify := range unverified {
+		// no roots are passed along, so only the verifiers bound to the verification time can accept a certificate
+		verifiers := append(envelopeVerifiersAt(toVerify.Envelope, s.roots, s.intermediates, s.verifyTime), s.pubKeys...)
+		checkedVerifiers, err := toVerify.Envelope.Verify(dsse.VerifyWithVerifiers(verifiers...))
+		if err != nil {
+			results = append(results, source.CollectionVerificationResult{
+				Errors:             []error{fmt.Errorf("failed to verify envelope: %w", err)},
+				CollectionEnvelope: toVerify,
+			})
+			continue
+		}
+
+		passedVerifiers := make([]cryptoutil.Verifier, 0)
+		for _, checked := range checkedVerifiers {
+			if checked.Error == nil {
+				passedVerifiers = append(passedVerifiers, checked.Verifier)
+			}
+		}
+
+		var errs []error
+		if len(passedVerifiers) == 0 {
+			errs = append(errs, fmt.Errorf("no verifiers passed"))
+		}
+
+		results = append(results, source.CollectionVerificationResult{
+			Verifiers:          passedVerifiers,
+			CollectionEnvelope: toVerify,
+			Errors:             errs,
+		})
+	}
+
+	return results, nil
+}
+
+// verificationSummary builds the summary of a policy evaluation the same way the policyverify attestor does
+func verificationSummary(ctx *attestation.AttestationContext, policyEnvelope dsse.Envelope, stepResults map[string]policy.StepResult, accepted bool) (slsa.VerificationSummary, error) {
+	inputAttestations := make([]slsa.ResourceDescriptor, 0, len(stepResults))
+	for _, step := range stepResults {
+		for _, collection := range step.Passed {
+			digest, err := cryptoutil.CalculateDigestSetFromBytes(collection.Envelope.Payload, ctx.Hashes())
+			if err != nil {
+				log.Debugf("failed to calculate evidence hash: %v", err)
+				continue
+			}
+
+			inputAttestations = append(inputAttestations, slsa.ResourceDescriptor{
+				URI:    collection.Reference,
+				Digest: digest,
+			})
+		}
+	}
+
+	policyDigest, err := cryptoutil.CalculateDigestSetFromBytes(policyEnvelope.Payload, ctx.Hashes())
+	if err != nil {
+		return slsa.VerificationSummary{}, fmt.Errorf("failed to calculate policy digest: %w", err)
+	}
+
+	verificationResult := slsa.FailedVerificationResult
+	if accepted {
+		verificationResult = slsa.PassedVerificationResult
+	}
+
+	return slsa.VerificationSummary{
+		Verifier: slsa.Verifier{
+			ID: "witness",
+		},
+		TimeVerified: time.Now(),
+		Policy: slsa.ResourceDescriptor{
+			URI:    policy.PolicyPredicate,
+			Digest: policyDigest,
+		},
+		InputAttestations:  inputAttestations,
+		VerificationResult: verificationResult,
+	}, nil
+}
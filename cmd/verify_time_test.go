@@ -0,0 +1,300 @@
+// Copyright 2026 The Witness Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/in-toto/go-witness/attestation"
+	"github.com/in-toto/go-witness/cryptoutil"
+	"github.com/in-toto/go-witness/dsse"
+	"github.com/in-toto/go-witness/intoto"
+	"github.com/in-toto/go-witness/policy"
+	"github.com/in-toto/go-witness/signer"
+	"github.com/in-toto/go-witness/signer/file"
+	"github.com/in-toto/go-witness/slsa"
+	"github.com/in-toto/go-witness/timestamp"
+	"github.com/in-toto/witness/options"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func policyEnvelopeExpiring(t *testing.T, expires time.Time) dsse.Envelope {
+	p, err := json.Marshal(policy.Policy{Expires: metav1.NewTime(expires)})
+	require.NoError(t, err)
+	return dsse.Envelope{Payload: p, PayloadType: "https://witness.testifysec.com/policy/v0.1"}
+}
+
+func timestampedEnvelope(signedAt ...time.Time) dsse.Envelope {
+	env := dsse.Envelope{}
+	for _, ts := range signedAt {
+		env.Signatures = append(env.Signatures, dsse.Signature{
+			Signature:  []byte("sig"),
+			Timestamps: []dsse.SignatureTimestamp{{Type: dsse.TimestampRFC3161, Data: []byte(ts.Format(time.RFC3339))}},
+		})
+	}
+
+	return env
+}
+
+func TestVerificationTime(t *testing.T) {
+	ctx := context.Background()
+	signedAt := time.Now().Add(-time.Hour).Truncate(time.Second)
+	policyEnv := policyEnvelopeExpiring(t, time.Now().Add(time.Hour))
+	attestations := []namedEnvelope{{name: "step01.json", envelope: timestampedEnvelope(signedAt)}}
+
+	verifyTime, err := verificationTime(ctx, options.VerifyOptions{}, policyEnv, attestations)
+	require.NoError(t, err)
+	assert.True(t, verifyTime.IsZero())
+
+	verifyTime, err = verificationTime(ctx, options.VerifyOptions{VerifyTime: signedAt}, policyEnv, attestations)
+	require.NoError(t, err)
+	assert.Equal(t, signedAt, verifyTime)
+
+	vo := options.VerifyOptions{VerifyTimeFromTimestamps: true}
+	_, err = verificationTime(ctx, vo, policyEnv, attestations)
+	require.ErrorContains(t, err, "the policy must have timestamp authorities")
+
+	vo.ArchivistaOptions.Enable = true
+	_, err = verificationTime(ctx, vo, policyEnv, attestations)
+	require.ErrorContains(t, err, "not ones read from archivista")
+}
+
+func TestAttestationsTime(t *testing.T) {
+	ctx := context.Background()
+	first := time.Now().Add(-2 * time.Hour).Truncate(time.Second)
+	second := first.Add(time.Hour)
+	tsVerifiers := []timestamp.TimestampVerifier{timestamp.FakeTimestamper{T: first}, timestamp.FakeTimestamper{T: second}}
+
+	// the verification time is when the last attestation was produced
+	verifyTime, err := attestationsTime(ctx, []namedEnvelope{
+		{name: "step01.json", envelope: timestampedEnvelope(first)},
+		{name: "step02.json", envelope: timestampedEnvelope(second)},
+	}, tsVerifiers)
+	require.NoError(t, err)
+	assert.Equal(t, second, verifyTime)
+
+	// an attestation that was countersigned later was produced at its first timestamp
+	verifyTime, err = attestationsTime(ctx, []namedEnvelope{{name: "step01.json", envelope: timestampedEnvelope(second, first)}}, tsVerifiers)
+	require.NoError(t, err)
+	assert.Equal(t, first, verifyTime)
+
+	_, err = attestationsTime(ctx, []namedEnvelope{
+		{name: "step01.json", envelope: timestampedEnvelope(first)},
+		{name: "step02.json", envelope: timestampedEnvelope(second.Add(time.Minute))},
+	}, tsVerifiers)
+	require.ErrorContains(t, err, "attestation step02.json has no timestamps")
+
+	_, err = attestationsTime(ctx, []namedEnvelope{{name: "step01.json", envelope: dsse.Envelope{Signatures: []dsse.Signature{{Signature: []byte("sig")}}}}}, tsVerifiers)
+	require.ErrorContains(t, err, "attestation step01.json has no timestamps")
+
+	_, err = attestationsTime(ctx, nil, tsVerifiers)
+	require.ErrorContains(t, err, "attestations must be provided")
+}
+
+func TestEnvelopeVerifiersAt(t *testing.T) {
+	caFile, intermediateFiles, leafFile, _ := fullChain(t)
+	readCert := func(f *os.File) []byte {
+		b, err := os.ReadFile(f.Name())
+		require.NoError(t, err)
+		return b
+	}
+
+	ca, err := cryptoutil.TryParseCertificate(readCert(caFile))
+	require.NoError(t, err)
+
+	env := dsse.Envelope{
+		Signatures: []dsse.Signature{
+			{Certificate: readCert(leafFile), Intermediates: [][]byte{readCert(intermediateFiles[0])}},
+			// signatures made with a plain key are left to the provided verifiers
+			{KeyID: "key"},
+		},
+	}
+
+	verifiers := envelopeVerifiersAt(env, []*x509.Certificate{ca}, nil, time.Now().Add(time.Hour))
+	require.Len(t, verifiers, 1)
+	x509Verifier, ok := verifiers[0].(*cryptoutil.X509Verifier)
+	require.True(t, ok)
+	require.NoError(t, x509Verifier.BelongsToRoot(ca))
+
+	// the certificates weren't valid yet a year ago
+	verifiers = envelopeVerifiersAt(env, []*x509.Certificate{ca}, nil, time.Now().AddDate(-1, 0, 0))
+	require.Len(t, verifiers, 1)
+	require.Error(t, verifiers[0].(*cryptoutil.X509Verifier).BelongsToRoot(ca))
+}
+
+func TestRunVerifyAtTime(t *testing.T) {
+	p, funcPriv := makepolicyRSAPub(t)
+	expiring := policy.Policy{}
+	require.NoError(t, json.Unmarshal(p, &expiring))
+	expiring.Expires = metav1.NewTime(time.Now().Add(time.Hour))
+	p, err := json.Marshal(expiring)
+	require.NoError(t, err)
+
+	signedPolicy, pub := signPolicyRSA(t, p)
+	workingDir := t.TempDir()
+	policyFilePath := filepath.Join(workingDir, "signed-policy.json")
+	require.NoError(t, os.WriteFile(policyFilePath, signedPolicy, 0644))
+	policyPubFilePath := filepath.Join(workingDir, "policy-pub.pem")
+	require.NoError(t, os.WriteFile(policyPubFilePath, pub, 0644))
+	funcPrivFilepath := filepath.Join(workingDir, "func-priv.pem")
+	require.NoError(t, os.WriteFile(funcPrivFilepath, funcPriv, 0644))
+
+	so := options.SignerOptions{}
+	so["file"] = []func(signer.SignerProvider) (signer.SignerProvider, error){
+		func(sp signer.SignerProvider) (signer.SignerProvider, error) {
+			fsp := sp.(file.FileSignerProvider)
+			fsp.KeyPath = funcPrivFilepath
+			return fsp, nil
+		},
+	}
+
+	signers, err := loadSigners(context.Background(), so, options.KMSSignerProviderOptions{}, map[string][]string{"file": nil}, false)
+	require.NoError(t, err)
+
+	attestationPaths, subjects := runTestSteps(t, so, workingDir, signers)
+	vo := options.VerifyOptions{
+		KeyPath:              policyPubFilePath,
+		AttestationFilePaths: attestationPaths,
+		PolicyFilePath:       policyFilePath,
+		AdditionalSubjects:   subjects,
+		PolicyCommonName:     "*",
+		VerifyTime:           time.Now().Add(-time.Hour),
+	}
+
+	require.NoError(t, runVerify(context.Background(), vo, nil))
+
+	// and a signed summary can be produced for the historical verification
+	vo.OutFilePath = filepath.Join(workingDir, "verification-summary.json")
+	require.NoError(t, runVerify(context.Background(), vo, signers))
+	summaryBytes, err := os.ReadFile(vo.OutFilePath)
+	require.NoError(t, err)
+	summaryEnv := dsse.Envelope{}
+	require.NoError(t, json.Unmarshal(summaryBytes, &summaryEnv))
+	statement := intoto.Statement{}
+	require.NoError(t, json.Unmarshal(summaryEnv.Payload, &statement))
+	collection := attestation.Collection{}
+	require.NoError(t, json.Unmarshal(statement.Predicate, &collection))
+	require.Len(t, collection.Attestations, 1)
+	require.Equal(t, slsa.VerificationSummaryPredicate, collection.Attestations[0].Type)
+
+	// the policy will have expired by then
+	vo.OutFilePath = ""
+	vo.VerifyTime = time.Now().Add(2 * time.Hour)
+	require.ErrorContains(t, runVerify(context.Background(), vo, nil), "expired")
+}
+
+func TestRunVerifyAtTimeFunctionaryCertificates(t *testing.T) {
+	ca, intermediates, leafcert, leafkey := fullChain(t)
+	so := options.SignerOptions{}
+	so["file"] = []func(signer.SignerProvider) (signer.SignerProvider, error){
+		func(sp signer.SignerProvider) (signer.SignerProvider, error) {
+			fsp := sp.(file.FileSignerProvider)
+			fsp.KeyPath = leafkey.Name()
+			fsp.IntermediatePaths = []string{intermediates[0].Name()}
+			fsp.CertPath = leafcert.Name()
+			return fsp, nil
+		},
+	}
+
+	signers, err := loadSigners(context.Background(), so, options.KMSSignerProviderOptions{}, map[string][]string{"file": nil}, false)
+	require.NoError(t, err)
+
+	caBytes, err := os.ReadFile(ca.Name())
+	require.NoError(t, err)
+	signedPolicy, pub := signPolicyRSA(t, makepolicyCA(t, caBytes))
+	workingDir := t.TempDir()
+	policyFilePath := filepath.Join(workingDir, "signed-policy.json")
+	require.NoError(t, os.WriteFile(policyFilePath, signedPolicy, 0644))
+	policyPubFilePath := filepath.Join(workingDir, "policy-pub.pem")
+	require.NoError(t, os.WriteFile(policyPubFilePath, pub, 0644))
+
+	attestationPaths, subjects := runTestSteps(t, so, workingDir, signers)
+	vo := options.VerifyOptions{
+		KeyPath:              policyPubFilePath,
+		AttestationFilePaths: attestationPaths,
+		PolicyFilePath:       policyFilePath,
+		AdditionalSubjects:   subjects,
+		PolicyCommonName:     "*",
+		VerifyTime:           time.Now(),
+	}
+
+	require.NoError(t, runVerify(context.Background(), vo, nil))
+
+	// the functionary certificates weren't valid yet an hour ago
+	vo.VerifyTime = time.Now().Add(-time.Hour)
+	require.ErrorContains(t, runVerify(context.Background(), vo, nil), "policy verification failed")
+}
+
+// runTestSteps runs the two steps the test policies expect and returns the attestations and the subjects that let the
+// policy find both steps
+func runTestSteps(t *testing.T, so options.SignerOptions, workingDir string, signers []cryptoutil.Signer) ([]string, []string) {
+	attestationPaths := []string{}
+	subjects := []string{}
+	for _, step := range []string{"step01", "step02"} {
+		outFilePath := filepath.Join(t.TempDir(), step+".json")
+		ro := options.RunOptions{
+			SignerOptions: so,
+			WorkingDir:    workingDir,
+			Attestations:  []string{},
+			OutFilePath:   outFilePath,
+			StepName:      step,
+		}
+
+		require.NoError(t, runRun(context.Background(), ro, []string{"bash", "-c", "echo " + step + " >> test.txt"}, signers...))
+		attestationPaths = append(attestationPaths, outFilePath)
+
+		artifactDigest, err := cryptoutil.CalculateDigestSetFromFile(filepath.Join(workingDir, "test.txt"), []cryptoutil.DigestValue{{Hash: crypto.SHA256}})
+		require.NoError(t, err)
+		for _, digest := range artifactDigest {
+			subjects = append(subjects, digest)
+		}
+	}
+
+	return attestationPaths, subjects
+}
+
+func TestPolicyCheckVerifyTimeFromTimestamps(t *testing.T) {
+	dir := t.TempDir()
+	policyPath := filepath.Join(dir, "policy.json")
+	p, err := json.Marshal(policy.Policy{Expires: metav1.NewTime(time.Now().Add(time.Hour))})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(policyPath, p, 0644))
+
+	attestationPath := filepath.Join(dir, "step01.json")
+	env, err := json.Marshal(timestampedEnvelope(time.Now()))
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(attestationPath, env, 0644))
+
+	cmd := PolicyCheckCmd()
+	cmd.SetArgs([]string{policyPath, "--verify-time-from-timestamps"})
+	require.ErrorContains(t, cmd.Execute(), "attestations")
+
+	// the policy has no timestamp authorities to verify the attestation timestamps with
+	cmd = PolicyCheckCmd()
+	cmd.SetArgs([]string{policyPath, "--verify-time-from-timestamps", "--attestations", attestationPath})
+	require.ErrorContains(t, cmd.Execute(), "policy validation failed")
+
+	_, err = attestationFilesTime(context.Background(), policy.Policy{}, []string{attestationPath})
+	require.ErrorContains(t, err, "the policy must have timestamp authorities")
+}
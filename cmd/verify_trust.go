@@ -0,0 +1,247 @@
+// Copyright 2026 The Witness Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/in-toto/go-witness/cryptoutil"
+	"github.com/in-toto/go-witness/dsse"
+	"github.com/in-toto/go-witness/policy"
+	"github.com/in-toto/witness/internal/trust"
+	"github.com/in-toto/witness/options"
+)
+
+// loadTrustProfile reads the trust profile selected in the verify options. Identity constraints and Fulcio extensions
+// from the profile are copied into the options wherever the corresponding flag was left unset. Flags may still narrow
+// down a shared profile, such as picking some of its emails, but a value the profile doesn't allow is rejected rather
+// than replacing the profile's constraint.
+func loadTrustProfile(vo *options.VerifyOptions) (trust.Material, error) {
+	config, err := trust.Load(vo.TrustConfigPath)
+	if err != nil {
+		return trust.Material{}, err
+	}
+
+	profile, err := config.Profile(vo.TrustProfile)
+	if err != nil {
+		return trust.Material{}, err
+	}
+
+	material, err := profile.Material()
+	if err != nil {
+		return trust.Material{}, err
+	}
+
+	identity := profile.Identity
+	if !isUnconstrained(identity.CommonName) {
+		if isUnconstrained(vo.PolicyCommonName) {
+			vo.PolicyCommonName = identity.CommonName
+		} else if vo.PolicyCommonName != identity.CommonName {
+			return trust.Material{}, fmt.Errorf("--policy-commonname %q conflicts with the common name %q of the trust profile", vo.PolicyCommonName, identity.CommonName)
+		}
+	}
+
+	for _, constraint := range []struct {
+		name    string
+		flag    *[]string
+		profile []string
+	}{
+		{"policy-dns-names", &vo.PolicyDNSNames, identity.DNSNames},
+		{"policy-emails", &vo.PolicyEmails, identity.Emails},
+		{"policy-organizations", &vo.PolicyOrganizations, identity.Organizations},
+		{"policy-uris", &vo.PolicyURIs, identity.URIs},
+	} {
+		if isUnconstrainedList(constraint.profile) {
+			continue
+		}
+
+		if isUnconstrainedList(*constraint.flag) {
+			*constraint.flag = constraint.profile
+			continue
+		}
+
+		for _, value := range *constraint.flag {
+			if !slices.Contains(constraint.profile, value) {
+				return trust.Material{}, fmt.Errorf("--%s %q is not one of the values %v allowed by the trust profile", constraint.name, value, constraint.profile)
+			}
+		}
+	}
+
+	if err := fillEmptyStrings(reflect.ValueOf(&vo.PolicyFulcioCertExtensions).Elem(), reflect.ValueOf(profile.Fulcio), "fulcio"); err != nil {
+		return trust.Material{}, err
+	}
+
+	if err := fillEmptyStrings(reflect.ValueOf(&vo.PolicyIdentityRegexp).Elem(), reflect.ValueOf(identity.Regexp), "identity.regexp"); err != nil {
+		return trust.Material{}, err
+	}
+
+	return material, nil
+}
+
+// fillEmptyStrings copies every string field of src, including those of nested structs, into dst if it is empty
+// there. A field set to a different value on both sides is reported, as the flag would replace the profile's value.
+func fillEmptyStrings(dst, src reflect.Value, path string) error {
+	for i := 0; i < dst.NumField(); i++ {
+		field, value := dst.Field(i), src.Field(i)
+		name := path + "." + yamlName(dst.Type().Field(i))
+		switch field.Kind() {
+		case reflect.String:
+			if field.String() == "" {
+				field.SetString(value.String())
+			} else if value.String() != "" && value.String() != field.String() {
+				return fmt.Errorf("flag value %q conflicts with %s %q of the trust profile", field.String(), name, value.String())
+			}
+		case reflect.Struct:
+			if err := fillEmptyStrings(field, value, name); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// yamlName returns the key a struct field is read from in a trust config
+func yamlName(field reflect.StructField) string {
+	if name, _, _ := strings.Cut(field.Tag.Get("yaml"), ","); name != "" {
+		return name
+	}
+
+	return strings.ToLower(field.Name)
+}
+
+func isUnconstrainedList(values []string) bool {
+	return len(values) == 0 || (len(values) == 1 && isUnconstrained(values[0]))
+}
+
+func isUnconstrained(value string) bool {
+	return value == "" || value == "*"
+}
+
+// policySignatureCheck decides whether the certificate of a policy signature may be trusted
+type policySignatureCheck func(cert *x509.Certificate, intermediates []*x509.Certificate) error
+
+// identityCheck requires the certificate to match the identity expressions
+func identityCheck(identity trust.IdentityRegexp) policySignatureCheck {
+	return func(cert *x509.Certificate, _ []*x509.Certificate) error {
+		if err := identity.Match(cert); err != nil {
+			return fmt.Errorf("certificate %s doesn't match the identity constraints: %w", cert.Subject, err)
+		}
+
+		return nil
+	}
+}
+
+// trustedRootCheck requires certificates issued by an authority of the trusted root to be issued while the authority
+// was trusted. Other certificates are left to the remaining roots.
+func trustedRootCheck(trustedRoot trust.TrustedRoot) policySignatureCheck {
+	return func(cert *x509.Certificate, intermediates []*x509.Certificate) error {
+		if err := trustedRoot.CheckCertificate(cert, intermediates); err != nil && !errors.Is(err, trust.ErrUnknownAuthority) {
+			return err
+		}
+
+		return nil
+	}
+}
+
+// verifyPolicySigners checks that the policy was signed by a verifier that meets the policy signer constraints and
+// passes the signature checks, as go-witness does before evaluating a policy. Certificates are validated at the
+// verification time, or as go-witness validates them when it is zero. The error names the constraint or check each
+// signer failed.
+func verifyPolicySigners(policyEnvelope dsse.Envelope, policyVerifiers []cryptoutil.Verifier, verifyTime time.Time, opts verifyAtOptions) error {
+	verifyOpts := []dsse.VerificationOption{
+		dsse.VerifyWithVerifiers(policyVerifiers...),
+		dsse.VerifyWithTimestampVerifiers(opts.policyTimestampVerifiers...),
+		dsse.VerifyWithRoots(opts.policyRoots...),
+		dsse.VerifyWithIntermediates(opts.policyIntermediates...),
+	}
+
+	if !verifyTime.IsZero() {
+		// no roots are passed along, so only the verifiers bound to the verification time can accept a certificate
+		verifiers := append(envelopeVerifiersAt(policyEnvelope, opts.policyRoots, opts.policyIntermediates, verifyTime), policyVerifiers...)
+		verifyOpts = []dsse.VerificationOption{dsse.VerifyWithVerifiers(verifiers...)}
+	}
+
+	checkedVerifiers, err := policyEnvelope.Verify(verifyOpts...)
+	if err != nil {
+		return fmt.Errorf("could not verify policy: %w", err)
+	}
+
+	certConstraint := opts.policyCertConstraint
+	certConstraint.Roots = nil
+	trustBundles := make(map[string]policy.TrustBundle)
+	for _, root := range opts.policyRoots {
+		id := base64.StdEncoding.EncodeToString(root.Raw)
+		certConstraint.Roots = append(certConstraint.Roots, id)
+		trustBundles[id] = policy.TrustBundle{Root: root}
+	}
+
+	errs := []error{}
+	for _, checked := range checkedVerifiers {
+		if checked.Error != nil {
+			continue
+		}
+
+		keyID, err := checked.Verifier.KeyID()
+		if err != nil {
+			return fmt.Errorf("could not get verifier key id: %w", err)
+		}
+
+		functionary := policy.Functionary{Type: "key", PublicKeyID: keyID}
+		x509Verifier, isX509 := checked.Verifier.(*cryptoutil.X509Verifier)
+		if isX509 {
+			functionary = policy.Functionary{Type: "root", CertConstraint: certConstraint}
+		}
+
+		if err := functionary.Validate(checked.Verifier, trustBundles); err != nil {
+			errs = append(errs, fmt.Errorf("policy verifier %s failed to match the supplied constraints: %w", keyID, err))
+			continue
+		}
+
+		// signatures made with a plain key are only checked against the provided keys
+		if isX509 {
+			if err := checkPolicySignature(x509Verifier, opts.policySignatureChecks); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+		}
+
+		return nil
+	}
+
+	if len(errs) == 0 {
+		return fmt.Errorf("no policy verifiers passed verification")
+	}
+
+	return fmt.Errorf("no policy verifiers passed verification: %w", errors.Join(errs...))
+}
+
+// checkPolicySignature runs the signature checks on the certificate of a policy signer
+func checkPolicySignature(verifier *cryptoutil.X509Verifier, checks []policySignatureCheck) error {
+	for _, check := range checks {
+		if err := check(verifier.Certificate(), verifier.Intermediates()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,309 @@
+// Copyright 2026 The Witness Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/in-toto/go-witness/cryptoutil"
+	"github.com/in-toto/go-witness/dsse"
+	"github.com/in-toto/go-witness/signer"
+	"github.com/in-toto/go-witness/signer/file"
+	"github.com/in-toto/witness/internal/detached"
+	"github.com/in-toto/witness/internal/trust"
+	"github.com/in-toto/witness/options"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadTrustProfile(t *testing.T) {
+	ca, intermediates, _, _ := fullChain(t)
+	dir := t.TempDir()
+	config := `
+profiles:
+  release:
+    roots: [` + ca.Name() + `]
+    intermediates: [` + intermediates[0].Name() + `]
+    identity:
+      common-name: Witness Testing Leaf
+      organizations: [Witness Testing]
+      regexp:
+        uris: ^https://github\.com/in-toto/
+    fulcio:
+      issuer: https://token.actions.githubusercontent.com
+      source-repository-ref: refs/heads/main
+`
+	configPath := filepath.Join(dir, "trust.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte(config), 0644))
+
+	vo := options.VerifyOptions{
+		TrustConfigPath:     configPath,
+		PolicyCommonName:    "*",
+		PolicyOrganizations: []string{"*"},
+		PolicyEmails:        []string{"release@example.com"},
+	}
+	vo.PolicyFulcioCertExtensions.SourceRepositoryRef = "refs/heads/main"
+
+	vo.PolicyIdentityRegexp.Emails = `@example\.com$`
+
+	material, err := loadTrustProfile(&vo)
+	require.NoError(t, err)
+	assert.Len(t, material.Roots, 1)
+	assert.Len(t, material.Intermediates, 1)
+	assert.Equal(t, `^https://github\.com/in-toto/`, vo.PolicyIdentityRegexp.URIs)
+	assert.Equal(t, `@example\.com$`, vo.PolicyIdentityRegexp.Emails)
+
+	// constraints from the profile fill in what the flags left open
+	assert.Equal(t, "Witness Testing Leaf", vo.PolicyCommonName)
+	assert.Equal(t, []string{"Witness Testing"}, vo.PolicyOrganizations)
+	assert.Equal(t, []string{"release@example.com"}, vo.PolicyEmails)
+	assert.Equal(t, "https://token.actions.githubusercontent.com", vo.PolicyFulcioCertExtensions.Issuer)
+	assert.Equal(t, "refs/heads/main", vo.PolicyFulcioCertExtensions.SourceRepositoryRef)
+
+	// flags may narrow down the profile, but never widen it
+	narrowed := options.VerifyOptions{TrustConfigPath: configPath, PolicyOrganizations: []string{"Witness Testing"}}
+	_, err = loadTrustProfile(&narrowed)
+	require.NoError(t, err)
+
+	for name, widen := range map[string]func(vo *options.VerifyOptions){
+		"common name":   func(vo *options.VerifyOptions) { vo.PolicyCommonName = "Another Leaf" },
+		"organizations": func(vo *options.VerifyOptions) { vo.PolicyOrganizations = []string{"Witness Testing", "Someone Else"} },
+		"fulcio":        func(vo *options.VerifyOptions) { vo.PolicyFulcioCertExtensions.SourceRepositoryRef = "refs/tags/*" },
+		"regexp":        func(vo *options.VerifyOptions) { vo.PolicyIdentityRegexp.URIs = `^https://` },
+	} {
+		t.Run(name, func(t *testing.T) {
+			vo := options.VerifyOptions{TrustConfigPath: configPath}
+			widen(&vo)
+			_, err := loadTrustProfile(&vo)
+			assert.ErrorContains(t, err, "trust profile")
+		})
+	}
+}
+
+func TestVerifyPolicySigners(t *testing.T) {
+	caFile, intermediateFiles, leafFile, leafKeyFile := fullChain(t)
+	caBytes, err := os.ReadFile(caFile.Name())
+	require.NoError(t, err)
+	ca, err := cryptoutil.TryParseCertificate(caBytes)
+	require.NoError(t, err)
+
+	signerOptions := options.SignerOptions{}
+	signerOptions["file"] = []func(signer.SignerProvider) (signer.SignerProvider, error){
+		func(sp signer.SignerProvider) (signer.SignerProvider, error) {
+			fsp := sp.(file.FileSignerProvider)
+			fsp.KeyPath = leafKeyFile.Name()
+			fsp.CertPath = leafFile.Name()
+			fsp.IntermediatePaths = []string{intermediateFiles[0].Name()}
+			return fsp, nil
+		},
+	}
+
+	signers, err := loadSigners(context.Background(), signerOptions, options.KMSSignerProviderOptions{}, map[string][]string{"file": nil}, false)
+	require.NoError(t, err)
+	env, err := dsse.Sign("https://witness.testifysec.com/policy/v0.1", bytes.NewReader([]byte("{}")), dsse.SignWithSigners(signers...))
+	require.NoError(t, err)
+
+	opts := verifyAtOptions{
+		policyRoots:          []*x509.Certificate{ca},
+		policyCertConstraint: policyCertConstraint(options.VerifyOptions{
+			PolicyCommonName:    "*",
+			PolicyDNSNames:      []string{"*"},
+			PolicyEmails:        []string{"*"},
+			PolicyOrganizations: []string{"*"},
+			PolicyURIs:          []string{"*"},
+		}),
+	}
+
+	require.NoError(t, verifyPolicySigners(env, nil, time.Time{}, opts))
+
+	opts.policySignatureChecks = []policySignatureCheck{identityCheck(trust.IdentityRegexp{CommonName: "^Witness Testing"})}
+	require.NoError(t, verifyPolicySigners(env, nil, time.Time{}, opts))
+
+	// the signer is verified before the checks, and the error names the constraint it failed
+	opts.policySignatureChecks = []policySignatureCheck{identityCheck(trust.IdentityRegexp{CommonName: "^Someone Else$"})}
+	err = verifyPolicySigners(env, nil, time.Time{}, opts)
+	require.ErrorContains(t, err, "doesn't match the identity constraints")
+	require.ErrorContains(t, err, `cert common name Witness Testing Leaf doesn't match "^Someone Else$"`)
+
+	opts.policySignatureChecks = []policySignatureCheck{identityCheck(trust.IdentityRegexp{Emails: ".*@example\\.com$"})}
+	require.ErrorContains(t, verifyPolicySigners(env, nil, time.Time{}, opts), "cert has no email")
+
+	// the constraints go-witness enforces are reported the same way
+	opts.policySignatureChecks = nil
+	opts.policyCertConstraint.CommonName = "Someone Else"
+	require.ErrorContains(t, verifyPolicySigners(env, nil, time.Time{}, opts), "failed to match the supplied constraints")
+
+	// without a root to chain to, no signer verifies
+	opts.policyRoots = nil
+	require.ErrorContains(t, verifyPolicySigners(env, nil, time.Time{}, opts), "could not verify policy")
+}
+
+func TestTrustedRootCheck(t *testing.T) {
+	caFile, intermediateFiles, leafFile, _ := fullChain(t)
+	readCert := func(f *os.File) *x509.Certificate {
+		b, err := os.ReadFile(f.Name())
+		require.NoError(t, err)
+		cert, err := cryptoutil.TryParseCertificate(b)
+		require.NoError(t, err)
+		return cert
+	}
+
+	ca, intermediate, leaf := readCert(caFile), readCert(intermediateFiles[0]), readCert(leafFile)
+	intermediates := []*x509.Certificate{intermediate}
+
+	authority := trust.Authority{Root: ca, Intermediates: []*x509.Certificate{intermediate}, Start: leaf.NotBefore.Add(-time.Hour)}
+	require.NoError(t, trustedRootCheck(trust.TrustedRoot{CertificateAuthorities: []trust.Authority{authority}})(leaf, intermediates))
+
+	// the leaf was issued after the authority stopped being trusted
+	authority.End = leaf.NotBefore.Add(-time.Minute)
+	require.ErrorContains(t, trustedRootCheck(trust.TrustedRoot{CertificateAuthorities: []trust.Authority{authority}})(leaf, intermediates), "outside the validity window")
+
+	// certificates from other authorities are left to the remaining roots
+	require.NoError(t, trustedRootCheck(trust.TrustedRoot{})(leaf, intermediates))
+}
+
+func Test_checkPolicySigners(t *testing.T) {
+	caFile, intermediateFiles, leafFile, leafKeyFile := fullChain(t)
+	readCert := func(f *os.File) *x509.Certificate {
+		b, err := os.ReadFile(f.Name())
+		require.NoError(t, err)
+		cert, err := cryptoutil.TryParseCertificate(b)
+		require.NoError(t, err)
+		return cert
+	}
+
+	ca, intermediate, leaf := readCert(caFile), readCert(intermediateFiles[0]), readCert(leafFile)
+	signerOptions := options.SignerOptions{}
+	signerOptions["file"] = []func(signer.SignerProvider) (signer.SignerProvider, error){
+		func(sp signer.SignerProvider) (signer.SignerProvider, error) {
+			fsp := sp.(file.FileSignerProvider)
+			fsp.KeyPath = leafKeyFile.Name()
+			fsp.CertPath = leafFile.Name()
+			fsp.IntermediatePaths = []string{intermediateFiles[0].Name()}
+			return fsp, nil
+		},
+	}
+
+	signers, err := loadSigners(context.Background(), signerOptions, options.KMSSignerProviderOptions{}, map[string][]string{"file": nil}, false)
+	require.NoError(t, err)
+	env, err := dsse.Sign("https://witness.testifysec.com/policy/v0.1", bytes.NewReader([]byte("{}")), dsse.SignWithSigners(signers...))
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	trustedRootPath := filepath.Join(dir, "trusted_root.json")
+	chain := struct {
+		Certificates []map[string][]byte `json:"certificates"`
+	}{}
+	for _, cert := range []*x509.Certificate{intermediate, ca} {
+		chain.Certificates = append(chain.Certificates, map[string][]byte{"rawBytes": cert.Raw})
+	}
+
+	trustedRoot, err := json.Marshal(map[string]any{
+		"mediaType": "application/vnd.dev.sigstore.trustedroot+json;version=0.1",
+		"certificateAuthorities": []any{map[string]any{
+			"uri":       "https://example.com",
+			"certChain": chain,
+			"validFor":  map[string]any{"start": leaf.NotBefore.Add(-time.Hour)},
+		}},
+	})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(trustedRootPath, trustedRoot, 0644))
+
+	checkFile := func(policyPath string) *ValidationResult {
+		_, envelope, _, err := readPolicyEnvelope(policyPath, false)
+		require.NoError(t, err)
+		result := &ValidationResult{Valid: true}
+		checkPolicySigners(context.Background(), envelope, trustedRootPath, time.Now(), result, nil, false)
+		return result
+	}
+
+	check := func(env dsse.Envelope) *ValidationResult {
+		policyPath := filepath.Join(dir, "policy-signed.json")
+		b, err := json.Marshal(env)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(policyPath, b, 0644))
+		return checkFile(policyPath)
+	}
+
+	result := check(env)
+	assert.True(t, result.Valid, result.Errors)
+
+	// the signatures of a detached policy are checked against the payload next to it
+	detachedPath := filepath.Join(dir, "policy.json"+detached.SignatureExtension)
+	b, err := json.Marshal(detached.Detach(env))
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(detachedPath, b, 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "policy.json"), env.Payload, 0644))
+	result = checkFile(detachedPath)
+	assert.True(t, result.Valid, result.Errors)
+
+	// a trusted certificate on a signature it didn't make doesn't pass
+	tampered := env
+	tampered.Signatures = []dsse.Signature{env.Signatures[0]}
+	tampered.Signatures[0].Signature = bytes.Repeat([]byte{1}, len(env.Signatures[0].Signature))
+	result = check(tampered)
+	assert.False(t, result.Valid)
+	require.Len(t, result.Errors, 1)
+	assert.Contains(t, result.Errors[0].Message, "does not verify against its certificate")
+
+	// neither does a signature over a different policy
+	tampered = env
+	tampered.Payload = []byte(`{"expires":"2100-01-01T00:00:00Z"}`)
+	result = check(tampered)
+	assert.False(t, result.Valid)
+
+	// the revocation status of the signer chain is only a warning when it can't be determined, unless strict
+	policyPath := filepath.Join(dir, "policy-signed.json")
+	env, err = dsse.Sign("https://witness.testifysec.com/policy/v0.1", bytes.NewReader([]byte(`{"expires":"2100-01-01T00:00:00Z"}`)), dsse.SignWithSigners(signers...))
+	require.NoError(t, err)
+	b, err = json.Marshal(env)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(policyPath, b, 0644))
+	checker, _, err := loadRevocationChecker(nil, "http://127.0.0.1:1")
+	require.NoError(t, err)
+	_, envelope, _, err := readPolicyEnvelope(policyPath, false)
+	require.NoError(t, err)
+	result = &ValidationResult{Valid: true}
+	checkPolicySigners(context.Background(), envelope, trustedRootPath, time.Now(), result, checker, false)
+	assert.True(t, result.Valid, result.Errors)
+	assert.NotEmpty(t, result.Warnings)
+
+	result = &ValidationResult{Valid: true}
+	checkPolicySigners(context.Background(), envelope, trustedRootPath, time.Now(), result, checker, true)
+	assert.False(t, result.Valid)
+	require.Len(t, result.Errors, 1)
+	assert.Equal(t, "Revocation", result.Errors[0].Category)
+	assert.Contains(t, result.Errors[0].Message, "revocation status of policy signer certificate is unknown")
+
+	// the flags are wired into policy check, which needs the trusted root to build the signer chains
+	cmd := PolicyCheckCmd()
+	cmd.SetArgs([]string{policyPath, "--trusted-root", trustedRootPath, "--ocsp-responder", "http://127.0.0.1:1", "--revocation-strict"})
+	require.ErrorContains(t, cmd.Execute(), "policy validation failed")
+
+	cmd = PolicyCheckCmd()
+	cmd.SetArgs([]string{policyPath, "--trusted-root", trustedRootPath, "--ocsp-responder", "http://127.0.0.1:1"})
+	require.NoError(t, cmd.Execute())
+
+	cmd = PolicyCheckCmd()
+	cmd.SetArgs([]string{policyPath, "--ocsp-responder", "http://127.0.0.1:1"})
+	require.ErrorContains(t, cmd.Execute(), "policy validation failed")
+}
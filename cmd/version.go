@@ -16,6 +16,7 @@ package cmd
 
 import (
 	"fmt"
+	"runtime"
 
 	"github.com/spf13/cobra"
 )
@@ -24,15 +25,35 @@ var Version = "dev"
 
 // versionCmd represents the version command
 func VersionCmd() *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:               "version",
 		Short:             "Prints out the witness version",
-		Long:              `Prints out the witness version`,
+		Long:              `Prints out the witness version. With --verbose it also prints the Go version and platform witness was built with, and the KMS backends the binary supports`,
 		SilenceErrors:     true,
 		SilenceUsage:      true,
 		DisableAutoGenTag: true,
 		Run: func(cmd *cobra.Command, args []string) {
 			fmt.Printf("witness %s\n", Version)
+			if verbose, _ := cmd.Flags().GetBool("verbose"); verbose {
+				printBuildInfo()
+			}
 		},
 	}
+
+	cmd.Flags().BoolP("verbose", "v", false, "Print the Go version, platform and available KMS backends of this build")
+	return cmd
+}
+
+func printBuildInfo() {
+	fmt.Printf("go version: %s\n", runtime.Version())
+	fmt.Printf("platform: %s/%s\n", runtime.GOOS, runtime.GOARCH)
+	fmt.Println("kms backends:")
+	for _, backend := range kmsBackends() {
+		if backend.Disabled {
+			fmt.Printf("  %s (disabled)\n", backend.Scheme)
+			continue
+		}
+
+		fmt.Printf("  %s (%s)\n", backend.Scheme, backend.Provider)
+	}
 }
@@ -16,6 +16,7 @@ package cmd
 
 import (
 	"bytes"
+	"fmt"
 	"io"
 	"os"
 	"testing"
@@ -55,3 +56,35 @@ func TestVersionCmd(t *testing.T) {
 	// Verify output
 	assert.Equal(t, "witness dev\n", buf.String())
 }
+
+func TestVersionCmdVerbose(t *testing.T) {
+	cmd := VersionCmd()
+	require.NoError(t, cmd.Flags().Set("verbose", "true"))
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	cmd.Run(cmd, []string{})
+
+	_ = w.Close()
+	os.Stdout = oldStdout
+
+	var buf bytes.Buffer
+	_, err := io.Copy(&buf, r)
+	require.NoError(t, err)
+
+	out := buf.String()
+	assert.Contains(t, out, "witness dev\n")
+	assert.Contains(t, out, "go version: ")
+	assert.Contains(t, out, "kms backends:\n")
+
+	// backends switched off with their no_kms_<backend> tag are listed as disabled
+	for scheme, provider := range map[string]string{"awskms://": "kms-aws", "gcpkms://": "kms-gcp", "hashivault://": "kms-hashivault"} {
+		if _, ok := disabledKMSBackends[scheme]; ok {
+			assert.Contains(t, out, fmt.Sprintf("  %s (disabled)\n", scheme))
+		} else {
+			assert.Contains(t, out, fmt.Sprintf("  %s (%s)\n", scheme, provider))
+		}
+	}
+}
@@ -0,0 +1,172 @@
+// Copyright 2026 The Witness Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/in-toto/go-witness/log"
+)
+
+// Key identifies a verification outcome. Any change to the policy, the subjects being verified, the inputs used to
+// establish trust in the policy or the set of attestations available results in a different key.
+type Key struct {
+	PolicyDigest       string   `json:"policyDigest"`
+	SubjectDigests     []string `json:"subjectDigests"`
+	TrustDigest        string   `json:"trustDigest"`
+	AttestationDigests []string `json:"attestationDigests"`
+}
+
+// String returns a stable digest of the key that is used as the name of the cache entry
+func (k Key) String() string {
+	sorted := Key{
+		PolicyDigest:       k.PolicyDigest,
+		SubjectDigests:     sortedCopy(k.SubjectDigests),
+		TrustDigest:        k.TrustDigest,
+		AttestationDigests: sortedCopy(k.AttestationDigests),
+	}
+
+	// Key only contains strings and string slices, so marshalling can't fail
+	b, _ := json.Marshal(sorted)
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// Entry is a cached successful verification
+type Entry struct {
+	Key       string              `json:"key"`
+	CreatedAt time.Time           `json:"createdAt"`
+	ExpiresAt time.Time           `json:"expiresAt"`
+	Evidence  map[string][]string `json:"evidence"`
+}
+
+type Cache struct {
+	dir string
+	ttl time.Duration
+}
+
+// New creates a cache that stores entries in dir. Entries are considered stale once ttl has passed since they were
+// created, or once the expiry provided when storing the entry has been reached, whichever comes first.
+func New(dir string, ttl time.Duration) (*Cache, error) {
+	if ttl <= 0 {
+		return nil, fmt.Errorf("cache ttl must be greater than zero")
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	return &Cache{dir: dir, ttl: ttl}, nil
+}
+
+// Get returns the entry for key if one exists and is still valid at the provided time.
+// Stale entries are removed from the cache.
+func (c *Cache) Get(key Key, now time.Time) (Entry, bool, error) {
+	entry := Entry{}
+	path := c.path(key)
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return entry, false, nil
+	} else if err != nil {
+		return entry, false, fmt.Errorf("failed to read cache entry: %w", err)
+	}
+
+	if err := json.Unmarshal(b, &entry); err != nil || entry.Key != key.String() {
+		log.Debugf("discarding unreadable cache entry %s", path)
+		return entry, false, c.remove(path)
+	}
+
+	if !now.Before(entry.ExpiresAt) || now.Before(entry.CreatedAt) {
+		log.Debugf("discarding stale cache entry %s", path)
+		return entry, false, c.remove(path)
+	}
+
+	return entry, true, nil
+}
+
+// Put stores the evidence for a successful verification under key. notAfter is the latest time the outcome may be
+// reused, such as the expiry of the policy or of the certificates used to verify it.
+func (c *Cache) Put(key Key, now time.Time, notAfter time.Time, evidence map[string][]string) error {
+	expiresAt := now.Add(c.ttl)
+	if !notAfter.IsZero() && notAfter.Before(expiresAt) {
+		expiresAt = notAfter
+	}
+
+	if !now.Before(expiresAt) {
+		return nil
+	}
+
+	entry := Entry{
+		Key:       key.String(),
+		CreatedAt: now,
+		ExpiresAt: expiresAt,
+		Evidence:  evidence,
+	}
+
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal cache entry: %w", err)
+	}
+
+	// write to a temporary file first so concurrent readers never see a partially written entry
+	tmp, err := os.CreateTemp(c.dir, "entry-*")
+	if err != nil {
+		return fmt.Errorf("failed to create cache entry: %w", err)
+	}
+
+	if _, err := tmp.Write(b); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), c.path(key)); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+
+	return nil
+}
+
+func (c *Cache) path(key Key) string {
+	return filepath.Join(c.dir, key.String()+".json")
+}
+
+func (c *Cache) remove(path string) error {
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove cache entry: %w", err)
+	}
+
+	return nil
+}
+
+func sortedCopy(s []string) []string {
+	c := append([]string{}, s...)
+	sort.Strings(c)
+	return c
+}
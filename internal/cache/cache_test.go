@@ -0,0 +1,99 @@
+// Copyright 2026 The Witness Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testKey() Key {
+	return Key{
+		PolicyDigest:       "policy",
+		SubjectDigests:     []string{"sha256:b", "sha256:a"},
+		TrustDigest:        "trust",
+		AttestationDigests: []string{"att2", "att1"},
+	}
+}
+
+func TestKeyIsOrderIndependent(t *testing.T) {
+	k1 := testKey()
+	k2 := testKey()
+	k2.SubjectDigests = []string{"sha256:a", "sha256:b"}
+	k2.AttestationDigests = []string{"att1", "att2"}
+	assert.Equal(t, k1.String(), k2.String())
+
+	k2.AttestationDigests = append(k2.AttestationDigests, "att3")
+	assert.NotEqual(t, k1.String(), k2.String())
+}
+
+func TestCacheRoundTrip(t *testing.T) {
+	c, err := New(t.TempDir(), time.Hour)
+	require.NoError(t, err)
+
+	now := time.Now()
+	evidence := map[string][]string{"build": {"build.json"}}
+	require.NoError(t, c.Put(testKey(), now, time.Time{}, evidence))
+
+	entry, ok, err := c.Get(testKey(), now.Add(time.Minute))
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, evidence, entry.Evidence)
+	assert.WithinDuration(t, now.Add(time.Hour), entry.ExpiresAt, time.Second)
+
+	other := testKey()
+	other.TrustDigest = "other"
+	_, ok, err = c.Get(other, now)
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestCacheExpiry(t *testing.T) {
+	dir := t.TempDir()
+	c, err := New(dir, time.Hour)
+	require.NoError(t, err)
+
+	now := time.Now()
+	notAfter := now.Add(10 * time.Minute)
+	require.NoError(t, c.Put(testKey(), now, notAfter, nil))
+
+	_, ok, err := c.Get(testKey(), now.Add(5*time.Minute))
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	// the entry must not outlive notAfter even though the ttl hasn't passed
+	_, ok, err = c.Get(testKey(), now.Add(15*time.Minute))
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries, "stale entries should be removed")
+
+	// outcomes that are already expired are never stored
+	require.NoError(t, c.Put(testKey(), now, now.Add(-time.Minute), nil))
+	entries, err = os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestNewRejectsInvalidTTL(t *testing.T) {
+	_, err := New(t.TempDir(), 0)
+	require.Error(t, err)
+}
@@ -0,0 +1,140 @@
+// Copyright 2026 The Witness Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package detached reads and writes DSSE envelopes that leave their payload out and identify it by digest instead.
+// The payload is reattached from the original file when the envelope is loaded.
+package detached
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/in-toto/go-witness/dsse"
+	"github.com/in-toto/witness/internal/stdio"
+)
+
+// SignatureExtension is appended to the path of a payload to name its sidecar envelope
+const SignatureExtension = ".sig"
+
+const digestAlgorithm = "sha256"
+
+// Envelope is a DSSE envelope without its payload
+type Envelope struct {
+	PayloadType   string            `json:"payloadType"`
+	PayloadDigest map[string]string `json:"payloadDigest"`
+	Signatures    []dsse.Signature  `json:"signatures"`
+}
+
+// Detach removes the payload from an envelope, recording its digest
+func Detach(env dsse.Envelope) Envelope {
+	digest := sha256.Sum256(env.Payload)
+	return Envelope{
+		PayloadType:   env.PayloadType,
+		PayloadDigest: map[string]string{digestAlgorithm: hex.EncodeToString(digest[:])},
+		Signatures:    env.Signatures,
+	}
+}
+
+// Attach returns the envelope with its payload, after checking the payload matches the recorded digest
+func (e Envelope) Attach(payload []byte) (dsse.Envelope, error) {
+	expected, ok := e.PayloadDigest[digestAlgorithm]
+	if !ok {
+		return dsse.Envelope{}, fmt.Errorf("detached envelope has no %s payload digest", digestAlgorithm)
+	}
+
+	digest := sha256.Sum256(payload)
+	if actual := hex.EncodeToString(digest[:]); !strings.EqualFold(actual, expected) {
+		return dsse.Envelope{}, fmt.Errorf("payload digest %s does not match the detached envelope's digest %s", actual, expected)
+	}
+
+	return dsse.Envelope{
+		Payload:     payload,
+		PayloadType: e.PayloadType,
+		Signatures:  e.Signatures,
+	}, nil
+}
+
+// Parse reads an envelope. The detached envelope is returned as well when the payload was left out.
+func Parse(data []byte) (dsse.Envelope, *Envelope, error) {
+	env := dsse.Envelope{}
+	if err := json.Unmarshal(data, &env); err != nil {
+		return env, nil, fmt.Errorf("failed to parse envelope: %w", err)
+	}
+
+	if len(env.Payload) > 0 {
+		return env, nil, nil
+	}
+
+	detached := Envelope{}
+	if err := json.Unmarshal(data, &detached); err != nil {
+		return env, nil, fmt.Errorf("failed to parse envelope: %w", err)
+	}
+
+	if len(detached.PayloadDigest) == 0 {
+		return env, nil, nil
+	}
+
+	return env, &detached, nil
+}
+
+// PayloadPath returns the path a sidecar envelope's payload is expected at
+func PayloadPath(envelopePath string) (string, bool) {
+	if !strings.HasSuffix(envelopePath, SignatureExtension) {
+		return "", false
+	}
+
+	return strings.TrimSuffix(envelopePath, SignatureExtension), true
+}
+
+// Load reads the envelope in data, which was read from path. The payload of a detached envelope is read from
+// payloadPath, or from the file next to the envelope if payloadPath is empty. The returned bool reports whether the
+// envelope was detached.
+func Load(data []byte, path, payloadPath string) (dsse.Envelope, bool, error) {
+	env, detached, err := Parse(data)
+	if err != nil || detached == nil {
+		return env, false, err
+	}
+
+	if payloadPath == "" {
+		var ok bool
+		if payloadPath, ok = PayloadPath(path); !ok {
+			return env, true, fmt.Errorf("envelope %s has a detached payload, provide the payload or name the envelope after it with a %s extension", path, SignatureExtension)
+		}
+	}
+
+	payload, err := stdio.ReadFile(payloadPath)
+	if err != nil {
+		return env, true, fmt.Errorf("failed to read detached payload: %w", err)
+	}
+
+	env, err = detached.Attach(payload)
+	if err != nil {
+		return env, true, fmt.Errorf("failed to attach payload %s: %w", payloadPath, err)
+	}
+
+	return env, true, nil
+}
+
+// LoadFile reads the envelope at path, reattaching a detached payload as Load does
+func LoadFile(path, payloadPath string) (dsse.Envelope, bool, error) {
+	data, err := stdio.ReadFile(path)
+	if err != nil {
+		return dsse.Envelope{}, false, fmt.Errorf("failed to read envelope: %w", err)
+	}
+
+	return Load(data, path, payloadPath)
+}
@@ -0,0 +1,70 @@
+// Copyright 2026 The Witness Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package detached
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/in-toto/go-witness/dsse"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	env := dsse.Envelope{
+		Payload:     []byte("payload"),
+		PayloadType: "text/plain",
+		Signatures:  []dsse.Signature{{KeyID: "key", Signature: []byte("sig")}},
+	}
+
+	detachedBytes, err := json.Marshal(Detach(env))
+	require.NoError(t, err)
+	assert.NotContains(t, string(detachedBytes), `"payload":`)
+
+	dir := t.TempDir()
+	payloadPath := filepath.Join(dir, "payload.txt")
+	envelopePath := payloadPath + SignatureExtension
+	require.NoError(t, os.WriteFile(payloadPath, env.Payload, 0644))
+	require.NoError(t, os.WriteFile(envelopePath, detachedBytes, 0644))
+
+	loaded, isDetached, err := LoadFile(envelopePath, "")
+	require.NoError(t, err)
+	assert.True(t, isDetached)
+	assert.Equal(t, env, loaded)
+
+	// the payload can live anywhere when its path is given
+	otherPath := filepath.Join(dir, "envelope.json")
+	require.NoError(t, os.WriteFile(otherPath, detachedBytes, 0644))
+	_, _, err = LoadFile(otherPath, "")
+	assert.ErrorContains(t, err, "has a detached payload")
+	loaded, _, err = LoadFile(otherPath, payloadPath)
+	require.NoError(t, err)
+	assert.Equal(t, env, loaded)
+
+	require.NoError(t, os.WriteFile(payloadPath, []byte("tampered"), 0644))
+	_, isDetached, err = LoadFile(envelopePath, "")
+	assert.True(t, isDetached)
+	assert.ErrorContains(t, err, "does not match")
+
+	attachedBytes, err := json.Marshal(env)
+	require.NoError(t, err)
+	loaded, isDetached, err = Load(attachedBytes, otherPath, "")
+	require.NoError(t, err)
+	assert.False(t, isDetached)
+	assert.Equal(t, env, loaded)
+}
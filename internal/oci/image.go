@@ -0,0 +1,367 @@
+// Copyright 2026 The Witness Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oci
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/in-toto/go-witness/cryptoutil"
+	"github.com/in-toto/go-witness/log"
+)
+
+const (
+	dockerManifestFile = "manifest.json"
+	ociLayoutFile      = "oci-layout"
+	ociIndexFile       = "index.json"
+
+	mediaTypeOCIIndex           = "application/vnd.oci.image.index.v1+json"
+	mediaTypeDockerManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+)
+
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// Subject is a single digest computed from an image, along with a name describing what was digested.
+// Names follow the same scheme as the subjects recorded by the oci attestor, except for ocimanifestdigest which the
+// attestor doesn't record.
+type Subject struct {
+	Name   string
+	Digest cryptoutil.DigestSet
+}
+
+// dockerManifest is an entry of the manifest.json file written by `docker save`
+type dockerManifest struct {
+	Config   string   `json:"Config"`
+	RepoTags []string `json:"RepoTags"`
+	Layers   []string `json:"Layers"`
+}
+
+type descriptor struct {
+	MediaType string `json:"mediaType"`
+	Digest    string `json:"digest"`
+}
+
+type index struct {
+	Manifests []descriptor `json:"manifests"`
+}
+
+type imageManifest struct {
+	MediaType string       `json:"mediaType"`
+	Config    descriptor   `json:"config"`
+	Layers    []descriptor `json:"layers"`
+	Manifests []descriptor `json:"manifests"`
+}
+
+// ImageSubjects calculates the subjects of an image stored on disk. The path may point to either a tarball
+// produced by `docker save` or a directory containing an OCI image layout.
+// The manifest, config (image ID) and uncompressed layer (diff ID) digests are calculated the same way the oci
+// attestor records them, so they can be used to look up attestations for the image.
+func ImageSubjects(path string, hashes []cryptoutil.DigestValue) ([]Subject, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat image: %w", err)
+	}
+
+	if fi.IsDir() {
+		return layoutSubjects(path, hashes)
+	}
+
+	return tarballSubjects(path, hashes)
+}
+
+func tarballSubjects(path string, hashes []cryptoutil.DigestValue) ([]Subject, error) {
+	tarDigest, err := cryptoutil.CalculateDigestSetFromFile(path, hashes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate tarball digest: %w", err)
+	}
+
+	subjects := []Subject{{Name: subjectName("tardigest", tarDigest), Digest: tarDigest}}
+
+	// manifest.json may appear anywhere in the tarball, so find it before digesting the blobs it references
+	manifestRaw, err := readTarEntry(path, dockerManifestFile)
+	if err != nil {
+		return nil, err
+	}
+
+	manifestDigest, err := cryptoutil.CalculateDigestSetFromBytes(manifestRaw, hashes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate manifest digest: %w", err)
+	}
+
+	subjects = append(subjects, Subject{Name: subjectName("manifestdigest", manifestDigest), Digest: manifestDigest})
+
+	manifests := []dockerManifest{}
+	if err := json.Unmarshal(manifestRaw, &manifests); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", dockerManifestFile, err)
+	}
+
+	if len(manifests) == 0 {
+		return nil, fmt.Errorf("no images found in %s", dockerManifestFile)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open image tarball: %w", err)
+	}
+
+	defer func() {
+		if err := f.Close(); err != nil {
+			log.Errorf("failed to close image tarball: %v", err)
+		}
+	}()
+
+	digests := make(map[string]cryptoutil.DigestSet)
+	wanted := make(map[string]bool)
+	for _, m := range manifests {
+		wanted[m.Config] = false
+		for _, l := range m.Layers {
+			wanted[l] = true
+		}
+	}
+
+	tr := tar.NewReader(f)
+	for {
+		h, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to read image tarball: %w", err)
+		}
+
+		isLayer, ok := wanted[h.Name]
+		if !ok || h.FileInfo().IsDir() {
+			continue
+		}
+
+		ds, err := blobDigest(tr, isLayer, hashes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to calculate digest of %s: %w", h.Name, err)
+		}
+
+		digests[h.Name] = ds
+	}
+
+	for _, m := range manifests {
+		imageID, ok := digests[m.Config]
+		if !ok {
+			return nil, fmt.Errorf("could not find config %s in image tarball", m.Config)
+		}
+
+		subjects = append(subjects, Subject{Name: subjectName("imageid", imageID), Digest: imageID})
+		for i, l := range m.Layers {
+			diffID, ok := digests[l]
+			if !ok {
+				return nil, fmt.Errorf("could not find layer %s in image tarball", l)
+			}
+
+			subjects = append(subjects, Subject{Name: subjectName(fmt.Sprintf("layerdiffid%02d", i), diffID), Digest: diffID})
+		}
+	}
+
+	return subjects, nil
+}
+
+func layoutSubjects(dir string, hashes []cryptoutil.DigestValue) ([]Subject, error) {
+	if _, err := os.Stat(filepath.Join(dir, ociLayoutFile)); err != nil {
+		return nil, fmt.Errorf("%s is not an OCI image layout: %w", dir, err)
+	}
+
+	indexRaw, err := os.ReadFile(filepath.Join(dir, ociIndexFile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", ociIndexFile, err)
+	}
+
+	idx := index{}
+	if err := json.Unmarshal(indexRaw, &idx); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", ociIndexFile, err)
+	}
+
+	if len(idx.Manifests) == 0 {
+		return nil, fmt.Errorf("no images found in %s", ociIndexFile)
+	}
+
+	subjects := []Subject{}
+
+	// the oci attestor records the digest of the manifest.json written by `docker save`, which newer versions of
+	// docker include next to the OCI layout
+	if manifestRaw, err := os.ReadFile(filepath.Join(dir, dockerManifestFile)); err == nil {
+		manifestDigest, err := cryptoutil.CalculateDigestSetFromBytes(manifestRaw, hashes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to calculate manifest digest: %w", err)
+		}
+
+		subjects = append(subjects, Subject{Name: subjectName("manifestdigest", manifestDigest), Digest: manifestDigest})
+	}
+
+	for _, desc := range idx.Manifests {
+		s, err := manifestSubjects(dir, desc, hashes)
+		if err != nil {
+			return nil, err
+		}
+
+		subjects = append(subjects, s...)
+	}
+
+	return subjects, nil
+}
+
+func manifestSubjects(dir string, desc descriptor, hashes []cryptoutil.DigestValue) ([]Subject, error) {
+	manifestRaw, err := os.ReadFile(blobPath(dir, desc.Digest))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest %s: %w", desc.Digest, err)
+	}
+
+	manifest := imageManifest{}
+	if err := json.Unmarshal(manifestRaw, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest %s: %w", desc.Digest, err)
+	}
+
+	manifestDigest, err := cryptoutil.CalculateDigestSetFromBytes(manifestRaw, hashes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate manifest digest: %w", err)
+	}
+
+	// the digest registries know the image by, which differs from the manifestdigest recorded by the oci attestor
+	subjects := []Subject{{Name: subjectName("ocimanifestdigest", manifestDigest), Digest: manifestDigest}}
+
+	// multi-platform images point at an index of manifests rather than a single image
+	if desc.MediaType == mediaTypeOCIIndex || desc.MediaType == mediaTypeDockerManifestList || len(manifest.Manifests) > 0 {
+		for _, child := range manifest.Manifests {
+			s, err := manifestSubjects(dir, child, hashes)
+			if err != nil {
+				return nil, err
+			}
+
+			subjects = append(subjects, s...)
+		}
+
+		return subjects, nil
+	}
+
+	imageID, err := blobFileDigest(blobPath(dir, manifest.Config.Digest), false, hashes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate digest of config %s: %w", manifest.Config.Digest, err)
+	}
+
+	subjects = append(subjects, Subject{Name: subjectName("imageid", imageID), Digest: imageID})
+	for i, l := range manifest.Layers {
+		diffID, err := blobFileDigest(blobPath(dir, l.Digest), true, hashes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to calculate digest of layer %s: %w", l.Digest, err)
+		}
+
+		subjects = append(subjects, Subject{Name: subjectName(fmt.Sprintf("layerdiffid%02d", i), diffID), Digest: diffID})
+	}
+
+	return subjects, nil
+}
+
+func blobPath(dir, digest string) string {
+	alg, encoded, _ := strings.Cut(digest, ":")
+	return filepath.Join(dir, "blobs", alg, encoded)
+}
+
+func blobFileDigest(path string, isLayer bool, hashes []cryptoutil.DigestValue) (cryptoutil.DigestSet, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err := f.Close(); err != nil {
+			log.Errorf("failed to close blob: %v", err)
+		}
+	}()
+
+	return blobDigest(f, isLayer, hashes)
+}
+
+// blobDigest digests the contents of r. Layers compressed with gzip are decompressed first so the resulting
+// digest is the layer's diff ID.
+func blobDigest(r io.Reader, isLayer bool, hashes []cryptoutil.DigestValue) (cryptoutil.DigestSet, error) {
+	if !isLayer {
+		return cryptoutil.CalculateDigestSet(r, hashes)
+	}
+
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(len(gzipMagic))
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	if !bytes.Equal(magic, gzipMagic) {
+		return cryptoutil.CalculateDigestSet(br, hashes)
+	}
+
+	gr, err := gzip.NewReader(br)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err := gr.Close(); err != nil {
+			log.Errorf("failed to close gzip reader: %v", err)
+		}
+	}()
+
+	return cryptoutil.CalculateDigestSet(gr, hashes)
+}
+
+func readTarEntry(path, name string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open image tarball: %w", err)
+	}
+
+	defer func() {
+		if err := f.Close(); err != nil {
+			log.Errorf("failed to close image tarball: %v", err)
+		}
+	}()
+
+	tr := tar.NewReader(f)
+	for {
+		h, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to read image tarball: %w", err)
+		}
+
+		if h.Name == name {
+			return io.ReadAll(tr)
+		}
+	}
+
+	return nil, fmt.Errorf("could not find %s in image tarball, only tarballs created by `docker save` are supported", name)
+}
+
+func subjectName(prefix string, ds cryptoutil.DigestSet) string {
+	return fmt.Sprintf("%s:%s", prefix, ds[cryptoutil.DigestValue{Hash: crypto.SHA256}])
+}
@@ -0,0 +1,181 @@
+// Copyright 2026 The Witness Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oci
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/in-toto/go-witness/cryptoutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var sha256Hashes = []cryptoutil.DigestValue{{Hash: crypto.SHA256}}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func gzipBytes(t *testing.T, b []byte) []byte {
+	buf := &bytes.Buffer{}
+	gw := gzip.NewWriter(buf)
+	_, err := gw.Write(b)
+	require.NoError(t, err)
+	require.NoError(t, gw.Close())
+	return buf.Bytes()
+}
+
+func subjectDigests(subjects []Subject) map[string]string {
+	digests := make(map[string]string)
+	for _, s := range subjects {
+		digests[s.Name] = s.Digest[cryptoutil.DigestValue{Hash: crypto.SHA256}]
+	}
+
+	return digests
+}
+
+func writeTar(t *testing.T, path string, files map[string][]byte, order []string) {
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	tw := tar.NewWriter(f)
+	for _, name := range order {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(files[name]))}))
+		_, err := tw.Write(files[name])
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, tw.Close())
+}
+
+func TestImageSubjectsDockerTarball(t *testing.T) {
+	config := []byte(`{"architecture":"amd64","os":"linux"}`)
+	layer0 := []byte("uncompressed layer")
+	layer1 := []byte("compressed layer")
+	manifest := []byte(`[{"Config":"config.json","RepoTags":["example:latest"],"Layers":["0/layer.tar","1/layer.tar"]}]`)
+
+	files := map[string][]byte{
+		"config.json":   config,
+		"0/layer.tar":   layer0,
+		"1/layer.tar":   gzipBytes(t, layer1),
+		"manifest.json": manifest,
+	}
+
+	// manifest.json is written last to make sure we don't depend on its position in the tarball
+	path := filepath.Join(t.TempDir(), "image.tar")
+	writeTar(t, path, files, []string{"config.json", "0/layer.tar", "1/layer.tar", "manifest.json"})
+
+	tarBytes, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	subjects, err := ImageSubjects(path, sha256Hashes)
+	require.NoError(t, err)
+
+	digests := subjectDigests(subjects)
+	assert.Len(t, digests, 5)
+	for name, digest := range map[string]string{
+		"tardigest":      sha256Hex(tarBytes),
+		"manifestdigest": sha256Hex(manifest),
+		"imageid":        sha256Hex(config),
+		"layerdiffid00":  sha256Hex(layer0),
+		"layerdiffid01":  sha256Hex(layer1),
+	} {
+		assert.Equal(t, digest, digests[fmt.Sprintf("%s:%s", name, digest)], "unexpected digest for %s", name)
+	}
+}
+
+func TestImageSubjectsDockerTarballMissingManifest(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "image.tar")
+	writeTar(t, path, map[string][]byte{"config.json": []byte("{}")}, []string{"config.json"})
+
+	_, err := ImageSubjects(path, sha256Hashes)
+	require.ErrorContains(t, err, "could not find manifest.json")
+}
+
+func TestImageSubjectsOCILayout(t *testing.T) {
+	dir := t.TempDir()
+	blobDir := filepath.Join(dir, "blobs", "sha256")
+	require.NoError(t, os.MkdirAll(blobDir, 0755))
+
+	writeBlob := func(b []byte) string {
+		digest := sha256Hex(b)
+		require.NoError(t, os.WriteFile(filepath.Join(blobDir, digest), b, 0644))
+		return digest
+	}
+
+	config := []byte(`{"architecture":"arm64","os":"linux"}`)
+	layer := []byte("layer contents")
+	configDigest := writeBlob(config)
+	layerDigest := writeBlob(gzipBytes(t, layer))
+
+	manifest, err := json.Marshal(map[string]any{
+		"schemaVersion": 2,
+		"mediaType":     "application/vnd.oci.image.manifest.v1+json",
+		"config":        map[string]any{"mediaType": "application/vnd.oci.image.config.v1+json", "digest": "sha256:" + configDigest},
+		"layers":        []any{map[string]any{"mediaType": "application/vnd.oci.image.layer.v1.tar+gzip", "digest": "sha256:" + layerDigest}},
+	})
+	require.NoError(t, err)
+	manifestDigest := writeBlob(manifest)
+
+	imageIndex, err := json.Marshal(map[string]any{
+		"schemaVersion": 2,
+		"mediaType":     mediaTypeOCIIndex,
+		"manifests":     []any{map[string]any{"mediaType": "application/vnd.oci.image.manifest.v1+json", "digest": "sha256:" + manifestDigest}},
+	})
+	require.NoError(t, err)
+	imageIndexDigest := writeBlob(imageIndex)
+
+	layoutIndex, err := json.Marshal(map[string]any{
+		"schemaVersion": 2,
+		"manifests":     []any{map[string]any{"mediaType": mediaTypeOCIIndex, "digest": "sha256:" + imageIndexDigest}},
+	})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "index.json"), layoutIndex, 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "oci-layout"), []byte(`{"imageLayoutVersion":"1.0.0"}`), 0644))
+
+	subjects, err := ImageSubjects(dir, sha256Hashes)
+	require.NoError(t, err)
+
+	digests := subjectDigests(subjects)
+	assert.Len(t, digests, 4)
+	assert.Contains(t, digests, "ocimanifestdigest:"+imageIndexDigest)
+	assert.Contains(t, digests, "ocimanifestdigest:"+manifestDigest)
+	assert.Contains(t, digests, "imageid:"+configDigest)
+	assert.Contains(t, digests, "layerdiffid00:"+sha256Hex(layer))
+
+	// layouts extracted from `docker save` carry the manifest.json the oci attestor digests
+	dockerManifest := []byte(`[{"Config":"blobs/sha256/` + configDigest + `","Layers":["blobs/sha256/` + layerDigest + `"]}]`)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "manifest.json"), dockerManifest, 0644))
+	subjects, err = ImageSubjects(dir, sha256Hashes)
+	require.NoError(t, err)
+	assert.Contains(t, subjectDigests(subjects), "manifestdigest:"+sha256Hex(dockerManifest))
+}
+
+func TestImageSubjectsNotALayout(t *testing.T) {
+	_, err := ImageSubjects(t.TempDir(), sha256Hashes)
+	require.ErrorContains(t, err, "is not an OCI image layout")
+}
@@ -16,14 +16,14 @@ package policy
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"os"
 	"reflect"
 
 	"github.com/in-toto/go-witness/archivista"
 	"github.com/in-toto/go-witness/dsse"
 	"github.com/in-toto/go-witness/log"
+	"github.com/in-toto/witness/internal/detached"
+	"github.com/in-toto/witness/internal/stdio"
 )
 
 // ArchivistaClienter defines what we need to retrieve policies from an Archivista instance
@@ -34,11 +34,13 @@ type ArchivistaClienter interface {
 }
 
 // LoadPolicy attempts to load a policy from either a file or Archivista.
-// It prefers to load from a file, if it fails, it tries to load from Archivista
-func LoadPolicy(ctx context.Context, policy string, ac ArchivistaClienter) (dsse.Envelope, error) {
+// It prefers to load from a file, if it fails, it tries to load from Archivista.
+// The payload of a detached policy envelope is read from payloadPath, or from the file the envelope was named after
+// if payloadPath is empty.
+func LoadPolicy(ctx context.Context, policy, payloadPath string, ac ArchivistaClienter) (dsse.Envelope, error) {
 	policyEnvelope := dsse.Envelope{}
 
-	filePolicy, err := os.Open(policy)
+	policyBytes, err := stdio.ReadFile(policy)
 	if err != nil {
 		log.Debug("failed to open policy file: ", policy)
 		if ac == nil || reflect.ValueOf(ac).IsNil() {
@@ -54,14 +56,8 @@ func LoadPolicy(ctx context.Context, policy string, ac ArchivistaClienter) (dsse
 		}
 
 	} else {
-		defer func() {
-			if err := filePolicy.Close(); err != nil {
-				log.Errorf("failed to close policy file: %v", err)
-			}
-		}()
-
-		decoder := json.NewDecoder(filePolicy)
-		if err := decoder.Decode(&policyEnvelope); err != nil {
+		policyEnvelope, _, err = detached.Load(policyBytes, policy, payloadPath)
+		if err != nil {
 			return policyEnvelope, fmt.Errorf("could not unmarshal policy envelope: %w", err)
 		}
 	}
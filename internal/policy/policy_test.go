@@ -16,10 +16,14 @@ package policy
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/in-toto/go-witness/dsse"
+	"github.com/in-toto/witness/internal/detached"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
 )
@@ -56,18 +60,43 @@ func (ut *UTPolicySuite) TestLoadPolicyFile() {
 	policy := "../../test/policy-hello-signed.json"
 
 	// Load policy from file
-	policyEnvelope, err := LoadPolicy(ctx, policy, nil)
+	policyEnvelope, err := LoadPolicy(ctx, policy, "", nil)
 	ut.NoError(err)
 	ut.NotNil(policyEnvelope)
 }
 
+// Test LoadPolicy with a detached policy envelope
+func (ut *UTPolicySuite) TestLoadPolicyDetached() {
+	ctx := context.Background()
+	signed, err := os.ReadFile("../../test/policy-hello-signed.json")
+	ut.Require().NoError(err)
+	env := dsse.Envelope{}
+	ut.Require().NoError(json.Unmarshal(signed, &env))
+
+	dir := ut.T().TempDir()
+	payloadPath := filepath.Join(dir, "policy.json")
+	envelopeBytes, err := json.Marshal(detached.Detach(env))
+	ut.Require().NoError(err)
+	ut.Require().NoError(os.WriteFile(payloadPath, env.Payload, 0644))
+	ut.Require().NoError(os.WriteFile(payloadPath+".sig", envelopeBytes, 0644))
+	ut.Require().NoError(os.WriteFile(filepath.Join(dir, "envelope.json"), envelopeBytes, 0644))
+
+	policyEnvelope, err := LoadPolicy(ctx, payloadPath+".sig", "", nil)
+	ut.NoError(err)
+	ut.Equal(env, policyEnvelope)
+
+	policyEnvelope, err = LoadPolicy(ctx, filepath.Join(dir, "envelope.json"), payloadPath, nil)
+	ut.NoError(err)
+	ut.Equal(env, policyEnvelope)
+}
+
 // Test LoadPolicy with file not found
 func (ut *UTPolicySuite) TestLoadPolicyFileNotFound() {
 	ctx := context.Background()
 	policy := "notfound"
 
 	// Load policy from file
-	_, err := LoadPolicy(ctx, policy, nil)
+	_, err := LoadPolicy(ctx, policy, "", nil)
 	ut.Error(err)
 	ut.Contains(err.Error(), "no such file or directory")
 }
@@ -81,7 +110,7 @@ func (ut *UTPolicySuite) TestLoadPolicyArchivista() {
 	ut.mockedAC.On("Download").Return(dsse.Envelope{}, nil)
 
 	// Load policy from archivista
-	policyEnvelope, err := LoadPolicy(ctx, policy, ut.mockedAC)
+	policyEnvelope, err := LoadPolicy(ctx, policy, "", ut.mockedAC)
 	ut.NoError(err)
 	ut.NotNil(policyEnvelope)
 }
@@ -95,7 +124,7 @@ func (ut *UTPolicySuite) TestLoadPolicyArchivistaNotFound() {
 	ut.mockedAC.On("Download").Return(dsse.Envelope{}, errors.New("not found"))
 
 	// Load policy from archivista
-	_, err := LoadPolicy(ctx, policy, ut.mockedAC)
+	_, err := LoadPolicy(ctx, policy, "", ut.mockedAC)
 	ut.Error(err)
 	ut.Contains(err.Error(), "failed to fetch policy from archivista")
 }
@@ -0,0 +1,313 @@
+// Copyright 2026 The Witness Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package revocation
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/in-toto/go-witness/log"
+	"golang.org/x/crypto/ocsp"
+)
+
+type Status string
+
+const (
+	StatusGood    Status = "good"
+	StatusRevoked Status = "revoked"
+	StatusUnknown Status = "unknown"
+
+	SourceCRL  = "crl"
+	SourceOCSP = "ocsp"
+	// SourceNone marks a certificate that no CRL or OCSP responder could report on
+	SourceNone = "none"
+)
+
+// Result is the revocation status of a single certificate as reported by a single source
+type Result struct {
+	Subject string
+	Serial  string
+	Status  Status
+	Source  string
+	Reason  string
+}
+
+func (r Result) String() string {
+	s := fmt.Sprintf("%s (serial %s): %s via %s", r.Subject, r.Serial, r.Status, r.Source)
+	if r.Reason != "" {
+		s += ": " + r.Reason
+	}
+
+	return s
+}
+
+type Checker struct {
+	crls          []*x509.RevocationList
+	ocspResponder string
+	client        *http.Client
+}
+
+type Option func(*Checker)
+
+// WithCRLs adds certificate revocation lists to check certificates against
+func WithCRLs(crls ...*x509.RevocationList) Option {
+	return func(c *Checker) {
+		c.crls = append(c.crls, crls...)
+	}
+}
+
+// WithOCSPResponder configures the URL of an OCSP responder to query for every certificate that has an issuer
+func WithOCSPResponder(url string) Option {
+	return func(c *Checker) {
+		c.ocspResponder = url
+	}
+}
+
+// WithHTTPClient sets the client used to make OCSP requests
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *Checker) {
+		c.client = client
+	}
+}
+
+func New(opts ...Option) *Checker {
+	c := &Checker{
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// LoadCRL reads a PEM or DER encoded certificate revocation list from disk
+func LoadCRL(path string) (*x509.RevocationList, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read crl: %w", err)
+	}
+
+	if block, _ := pem.Decode(b); block != nil {
+		b = block.Bytes
+	}
+
+	crl, err := x509.ParseRevocationList(b)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse crl %s: %w", path, err)
+	}
+
+	return crl, nil
+}
+
+// CheckChain builds every chain from cert to one of roots and checks the revocation status of every certificate in
+// them, including the roots, as of the provided time. A zero time means the current time. Certificates other than
+// the roots that neither a CRL nor the OCSP responder covers are reported with an unknown status. Roots are trust
+// anchors, so they are only reported when a CRL they issued themselves covers them.
+func (c *Checker) CheckChain(ctx context.Context, cert *x509.Certificate, intermediates, roots []*x509.Certificate, at time.Time) ([]Result, error) {
+	if at.IsZero() {
+		at = time.Now()
+	}
+
+	rootPool := x509.NewCertPool()
+	for _, root := range roots {
+		rootPool.AddCert(root)
+	}
+
+	intermediatePool := x509.NewCertPool()
+	for _, intermediate := range intermediates {
+		intermediatePool.AddCert(intermediate)
+	}
+
+	// The validity of the chain is checked during verification, possibly against a timestamp. We only need to find
+	// each certificate's issuer, so build the chain as of when the certificate was issued.
+	chains, err := cert.Verify(x509.VerifyOptions{
+		Roots:         rootPool,
+		Intermediates: intermediatePool,
+		CurrentTime:   cert.NotBefore,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to build certificate chain: %w", err)
+	}
+
+	// a revoked intermediate on any of the chains fails the certificate, and certificates shared by the chains are
+	// only checked once for each of their issuers
+	results := []Result{}
+	checked := map[string]bool{}
+	for _, chain := range chains {
+		for i, link := range chain {
+			// roots are their own issuer, which allows a CA to revoke itself through its own CRL
+			issuer := link
+			if i+1 < len(chain) {
+				issuer = chain[i+1]
+			}
+
+			pair := string(link.Raw) + string(issuer.Raw)
+			if checked[pair] {
+				continue
+			}
+
+			checked[pair] = true
+			linkResults := c.checkCRLs(link, issuer, at)
+			if c.ocspResponder != "" && issuer != link {
+				linkResults = append(linkResults, c.checkOCSP(ctx, link, issuer, at))
+			}
+
+			if len(linkResults) == 0 && issuer != link {
+				uncovered := newResult(link, SourceNone)
+				uncovered.Status = StatusUnknown
+				uncovered.Reason = fmt.Sprintf("no crl or ocsp responder covers certificates issued by %s", issuer.Subject)
+				linkResults = append(linkResults, uncovered)
+			}
+
+			results = append(results, linkResults...)
+		}
+	}
+
+	return results, nil
+}
+
+func (c *Checker) checkCRLs(cert, issuer *x509.Certificate, at time.Time) []Result {
+	results := []Result{}
+	for _, crl := range c.crls {
+		if !bytes.Equal(crl.RawIssuer, issuer.RawSubject) || crl.CheckSignatureFrom(issuer) != nil {
+			continue
+		}
+
+		result := newResult(cert, SourceCRL)
+		result.Status = StatusGood
+		for _, entry := range crl.RevokedCertificateEntries {
+			if entry.SerialNumber.Cmp(cert.SerialNumber) == 0 && !entry.RevocationTime.After(at) {
+				result.Status = StatusRevoked
+				result.Reason = fmt.Sprintf("revoked at %s", entry.RevocationTime.Format(time.RFC3339))
+				break
+			}
+		}
+
+		if result.Status == StatusGood && !crl.NextUpdate.IsZero() && at.After(crl.NextUpdate) {
+			result.Status = StatusUnknown
+			result.Reason = fmt.Sprintf("crl expired at %s", crl.NextUpdate.Format(time.RFC3339))
+		}
+
+		results = append(results, result)
+	}
+
+	return results
+}
+
+// checkOCSP asks the responder about cert. Like with CRLs, a revocation after the provided time leaves the certificate
+// good, and a responder that can't be reached or doesn't know the certificate reports it as unknown. So does a
+// response that wasn't valid at the provided time, unless it reports a revocation before that time.
+func (c *Checker) checkOCSP(ctx context.Context, cert, issuer *x509.Certificate, at time.Time) Result {
+	result := newResult(cert, SourceOCSP)
+	result.Status = StatusUnknown
+	req, err := ocsp.CreateRequest(cert, issuer, nil)
+	if err != nil {
+		result.Reason = fmt.Sprintf("failed to create ocsp request: %v", err)
+		return result
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.ocspResponder, bytes.NewReader(req))
+	if err != nil {
+		result.Reason = fmt.Sprintf("failed to create ocsp request: %v", err)
+		return result
+	}
+
+	httpReq.Header.Set("Content-Type", "application/ocsp-request")
+	httpReq.Header.Set("Accept", "application/ocsp-response")
+	resp, err := c.client.Do(httpReq)
+	if err != nil {
+		result.Reason = fmt.Sprintf("failed to query ocsp responder: %v", err)
+		return result
+	}
+
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			log.Errorf("failed to close ocsp response: %w", err)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		result.Reason = fmt.Sprintf("ocsp responder returned %s", resp.Status)
+		return result
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		result.Reason = fmt.Sprintf("failed to read ocsp response: %v", err)
+		return result
+	}
+
+	ocspResp, err := ocsp.ParseResponseForCert(body, cert, issuer)
+	if err != nil {
+		result.Reason = fmt.Sprintf("failed to parse ocsp response: %v", err)
+		return result
+	}
+
+	switch ocspResp.Status {
+	case ocsp.Good:
+		result.Status = StatusGood
+	case ocsp.Revoked:
+		result.Status = StatusRevoked
+		if ocspResp.RevokedAt.After(at) {
+			result.Status = StatusGood
+		}
+
+		result.Reason = fmt.Sprintf("revoked at %s", ocspResp.RevokedAt.Format(time.RFC3339))
+	default:
+		result.Reason = "responder does not know the certificate"
+	}
+
+	if result.Status == StatusRevoked {
+		return result
+	}
+
+	// responders only return the current status, so a good response holds for any earlier time as long as it is fresh
+	if !ocspResp.NextUpdate.IsZero() && time.Now().After(ocspResp.NextUpdate) {
+		result.Status = StatusUnknown
+		result.Reason = fmt.Sprintf("ocsp response expired at %s", ocspResp.NextUpdate.Format(time.RFC3339))
+	}
+
+	return result
+}
+
+// Revoked returns the results that report a certificate as revoked
+func Revoked(results []Result) []Result {
+	revoked := []Result{}
+	for _, r := range results {
+		if r.Status == StatusRevoked {
+			revoked = append(revoked, r)
+		}
+	}
+
+	return revoked
+}
+
+func newResult(cert *x509.Certificate, source string) Result {
+	return Result{
+		Subject: cert.Subject.String(),
+		Serial:  cert.SerialNumber.String(),
+		Source:  source,
+	}
+}
@@ -0,0 +1,250 @@
+// Copyright 2026 The Witness Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package revocation
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ocsp"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  crypto.Signer
+}
+
+func newCert(t *testing.T, serial int64, name string, isCA bool, parent *testCA) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(serial),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  isCA,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}
+
+	parentCert, parentKey := template, crypto.Signer(key)
+	if parent != nil {
+		parentCert, parentKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parentCert, key.Public(), parentKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCA{cert: cert, key: key}
+}
+
+func newCRL(t *testing.T, issuer *testCA, nextUpdate time.Time, revoked ...x509.RevocationListEntry) *x509.RevocationList {
+	der, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		Number:                    big.NewInt(1),
+		ThisUpdate:                time.Now().Add(-time.Hour),
+		NextUpdate:                nextUpdate,
+		RevokedCertificateEntries: revoked,
+	}, issuer.cert, issuer.key)
+	require.NoError(t, err)
+	crl, err := x509.ParseRevocationList(der)
+	require.NoError(t, err)
+	return crl
+}
+
+func statuses(results []Result) map[string]Status {
+	s := map[string]Status{}
+	for _, r := range results {
+		s[r.Subject+"/"+r.Source] = r.Status
+	}
+
+	return s
+}
+
+func TestCheckChainCRL(t *testing.T) {
+	ctx := context.Background()
+	root := newCert(t, 1, "root", true, nil)
+	intermediate := newCert(t, 2, "intermediate", true, root)
+	leaf := newCert(t, 3, "leaf", false, intermediate)
+	revokedAt := time.Now().Add(-30 * time.Minute)
+
+	rootCRL := newCRL(t, root, time.Now().Add(time.Hour))
+	intermediateCRL := newCRL(t, intermediate, time.Now().Add(time.Hour), x509.RevocationListEntry{SerialNumber: leaf.cert.SerialNumber, RevocationTime: revokedAt})
+	checker := New(WithCRLs(rootCRL, intermediateCRL))
+
+	results, err := checker.CheckChain(ctx, leaf.cert, []*x509.Certificate{intermediate.cert}, []*x509.Certificate{root.cert}, time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]Status{
+		"CN=leaf/crl":         StatusRevoked,
+		"CN=intermediate/crl": StatusGood,
+		"CN=root/crl":         StatusGood,
+	}, statuses(results))
+	assert.Len(t, Revoked(results), 1)
+
+	// the certificate wasn't revoked yet at the verification time
+	results, err = checker.CheckChain(ctx, leaf.cert, []*x509.Certificate{intermediate.cert}, []*x509.Certificate{root.cert}, revokedAt.Add(-time.Minute))
+	require.NoError(t, err)
+	assert.Empty(t, Revoked(results))
+
+	// a crl past its next update can't vouch for a certificate
+	staleChecker := New(WithCRLs(newCRL(t, intermediate, time.Now().Add(-time.Minute))))
+	results, err = staleChecker.CheckChain(ctx, leaf.cert, []*x509.Certificate{intermediate.cert}, []*x509.Certificate{root.cert}, time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]Status{"CN=leaf/crl": StatusUnknown, "CN=intermediate/none": StatusUnknown}, statuses(results))
+
+	// crls from other issuers are ignored, leaving the chain uncovered
+	otherRoot := newCert(t, 1, "other", true, nil)
+	results, err = New(WithCRLs(newCRL(t, otherRoot, time.Now().Add(time.Hour)))).CheckChain(ctx, leaf.cert, []*x509.Certificate{intermediate.cert}, []*x509.Certificate{root.cert}, time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]Status{"CN=leaf/none": StatusUnknown, "CN=intermediate/none": StatusUnknown}, statuses(results))
+	assert.Empty(t, Revoked(results))
+
+	_, err = checker.CheckChain(ctx, leaf.cert, nil, []*x509.Certificate{otherRoot.cert}, time.Time{})
+	require.ErrorContains(t, err, "failed to build certificate chain")
+}
+
+func TestCheckChainEveryChain(t *testing.T) {
+	ctx := context.Background()
+	root := newCert(t, 1, "root", true, nil)
+	otherRoot := newCert(t, 2, "other root", true, nil)
+	intermediate := newCert(t, 3, "intermediate", true, root)
+
+	// the same intermediate key cross-signed by a second root, which revoked it
+	template := *intermediate.cert
+	template.SerialNumber = big.NewInt(4)
+	der, err := x509.CreateCertificate(rand.Reader, &template, otherRoot.cert, intermediate.cert.PublicKey, otherRoot.key)
+	require.NoError(t, err)
+	crossSigned, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	leaf := newCert(t, 5, "leaf", false, intermediate)
+	checker := New(WithCRLs(
+		newCRL(t, root, time.Now().Add(time.Hour)),
+		newCRL(t, intermediate, time.Now().Add(time.Hour)),
+		newCRL(t, otherRoot, time.Now().Add(time.Hour), x509.RevocationListEntry{SerialNumber: crossSigned.SerialNumber, RevocationTime: time.Now().Add(-time.Minute)}),
+	))
+
+	results, err := checker.CheckChain(ctx, leaf.cert, []*x509.Certificate{intermediate.cert, crossSigned}, []*x509.Certificate{root.cert, otherRoot.cert}, time.Time{})
+	require.NoError(t, err)
+	revoked := Revoked(results)
+	require.Len(t, revoked, 1)
+	assert.Equal(t, crossSigned.SerialNumber.String(), revoked[0].Serial)
+}
+
+func TestCheckChainOCSP(t *testing.T) {
+	ctx := context.Background()
+	root := newCert(t, 1, "root", true, nil)
+	good := newCert(t, 2, "good", false, root)
+	revoked := newCert(t, 3, "revoked", false, root)
+
+	thisUpdate, nextUpdate := time.Now().Add(-2*time.Hour), time.Now().Add(time.Hour)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		req, err := ocsp.ParseRequest(body)
+		require.NoError(t, err)
+
+		template := ocsp.Response{
+			SerialNumber: req.SerialNumber,
+			Status:       ocsp.Good,
+			ThisUpdate:   thisUpdate,
+			NextUpdate:   nextUpdate,
+		}
+
+		if req.SerialNumber.Cmp(revoked.cert.SerialNumber) == 0 {
+			template.Status = ocsp.Revoked
+			template.RevokedAt = time.Now().Add(-time.Minute)
+		}
+
+		resp, err := ocsp.CreateResponse(root.cert, root.cert, template, root.key)
+		require.NoError(t, err)
+		_, _ = w.Write(resp)
+	}))
+	defer server.Close()
+
+	checker := New(WithOCSPResponder(server.URL))
+	results, err := checker.CheckChain(ctx, good.cert, nil, []*x509.Certificate{root.cert}, time.Time{})
+	require.NoError(t, err)
+	// roots have no issuer to ask about them
+	assert.Equal(t, map[string]Status{"CN=good/ocsp": StatusGood}, statuses(results))
+
+	results, err = checker.CheckChain(ctx, revoked.cert, nil, []*x509.Certificate{root.cert}, time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]Status{"CN=revoked/ocsp": StatusRevoked}, statuses(results))
+
+	// a certificate revoked after the verification time was still good at that time
+	results, err = checker.CheckChain(ctx, revoked.cert, nil, []*x509.Certificate{root.cert}, time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]Status{"CN=revoked/ocsp": StatusGood}, statuses(results))
+
+	// responders only return current responses, so a good response produced after the verification time still holds
+	results, err = checker.CheckChain(ctx, good.cert, nil, []*x509.Certificate{root.cert}, time.Now().Add(-3*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]Status{"CN=good/ocsp": StatusGood}, statuses(results))
+
+	// an expired response is stale, but a revocation it reports still stands
+	thisUpdate, nextUpdate = time.Now().Add(-2*time.Hour), time.Now().Add(-time.Hour)
+	results, err = checker.CheckChain(ctx, good.cert, nil, []*x509.Certificate{root.cert}, time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]Status{"CN=good/ocsp": StatusUnknown}, statuses(results))
+	assert.Contains(t, results[0].Reason, "ocsp response expired at")
+
+	results, err = checker.CheckChain(ctx, revoked.cert, nil, []*x509.Certificate{root.cert}, time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]Status{"CN=revoked/ocsp": StatusRevoked}, statuses(results))
+
+	// an unreachable responder is reported as unknown rather than failing the check
+	server.Close()
+	results, err = checker.CheckChain(ctx, good.cert, nil, []*x509.Certificate{root.cert}, time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]Status{"CN=good/ocsp": StatusUnknown}, statuses(results))
+}
+
+func TestLoadCRL(t *testing.T) {
+	root := newCert(t, 1, "root", true, nil)
+	crl := newCRL(t, root, time.Now().Add(time.Hour))
+	dir := t.TempDir()
+
+	derPath := filepath.Join(dir, "crl.der")
+	require.NoError(t, os.WriteFile(derPath, crl.Raw, 0644))
+	pemPath := filepath.Join(dir, "crl.pem")
+	require.NoError(t, os.WriteFile(pemPath, pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: crl.Raw}), 0644))
+
+	for _, path := range []string{derPath, pemPath} {
+		loaded, err := LoadCRL(path)
+		require.NoError(t, err)
+		assert.Equal(t, crl.Raw, loaded.Raw)
+	}
+
+	_, err := LoadCRL(filepath.Join(dir, "missing"))
+	require.Error(t, err)
+}
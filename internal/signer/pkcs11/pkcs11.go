@@ -0,0 +1,287 @@
+// Copyright 2026 The Witness Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package pkcs11 provides a signer and verifier backed by a key held in a PKCS#11 token, such as an HSM or SoftHSM.
+// Talking to the token's module requires cgo, so the provider and its flags are only registered in cgo builds.
+package pkcs11
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/asn1"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/in-toto/go-witness/cryptoutil"
+	"github.com/in-toto/go-witness/registry"
+)
+
+const name = "pkcs11"
+
+// configOptions returns the options shared by the signer and verifier registrations
+func configOptions[T any]() []registry.Configurer {
+	set := func(p T, opt Option) (T, error) {
+		sp, ok := any(p).(*SignerProvider)
+		if !ok {
+			return p, fmt.Errorf("provided provider is not a pkcs11 provider")
+		}
+
+		opt(sp)
+		return p, nil
+	}
+
+	return []registry.Configurer{
+		registry.StringConfigOption(
+			"module",
+			"Path to the PKCS#11 module of the token, e.g. /usr/lib/softhsm/libsofthsm2.so",
+			"",
+			func(p T, module string) (T, error) { return set(p, WithModulePath(module)) },
+		),
+		registry.IntConfigOption(
+			"slot",
+			"ID of the slot holding the token. Defaults to the only slot with a token present",
+			-1,
+			func(p T, slot int) (T, error) { return set(p, WithSlot(slot)) },
+		),
+		registry.StringConfigOption(
+			"key-label",
+			"Label of the key on the token",
+			"",
+			func(p T, label string) (T, error) { return set(p, WithKeyLabel(label)) },
+		),
+		registry.StringConfigOption(
+			"pin-path",
+			"Path to a file containing the user PIN of the token. Defaults to the WITNESS_PKCS11_PIN environment variable",
+			"",
+			func(p T, path string) (T, error) { return set(p, WithPinPath(path)) },
+		),
+	}
+}
+
+// SignerProvider loads a key from a PKCS#11 token
+type SignerProvider struct {
+	ModulePath string
+	Slot       int
+	KeyLabel   string
+	PinPath    string
+}
+
+type Option func(*SignerProvider)
+
+func WithModulePath(path string) Option {
+	return func(sp *SignerProvider) {
+		sp.ModulePath = path
+	}
+}
+
+func WithSlot(slot int) Option {
+	return func(sp *SignerProvider) {
+		sp.Slot = slot
+	}
+}
+
+func WithKeyLabel(label string) Option {
+	return func(sp *SignerProvider) {
+		sp.KeyLabel = label
+	}
+}
+
+func WithPinPath(path string) Option {
+	return func(sp *SignerProvider) {
+		sp.PinPath = path
+	}
+}
+
+func New(opts ...Option) *SignerProvider {
+	sp := &SignerProvider{Slot: -1}
+	for _, opt := range opts {
+		opt(sp)
+	}
+
+	return sp
+}
+
+// Signer opens a session on the token and returns a signer for the private key with the configured label. The session
+// stays open for as long as the process runs.
+func (sp *SignerProvider) Signer(ctx context.Context) (cryptoutil.Signer, error) {
+	if err := sp.validate(); err != nil {
+		return nil, err
+	}
+
+	pin, err := sp.pin()
+	if err != nil {
+		return nil, err
+	}
+
+	pub, signDigest, err := openKey(sp, pin, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Signer{pub: pub, hash: crypto.SHA256, signDigest: signDigest}, nil
+}
+
+// Verifier returns a verifier for the public key with the configured label. The token is only logged in to when a
+// PIN is available.
+func (sp *SignerProvider) Verifier(ctx context.Context) (cryptoutil.Verifier, error) {
+	if err := sp.validate(); err != nil {
+		return nil, err
+	}
+
+	pin, err := sp.pin()
+	if err != nil {
+		return nil, err
+	}
+
+	pub, _, err := openKey(sp, pin, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return cryptoutil.NewVerifier(pub, cryptoutil.VerifyWithHash(crypto.SHA256))
+}
+
+func (sp *SignerProvider) validate() error {
+	if sp.ModulePath == "" {
+		return fmt.Errorf("a pkcs11 module is required")
+	}
+
+	if sp.KeyLabel == "" {
+		return fmt.Errorf("a pkcs11 key label is required")
+	}
+
+	return nil
+}
+
+// pin reads the PIN from the PIN file, falling back to the WITNESS_PKCS11_PIN environment variable
+func (sp *SignerProvider) pin() (string, error) {
+	if sp.PinPath == "" {
+		return os.Getenv("WITNESS_PKCS11_PIN"), nil
+	}
+
+	b, err := os.ReadFile(sp.PinPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read pkcs11 pin file: %w", err)
+	}
+
+	// Accept a single trailing newline (CR/LF). Error on additional lines.
+	pin := strings.TrimRight(string(b), "\r\n")
+	if strings.ContainsAny(pin, "\r\n") {
+		return "", fmt.Errorf("pkcs11 pin file must contain a single line")
+	}
+
+	return pin, nil
+}
+
+// Signer signs with a private key that never leaves the token
+type Signer struct {
+	pub        crypto.PublicKey
+	hash       crypto.Hash
+	signDigest func(digest []byte) ([]byte, error)
+}
+
+func (s *Signer) KeyID() (string, error) {
+	return cryptoutil.GeneratePublicKeyID(s.pub, s.hash)
+}
+
+// Sign signs the digest of r on the token. RSA keys sign with PSS, ECDSA signatures are converted from the raw r||s
+// form tokens return to the ASN.1 form the ECDSA verifier expects.
+func (s *Signer) Sign(r io.Reader) ([]byte, error) {
+	digest, err := cryptoutil.Digest(r, s.hash)
+	if err != nil {
+		return nil, err
+	}
+
+	sig, err := s.signDigest(digest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign with pkcs11 key: %w", err)
+	}
+
+	if _, ok := s.pub.(*ecdsa.PublicKey); ok {
+		return ecdsaSignatureToASN1(sig)
+	}
+
+	return sig, nil
+}
+
+func (s *Signer) Verifier() (cryptoutil.Verifier, error) {
+	return cryptoutil.NewVerifier(s.pub, cryptoutil.VerifyWithHash(s.hash))
+}
+
+func ecdsaSignatureToASN1(sig []byte) ([]byte, error) {
+	if len(sig) == 0 || len(sig)%2 != 0 {
+		return nil, fmt.Errorf("invalid ecdsa signature length %d", len(sig))
+	}
+
+	half := len(sig) / 2
+	return asn1.Marshal(struct {
+		R, S *big.Int
+	}{new(big.Int).SetBytes(sig[:half]), new(big.Int).SetBytes(sig[half:])})
+}
+
+func rsaPublicKey(modulus, exponent []byte) (*rsa.PublicKey, error) {
+	e := new(big.Int).SetBytes(exponent)
+	if !e.IsInt64() || e.Int64() > int64(^uint32(0)>>1) || e.Sign() <= 0 {
+		return nil, fmt.Errorf("invalid rsa public exponent")
+	}
+
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(modulus), E: int(e.Int64())}, nil
+}
+
+var namedCurves = []struct {
+	oid   asn1.ObjectIdentifier
+	curve elliptic.Curve
+}{
+	{asn1.ObjectIdentifier{1, 2, 840, 10045, 3, 1, 7}, elliptic.P256()},
+	{asn1.ObjectIdentifier{1, 3, 132, 0, 34}, elliptic.P384()},
+	{asn1.ObjectIdentifier{1, 3, 132, 0, 35}, elliptic.P521()},
+}
+
+// ecdsaPublicKey parses the DER encoded CKA_EC_PARAMS and CKA_EC_POINT attributes of an EC public key
+func ecdsaPublicKey(params, point []byte) (*ecdsa.PublicKey, error) {
+	oid := asn1.ObjectIdentifier{}
+	if _, err := asn1.Unmarshal(params, &oid); err != nil {
+		return nil, fmt.Errorf("failed to parse ec params, only named curves are supported: %w", err)
+	}
+
+	var curve elliptic.Curve
+	for _, c := range namedCurves {
+		if c.oid.Equal(oid) {
+			curve = c.curve
+		}
+	}
+
+	if curve == nil {
+		return nil, fmt.Errorf("unsupported ec curve %s", oid)
+	}
+
+	// the point is wrapped in an octet string, though some modules return it bare
+	raw := point
+	if rest, err := asn1.Unmarshal(point, &raw); err != nil || len(rest) > 0 {
+		raw = point
+	}
+
+	pub, err := ecdsa.ParseUncompressedPublicKey(curve, raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ec point: %w", err)
+	}
+
+	return pub, nil
+}
@@ -0,0 +1,113 @@
+// Copyright 2026 The Witness Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkcs11
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/asn1"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/in-toto/go-witness/cryptoutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignerECDSA(t *testing.T) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	// tokens return r||s, padded to the size of the curve
+	s := &Signer{pub: &priv.PublicKey, hash: crypto.SHA256, signDigest: func(digest []byte) ([]byte, error) {
+		r, s, err := ecdsa.Sign(rand.Reader, priv, digest)
+		if err != nil {
+			return nil, err
+		}
+
+		sig := make([]byte, 64)
+		r.FillBytes(sig[:32])
+		s.FillBytes(sig[32:])
+		return sig, nil
+	}}
+
+	sig, err := s.Sign(bytes.NewReader([]byte("payload")))
+	require.NoError(t, err)
+	verifier, err := s.Verifier()
+	require.NoError(t, err)
+	require.NoError(t, verifier.Verify(bytes.NewReader([]byte("payload")), sig))
+
+	keyID, err := s.KeyID()
+	require.NoError(t, err)
+	verifierKeyID, err := verifier.KeyID()
+	require.NoError(t, err)
+	assert.Equal(t, verifierKeyID, keyID)
+
+	_, err = ecdsaSignatureToASN1([]byte{1, 2, 3})
+	assert.Error(t, err)
+}
+
+func TestPublicKeys(t *testing.T) {
+	priv, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+	params, err := asn1.Marshal(asn1.ObjectIdentifier{1, 3, 132, 0, 34})
+	require.NoError(t, err)
+	raw, err := priv.PublicKey.Bytes()
+	require.NoError(t, err)
+	point, err := asn1.Marshal(raw)
+	require.NoError(t, err)
+
+	for _, p := range [][]byte{point, raw} {
+		pub, err := ecdsaPublicKey(params, p)
+		require.NoError(t, err)
+		assert.True(t, pub.Equal(&priv.PublicKey))
+	}
+
+	unsupported, err := asn1.Marshal(asn1.ObjectIdentifier{1, 3, 132, 0, 10})
+	require.NoError(t, err)
+	_, err = ecdsaPublicKey(unsupported, point)
+	assert.ErrorContains(t, err, "unsupported ec curve")
+
+	rsaPriv, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	rsaPub, err := rsaPublicKey(rsaPriv.N.Bytes(), []byte{1, 0, 1})
+	require.NoError(t, err)
+	assert.True(t, rsaPub.Equal(&rsaPriv.PublicKey))
+
+	_, err = cryptoutil.NewVerifier(rsaPub)
+	assert.NoError(t, err)
+}
+
+func TestPin(t *testing.T) {
+	t.Setenv("WITNESS_PKCS11_PIN", "from-env")
+	pin, err := New().pin()
+	require.NoError(t, err)
+	assert.Equal(t, "from-env", pin)
+
+	path := filepath.Join(t.TempDir(), "pin")
+	require.NoError(t, os.WriteFile(path, []byte("1234\n"), 0600))
+	pin, err = New(WithPinPath(path)).pin()
+	require.NoError(t, err)
+	assert.Equal(t, "1234", pin)
+
+	require.NoError(t, os.WriteFile(path, []byte("1234\n5678\n"), 0600))
+	_, err = New(WithPinPath(path)).pin()
+	assert.ErrorContains(t, err, "single line")
+}
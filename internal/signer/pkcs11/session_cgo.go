@@ -0,0 +1,279 @@
+// Copyright 2026 The Witness Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build cgo
+
+package pkcs11
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/in-toto/go-witness/cryptoutil"
+	"github.com/in-toto/go-witness/signer"
+	"github.com/miekg/pkcs11"
+)
+
+func init() {
+	signer.Register(name, func() signer.SignerProvider { return New() }, configOptions[signer.SignerProvider]()...)
+	signer.RegisterVerifier(name, func() signer.VerifierProvider { return New() }, configOptions[signer.VerifierProvider]()...)
+}
+
+// openKey loads the module, opens a session on the slot and finds the key with the configured label. The private key
+// is only looked up when withPrivate is set, in which case the session stays open for signing. Otherwise, and when
+// anything fails, the session is closed and the module finalized again.
+func openKey(sp *SignerProvider, pin string, withPrivate bool) (_ crypto.PublicKey, _ func([]byte) ([]byte, error), err error) {
+	if withPrivate && pin == "" {
+		return nil, nil, fmt.Errorf("a pkcs11 pin is required to sign, provide a pin file or set WITNESS_PKCS11_PIN")
+	}
+
+	ctx := pkcs11.New(sp.ModulePath)
+	if ctx == nil {
+		return nil, nil, fmt.Errorf("failed to load pkcs11 module %s", sp.ModulePath)
+	}
+
+	initErr := ctx.Initialize()
+	if initErr != nil && !isError(initErr, pkcs11.CKR_CRYPTOKI_ALREADY_INITIALIZED) {
+		ctx.Destroy()
+		return nil, nil, fmt.Errorf("failed to initialize pkcs11 module: %w", initErr)
+	}
+
+	keepOpen := false
+	var session *pkcs11.SessionHandle
+	defer func() {
+		if keepOpen && err == nil {
+			return
+		}
+
+		if session != nil {
+			_ = ctx.CloseSession(*session)
+		}
+
+		// a module initialized by another provider in this process is left to it
+		if initErr == nil {
+			_ = ctx.Finalize()
+		}
+
+		ctx.Destroy()
+	}()
+
+	slot, err := selectSlot(ctx, sp.Slot)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	handle, err := ctx.OpenSession(slot, pkcs11.CKF_SERIAL_SESSION)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open pkcs11 session on slot %d: %w", slot, err)
+	}
+
+	session = &handle
+	if pin != "" {
+		if err := ctx.Login(handle, pkcs11.CKU_USER, pin); err != nil && !isError(err, pkcs11.CKR_USER_ALREADY_LOGGED_IN) {
+			return nil, nil, fmt.Errorf("failed to log in to pkcs11 token: %w", err)
+		}
+	}
+
+	pub, err := findPublicKey(ctx, handle, sp.KeyLabel)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !withPrivate {
+		return pub, nil, nil
+	}
+
+	priv, err := findObject(ctx, handle, pkcs11.CKO_PRIVATE_KEY, sp.KeyLabel)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var mechanism *pkcs11.Mechanism
+	switch pub.(type) {
+	case *rsa.PublicKey:
+		params := pkcs11.NewPSSParams(pkcs11.CKM_SHA256, pkcs11.CKG_MGF1_SHA256, uint(crypto.SHA256.Size()))
+		mechanism = pkcs11.NewMechanism(pkcs11.CKM_RSA_PKCS_PSS, params)
+	case *ecdsa.PublicKey:
+		mechanism = pkcs11.NewMechanism(pkcs11.CKM_ECDSA, nil)
+	default:
+		return nil, nil, fmt.Errorf("unsupported pkcs11 public key type %T", pub)
+	}
+
+	signDigest := func(digest []byte) ([]byte, error) {
+		if err := ctx.SignInit(handle, []*pkcs11.Mechanism{mechanism}, priv); err != nil {
+			return nil, err
+		}
+
+		return ctx.Sign(handle, digest)
+	}
+
+	keepOpen = true
+	return pub, signDigest, nil
+}
+
+func isError(err error, code uint) bool {
+	var p11Err pkcs11.Error
+	return errors.As(err, &p11Err) && uint(p11Err) == code
+}
+
+// selectSlot returns the configured slot, or the only slot with a token present if none was configured
+func selectSlot(ctx *pkcs11.Ctx, configured int) (uint, error) {
+	slots, err := ctx.GetSlotList(true)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list pkcs11 slots: %w", err)
+	}
+
+	if configured >= 0 {
+		for _, slot := range slots {
+			if slot == uint(configured) {
+				return slot, nil
+			}
+		}
+
+		return 0, fmt.Errorf("pkcs11 slot %d has no token present, slots with a token: %v", configured, slots)
+	}
+
+	switch len(slots) {
+	case 0:
+		return 0, fmt.Errorf("no pkcs11 slot has a token present")
+	case 1:
+		return slots[0], nil
+	default:
+		return 0, fmt.Errorf("more than one pkcs11 slot has a token present, choose one of %v with the slot option", slots)
+	}
+}
+
+func findObject(ctx *pkcs11.Ctx, session pkcs11.SessionHandle, class uint, label string) (pkcs11.ObjectHandle, error) {
+	template := []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, class),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, label),
+	}
+
+	if err := ctx.FindObjectsInit(session, template); err != nil {
+		return 0, fmt.Errorf("failed to search pkcs11 token: %w", err)
+	}
+
+	objects, _, err := ctx.FindObjects(session, 2)
+	if finalErr := ctx.FindObjectsFinal(session); err == nil {
+		err = finalErr
+	}
+
+	if err != nil {
+		return 0, fmt.Errorf("failed to search pkcs11 token: %w", err)
+	}
+
+	switch len(objects) {
+	case 0:
+		return 0, errNotFound{class: class, label: label}
+	case 1:
+		return objects[0], nil
+	default:
+		return 0, fmt.Errorf("more than one pkcs11 %s has the label %q", className(class), label)
+	}
+}
+
+type errNotFound struct {
+	class uint
+	label string
+}
+
+func (e errNotFound) Error() string {
+	return fmt.Sprintf("no pkcs11 %s has the label %q", className(e.class), e.label)
+}
+
+func className(class uint) string {
+	switch class {
+	case pkcs11.CKO_PRIVATE_KEY:
+		return "private key"
+	case pkcs11.CKO_PUBLIC_KEY:
+		return "public key"
+	case pkcs11.CKO_CERTIFICATE:
+		return "certificate"
+	default:
+		return "object"
+	}
+}
+
+// findPublicKey reads the public key object with the label, or the public key of the certificate with the label for
+// tokens that don't keep public key objects
+func findPublicKey(ctx *pkcs11.Ctx, session pkcs11.SessionHandle, label string) (crypto.PublicKey, error) {
+	obj, err := findObject(ctx, session, pkcs11.CKO_PUBLIC_KEY, label)
+	if errors.As(err, &errNotFound{}) {
+		certObj, certErr := findObject(ctx, session, pkcs11.CKO_CERTIFICATE, label)
+		if certErr != nil {
+			return nil, err
+		}
+
+		attrs, err := ctx.GetAttributeValue(session, certObj, []*pkcs11.Attribute{pkcs11.NewAttribute(pkcs11.CKA_VALUE, nil)})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read pkcs11 certificate: %w", err)
+		}
+
+		cert, err := cryptoutil.TryParseCertificate(attrs[0].Value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse pkcs11 certificate: %w", err)
+		}
+
+		return cert.PublicKey, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	attrs, err := ctx.GetAttributeValue(session, obj, []*pkcs11.Attribute{pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, nil)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pkcs11 public key type: %w", err)
+	}
+
+	keyType := bytesToUint(attrs[0].Value)
+	switch keyType {
+	case pkcs11.CKK_RSA:
+		attrs, err := ctx.GetAttributeValue(session, obj, []*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_MODULUS, nil),
+			pkcs11.NewAttribute(pkcs11.CKA_PUBLIC_EXPONENT, nil),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read pkcs11 rsa public key: %w", err)
+		}
+
+		return rsaPublicKey(attrs[0].Value, attrs[1].Value)
+	case pkcs11.CKK_EC:
+		attrs, err := ctx.GetAttributeValue(session, obj, []*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_EC_PARAMS, nil),
+			pkcs11.NewAttribute(pkcs11.CKA_EC_POINT, nil),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read pkcs11 ec public key: %w", err)
+		}
+
+		return ecdsaPublicKey(attrs[0].Value, attrs[1].Value)
+	default:
+		return nil, fmt.Errorf("unsupported pkcs11 key type %d", keyType)
+	}
+}
+
+// bytesToUint decodes a CK_ULONG attribute, which tokens return in native byte order
+func bytesToUint(b []byte) uint {
+	switch len(b) {
+	case 8:
+		return uint(binary.NativeEndian.Uint64(b))
+	case 4:
+		return uint(binary.NativeEndian.Uint32(b))
+	default:
+		return ^uint(0)
+	}
+}
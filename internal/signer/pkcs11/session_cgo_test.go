@@ -0,0 +1,176 @@
+// Copyright 2026 The Witness Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build cgo
+
+package pkcs11
+
+import (
+	"bytes"
+	"context"
+	"encoding/asn1"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/in-toto/go-witness/signer"
+	"github.com/miekg/pkcs11"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testTokenLabel = "witness"
+	testPin        = "1234"
+)
+
+func TestRegistered(t *testing.T) {
+	sp, err := signer.NewSignerProvider("pkcs11")
+	require.NoError(t, err)
+	assert.Equal(t, -1, sp.(*SignerProvider).Slot)
+
+	_, err = sp.Signer(context.Background())
+	assert.ErrorContains(t, err, "module is required")
+
+	vp, err := signer.NewVerifierProvider("pkcs11")
+	require.NoError(t, err)
+	_, err = vp.Verifier(context.Background())
+	assert.ErrorContains(t, err, "module is required")
+}
+
+// softHSM initializes a SoftHSM token holding an RSA and an ECDSA key pair and returns the module path and the slot of
+// the token. The module is taken from WITNESS_TEST_PKCS11_MODULE or the usual SoftHSM install locations.
+func softHSM(t *testing.T) (string, int) {
+	module := os.Getenv("WITNESS_TEST_PKCS11_MODULE")
+	if module == "" {
+		for _, path := range []string{"/usr/lib/softhsm/libsofthsm2.so", "/usr/local/lib/softhsm/libsofthsm2.so", "/usr/lib/x86_64-linux-gnu/softhsm/libsofthsm2.so", "/opt/homebrew/lib/softhsm/libsofthsm2.so"} {
+			if _, err := os.Stat(path); err == nil {
+				module = path
+				break
+			}
+		}
+	}
+
+	if module == "" {
+		t.Skip("SoftHSM is not installed, set WITNESS_TEST_PKCS11_MODULE to run the PKCS#11 tests")
+	}
+
+	dir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "tokens"), 0700))
+	conf := filepath.Join(dir, "softhsm2.conf")
+	require.NoError(t, os.WriteFile(conf, []byte(fmt.Sprintf("directories.tokendir = %s\nobjectstore.backend = file\n", filepath.Join(dir, "tokens"))), 0600))
+	t.Setenv("SOFTHSM2_CONF", conf)
+
+	ctx := pkcs11.New(module)
+	require.NotNil(t, ctx)
+	require.NoError(t, ctx.Initialize())
+	t.Cleanup(func() {
+		_ = ctx.Finalize()
+	})
+
+	slots, err := ctx.GetSlotList(true)
+	require.NoError(t, err)
+	require.NotEmpty(t, slots)
+	require.NoError(t, ctx.InitToken(slots[0], "so-pin", testTokenLabel))
+
+	// SoftHSM moves an initialized token to a new slot
+	slots, err = ctx.GetSlotList(true)
+	require.NoError(t, err)
+	slot := -1
+	for _, s := range slots {
+		info, err := ctx.GetTokenInfo(s)
+		require.NoError(t, err)
+		if info.Label == testTokenLabel {
+			slot = int(s)
+		}
+	}
+
+	require.NotEqual(t, -1, slot)
+	session, err := ctx.OpenSession(uint(slot), pkcs11.CKF_SERIAL_SESSION|pkcs11.CKF_RW_SESSION)
+	require.NoError(t, err)
+	require.NoError(t, ctx.Login(session, pkcs11.CKU_SO, "so-pin"))
+	require.NoError(t, ctx.InitPIN(session, testPin))
+	require.NoError(t, ctx.Logout(session))
+	require.NoError(t, ctx.Login(session, pkcs11.CKU_USER, testPin))
+
+	p256, err := asn1.Marshal(asn1.ObjectIdentifier{1, 2, 840, 10045, 3, 1, 7})
+	require.NoError(t, err)
+	for _, key := range []struct {
+		label     string
+		mechanism uint
+		public    []*pkcs11.Attribute
+	}{
+		{"rsa", pkcs11.CKM_RSA_PKCS_KEY_PAIR_GEN, []*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_MODULUS_BITS, 2048),
+			pkcs11.NewAttribute(pkcs11.CKA_PUBLIC_EXPONENT, []byte{1, 0, 1}),
+		}},
+		{"ecdsa", pkcs11.CKM_EC_KEY_PAIR_GEN, []*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_EC_PARAMS, p256),
+		}},
+	} {
+		public := append([]*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+			pkcs11.NewAttribute(pkcs11.CKA_VERIFY, true),
+			pkcs11.NewAttribute(pkcs11.CKA_LABEL, key.label),
+		}, key.public...)
+		private := []*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+			pkcs11.NewAttribute(pkcs11.CKA_SIGN, true),
+			pkcs11.NewAttribute(pkcs11.CKA_PRIVATE, true),
+			pkcs11.NewAttribute(pkcs11.CKA_SENSITIVE, true),
+			pkcs11.NewAttribute(pkcs11.CKA_LABEL, key.label),
+		}
+
+		_, _, err := ctx.GenerateKeyPair(session, []*pkcs11.Mechanism{pkcs11.NewMechanism(key.mechanism, nil)}, public, private)
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, ctx.CloseSession(session))
+	return module, slot
+}
+
+func TestSoftHSM(t *testing.T) {
+	module, slot := softHSM(t)
+	pinPath := filepath.Join(t.TempDir(), "pin")
+	require.NoError(t, os.WriteFile(pinPath, []byte(testPin+"\n"), 0600))
+
+	for _, label := range []string{"rsa", "ecdsa"} {
+		t.Run(label, func(t *testing.T) {
+			sp := New(WithModulePath(module), WithSlot(slot), WithKeyLabel(label), WithPinPath(pinPath))
+			s, err := sp.Signer(context.Background())
+			require.NoError(t, err)
+			sig, err := s.Sign(bytes.NewReader([]byte("payload")))
+			require.NoError(t, err)
+
+			// the verifier reads the public key without logging in
+			verifier, err := New(WithModulePath(module), WithSlot(slot), WithKeyLabel(label)).Verifier(context.Background())
+			require.NoError(t, err)
+			require.NoError(t, verifier.Verify(bytes.NewReader([]byte("payload")), sig))
+
+			keyID, err := s.KeyID()
+			require.NoError(t, err)
+			verifierKeyID, err := verifier.KeyID()
+			require.NoError(t, err)
+			assert.Equal(t, keyID, verifierKeyID)
+		})
+	}
+
+	_, err := New(WithModulePath(module), WithSlot(slot), WithKeyLabel("missing"), WithPinPath(pinPath)).Signer(context.Background())
+	assert.ErrorContains(t, err, `no pkcs11 public key has the label "missing"`)
+
+	t.Setenv("WITNESS_PKCS11_PIN", "")
+	_, err = New(WithModulePath(module), WithSlot(slot), WithKeyLabel("rsa")).Signer(context.Background())
+	assert.ErrorContains(t, err, "pin is required")
+}
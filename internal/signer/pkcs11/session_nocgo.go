@@ -0,0 +1,27 @@
+// Copyright 2026 The Witness Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !cgo
+
+package pkcs11
+
+import (
+	"crypto"
+	"fmt"
+)
+
+// openKey reports the missing cgo support, the provider isn't registered in these builds but can still be constructed
+func openKey(sp *SignerProvider, pin string, withPrivate bool) (crypto.PublicKey, func([]byte) ([]byte, error), error) {
+	return nil, nil, fmt.Errorf("pkcs11 keys are not supported by this build of witness, it must be built with CGO_ENABLED=1")
+}
@@ -0,0 +1,32 @@
+// Copyright 2026 The Witness Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !cgo
+
+package pkcs11
+
+import (
+	"testing"
+
+	"github.com/in-toto/go-witness/signer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotRegistered(t *testing.T) {
+	_, err := signer.NewSignerProvider("pkcs11")
+	assert.Error(t, err)
+
+	_, err = signer.NewVerifierProvider("pkcs11")
+	assert.Error(t, err)
+}
@@ -0,0 +1,85 @@
+// Copyright 2026 The Witness Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package stdio lets file flags accept - for stdin or stdout so witness can be used in a pipeline.
+package stdio
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// Path is the path that stands for stdin when reading and stdout when writing
+const Path = "-"
+
+var (
+	stdinMu   sync.Mutex
+	stdinFile *os.File
+	stdinData []byte
+)
+
+// IsStdio reports whether path stands for stdin or stdout
+func IsStdio(path string) bool {
+	return path == Path
+}
+
+// ReadFile reads the file at path, or stdin if path is -. Stdin is only read once, later reads return the same
+// contents so an input can be read more than once during a command.
+func ReadFile(path string) ([]byte, error) {
+	if !IsStdio(path) {
+		return os.ReadFile(path)
+	}
+
+	stdinMu.Lock()
+	defer stdinMu.Unlock()
+	if stdinFile == os.Stdin {
+		return stdinData, nil
+	}
+
+	data, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stdin: %w", err)
+	}
+
+	stdinFile, stdinData = os.Stdin, data
+	return data, nil
+}
+
+// Open opens the file at path, or stdin if path is -. Stdin is streamed rather than buffered, so it can't be read
+// again afterwards.
+func Open(path string) (io.ReadCloser, error) {
+	if !IsStdio(path) {
+		return os.Open(path)
+	}
+
+	return io.NopCloser(os.Stdin), nil
+}
+
+// CheckSingleStdin returns an error if more than one of the paths is -, since stdin can only carry one input
+func CheckSingleStdin(paths ...string) error {
+	count := 0
+	for _, path := range paths {
+		if IsStdio(path) {
+			count++
+		}
+	}
+
+	if count > 1 {
+		return fmt.Errorf("only one input can be read from stdin, %d were given as %s", count, Path)
+	}
+
+	return nil
+}
@@ -0,0 +1,52 @@
+// Copyright 2026 The Witness Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stdio
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stdin")
+	require.NoError(t, os.WriteFile(path, []byte("input"), 0644))
+	stdin, err := os.Open(path)
+	require.NoError(t, err)
+	defer stdin.Close()
+
+	original := os.Stdin
+	os.Stdin = stdin
+	defer func() { os.Stdin = original }()
+
+	// stdin is consumed by the first read, the second gets the same contents
+	for i := 0; i < 2; i++ {
+		data, err := ReadFile(Path)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("input"), data)
+	}
+
+	data, err := ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("input"), data)
+}
+
+func TestCheckSingleStdin(t *testing.T) {
+	assert.NoError(t, CheckSingleStdin("policy.json", "-", ""))
+	assert.ErrorContains(t, CheckSingleStdin("-", "attestation.json", "-"), "only one input")
+}
@@ -0,0 +1,141 @@
+// Copyright 2026 The Witness Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package trust
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+
+	"github.com/sigstore/fulcio/pkg/certificate"
+)
+
+// IdentityRegexp constrains the subject and Fulcio extensions of a certificate with regular expressions. Empty
+// expressions allow any value. Every value of a multi-valued attribute, such as the DNS names, must match and at least
+// one value must be present.
+type IdentityRegexp struct {
+	CommonName    string                 `yaml:"common-name"`
+	DNSNames      string                 `yaml:"dns-names"`
+	Emails        string                 `yaml:"emails"`
+	Organizations string                 `yaml:"organizations"`
+	URIs          string                 `yaml:"uris"`
+	Fulcio        certificate.Extensions `yaml:"fulcio"`
+}
+
+// IsEmpty returns true if no expressions are set
+func (r IdentityRegexp) IsEmpty() bool {
+	return r == IdentityRegexp{}
+}
+
+// Validate checks that every expression compiles
+func (r IdentityRegexp) Validate() error {
+	constraints, err := r.constraints(&x509.Certificate{})
+	if err != nil {
+		return err
+	}
+
+	errs := []error{}
+	for _, c := range constraints {
+		if _, err := regexp.Compile(c.expr); err != nil {
+			errs = append(errs, fmt.Errorf("invalid %s regexp: %w", c.attribute, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Match checks the certificate against every expression
+func (r IdentityRegexp) Match(cert *x509.Certificate) error {
+	constraints, err := r.constraints(cert)
+	if err != nil {
+		return err
+	}
+
+	errs := []error{}
+	for _, c := range constraints {
+		re, err := regexp.Compile(c.expr)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid %s regexp: %w", c.attribute, err))
+			continue
+		}
+
+		if len(c.values) == 0 {
+			errs = append(errs, fmt.Errorf("cert has no %s to match %q", c.attribute, c.expr))
+			continue
+		}
+
+		for _, value := range c.values {
+			if !re.MatchString(value) {
+				errs = append(errs, fmt.Errorf("cert %s %s doesn't match %q", c.attribute, value, c.expr))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+type regexpConstraint struct {
+	attribute string
+	expr      string
+	values    []string
+}
+
+// constraints pairs every set expression with the certificate values it applies to
+func (r IdentityRegexp) constraints(cert *x509.Certificate) ([]regexpConstraint, error) {
+	uris := []string{}
+	for _, uri := range cert.URIs {
+		uris = append(uris, uri.String())
+	}
+
+	commonNames := []string{}
+	if cert.Subject.CommonName != "" {
+		commonNames = append(commonNames, cert.Subject.CommonName)
+	}
+
+	all := []regexpConstraint{
+		{"common name", r.CommonName, commonNames},
+		{"dns name", r.DNSNames, cert.DNSNames},
+		{"email", r.Emails, cert.EmailAddresses},
+		{"organization", r.Organizations, cert.Subject.Organization},
+		{"uri", r.URIs, uris},
+	}
+
+	extensions, err := certificate.ParseExtensions(cert.Extensions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse fulcio cert extensions: %w", err)
+	}
+
+	exprs := reflect.ValueOf(r.Fulcio)
+	values := reflect.ValueOf(extensions)
+	for i := 0; i < exprs.NumField(); i++ {
+		c := regexpConstraint{attribute: "fulcio extension " + exprs.Type().Field(i).Name, expr: exprs.Field(i).String()}
+		if value := values.Field(i).String(); value != "" {
+			c.values = []string{value}
+		}
+
+		all = append(all, c)
+	}
+
+	set := []regexpConstraint{}
+	for _, c := range all {
+		if c.expr != "" {
+			set = append(set, c)
+		}
+	}
+
+	return set, nil
+}
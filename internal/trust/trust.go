@@ -0,0 +1,213 @@
+// Copyright 2026 The Witness Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package trust
+
+import (
+	"bytes"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/in-toto/go-witness/cryptoutil"
+	"github.com/in-toto/go-witness/log"
+	"github.com/sigstore/fulcio/pkg/certificate"
+	"go.yaml.in/yaml/v3"
+)
+
+// Config is a set of named trust profiles used to verify policy signatures. It is read from YAML or JSON.
+type Config struct {
+	Profiles map[string]Profile `yaml:"profiles"`
+
+	// dir is the directory of the config file, which relative paths are resolved against
+	dir string
+}
+
+// Profile describes the keys, certificate authorities and identities trusted to sign a policy.
+// Keys and certificates are either paths, relative to the config file, or inline PEM.
+type Profile struct {
+	PublicKeys           []string               `yaml:"public-keys"`
+	Roots                []string               `yaml:"roots"`
+	Intermediates        []string               `yaml:"intermediates"`
+	TimestampAuthorities []string               `yaml:"timestamp-authorities"`
+	Identity             Identity               `yaml:"identity"`
+	Fulcio               certificate.Extensions `yaml:"fulcio"`
+
+	dir string
+}
+
+// Identity constrains the subject of the certificate that signed a policy
+type Identity struct {
+	CommonName    string         `yaml:"common-name"`
+	DNSNames      []string       `yaml:"dns-names"`
+	Emails        []string       `yaml:"emails"`
+	Organizations []string       `yaml:"organizations"`
+	URIs          []string       `yaml:"uris"`
+	Regexp        IdentityRegexp `yaml:"regexp"`
+}
+
+// Material is the trust material a profile resolves to
+type Material struct {
+	Verifiers      []cryptoutil.Verifier
+	Roots          []*x509.Certificate
+	Intermediates  []*x509.Certificate
+	TimestampCerts []*x509.Certificate
+}
+
+// Load reads a trust config from disk. Unknown fields are rejected so typos don't silently loosen trust.
+func Load(path string) (Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to open trust config: %w", err)
+	}
+
+	defer func() {
+		if err := f.Close(); err != nil {
+			log.Errorf("failed to close trust config: %w", err)
+		}
+	}()
+
+	return Parse(f, filepath.Dir(path))
+}
+
+// Parse reads a trust config, resolving relative paths against dir
+func Parse(r io.Reader, dir string) (Config, error) {
+	c := Config{}
+	decoder := yaml.NewDecoder(r)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&c); err != nil && !errors.Is(err, io.EOF) {
+		return c, fmt.Errorf("failed to parse trust config: %w", err)
+	}
+
+	if len(c.Profiles) == 0 {
+		return c, fmt.Errorf("trust config doesn't define any profiles")
+	}
+
+	for name, profile := range c.Profiles {
+		if err := profile.Identity.Regexp.Validate(); err != nil {
+			return c, fmt.Errorf("invalid identity in trust profile %s: %w", name, err)
+		}
+	}
+
+	c.dir = dir
+	return c, nil
+}
+
+// Profile returns the named profile. An empty name selects the only profile in the config.
+func (c Config) Profile(name string) (Profile, error) {
+	if name == "" {
+		if len(c.Profiles) != 1 {
+			return Profile{}, fmt.Errorf("trust config has multiple profiles, select one of %s", strings.Join(c.profileNames(), ", "))
+		}
+
+		for _, profile := range c.Profiles {
+			profile.dir = c.dir
+			return profile, nil
+		}
+	}
+
+	profile, ok := c.Profiles[name]
+	if !ok {
+		return Profile{}, fmt.Errorf("trust profile %s not found, available profiles are %s", name, strings.Join(c.profileNames(), ", "))
+	}
+
+	profile.dir = c.dir
+	return profile, nil
+}
+
+func (c Config) profileNames() []string {
+	names := []string{}
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// Material reads the keys and certificates referenced by the profile
+func (p Profile) Material() (Material, error) {
+	m := Material{}
+	for _, key := range p.PublicKeys {
+		b, err := p.read(key)
+		if err != nil {
+			return m, err
+		}
+
+		verifier, err := cryptoutil.NewVerifierFromReader(bytes.NewReader(b))
+		if err != nil {
+			return m, fmt.Errorf("failed to create verifier from public key: %w", err)
+		}
+
+		m.Verifiers = append(m.Verifiers, verifier)
+	}
+
+	var err error
+	if m.Roots, err = p.certificates(p.Roots); err != nil {
+		return m, fmt.Errorf("failed to load root certificate: %w", err)
+	}
+
+	if m.Intermediates, err = p.certificates(p.Intermediates); err != nil {
+		return m, fmt.Errorf("failed to load intermediate certificate: %w", err)
+	}
+
+	if m.TimestampCerts, err = p.certificates(p.TimestampAuthorities); err != nil {
+		return m, fmt.Errorf("failed to load timestamp authority certificate: %w", err)
+	}
+
+	return m, nil
+}
+
+func (p Profile) certificates(refs []string) ([]*x509.Certificate, error) {
+	certs := []*x509.Certificate{}
+	for _, ref := range refs {
+		b, err := p.read(ref)
+		if err != nil {
+			return nil, err
+		}
+
+		cert, err := cryptoutil.TryParseCertificate(b)
+		if err != nil {
+			return nil, err
+		}
+
+		certs = append(certs, cert)
+	}
+
+	return certs, nil
+}
+
+// read returns inline PEM as is, and otherwise reads the referenced file
+func (p Profile) read(ref string) ([]byte, error) {
+	if strings.HasPrefix(strings.TrimSpace(ref), "-----BEGIN") {
+		return []byte(ref), nil
+	}
+
+	path := ref
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(p.dir, path)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", ref, err)
+	}
+
+	return b, nil
+}
@@ -0,0 +1,159 @@
+// Copyright 2026 The Witness Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package trust
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sigstore/fulcio/pkg/certificate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func selfSigned(t *testing.T, template *x509.Certificate) (*x509.Certificate, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template.SerialNumber = big.NewInt(1)
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func publicKeyPEM(t *testing.T) []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(key.Public())
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+}
+
+func TestLoadProfileMaterial(t *testing.T) {
+	dir := t.TempDir()
+	_, rootPEM := selfSigned(t, &x509.Certificate{Subject: pkix.Name{CommonName: "root"}, IsCA: true, BasicConstraintsValid: true})
+	_, tsaPEM := selfSigned(t, &x509.Certificate{Subject: pkix.Name{CommonName: "tsa"}})
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "root.pem"), rootPEM, 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "key.pem"), publicKeyPEM(t), 0644))
+
+	// json is valid yaml, and certificates may be inlined
+	config := `{"profiles": {"release": {
+		"public-keys": ["key.pem"],
+		"roots": ["root.pem"],
+		"timestamp-authorities": [` + jsonString(string(tsaPEM)) + `]
+	}}}`
+	configPath := filepath.Join(dir, "trust.json")
+	require.NoError(t, os.WriteFile(configPath, []byte(config), 0644))
+
+	c, err := Load(configPath)
+	require.NoError(t, err)
+
+	// the only profile is selected by default
+	profile, err := c.Profile("")
+	require.NoError(t, err)
+	m, err := profile.Material()
+	require.NoError(t, err)
+	assert.Len(t, m.Verifiers, 1)
+	require.Len(t, m.Roots, 1)
+	assert.Equal(t, "root", m.Roots[0].Subject.CommonName)
+	assert.Empty(t, m.Intermediates)
+	require.Len(t, m.TimestampCerts, 1)
+	assert.Equal(t, "tsa", m.TimestampCerts[0].Subject.CommonName)
+
+	_, err = c.Profile("staging")
+	require.ErrorContains(t, err, "available profiles are release")
+}
+
+func TestParseRejectsInvalidConfigs(t *testing.T) {
+	for name, config := range map[string]string{
+		"empty":          "",
+		"unknown field":  "profiles:\n  release:\n    root: [ca.pem]\n",
+		"invalid regexp": "profiles:\n  release:\n    identity:\n      regexp:\n        emails: '['\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(config), t.TempDir())
+			require.Error(t, err)
+		})
+	}
+
+	c, err := Parse(strings.NewReader("profiles:\n  a: {}\n  b: {}\n"), t.TempDir())
+	require.NoError(t, err)
+	_, err = c.Profile("")
+	require.ErrorContains(t, err, "select one of a, b")
+}
+
+func TestIdentityRegexpMatch(t *testing.T) {
+	uri, err := url.Parse("https://github.com/in-toto/witness/.github/workflows/release.yml@refs/heads/main")
+	require.NoError(t, err)
+	cert, _ := selfSigned(t, &x509.Certificate{
+		Subject:        pkix.Name{CommonName: "builder", Organization: []string{"in-toto"}},
+		EmailAddresses: []string{"release@example.com", "ci@example.com"},
+		URIs:           []*url.URL{uri},
+	})
+
+	assert.True(t, IdentityRegexp{}.IsEmpty())
+	require.NoError(t, IdentityRegexp{}.Match(cert))
+	require.NoError(t, IdentityRegexp{
+		CommonName:    "^builder$",
+		Emails:        `@example\.com$`,
+		Organizations: "^in-toto$",
+		URIs:          `^https://github\.com/in-toto/[^/]+/\.github/workflows/`,
+	}.Match(cert))
+
+	// every value has to match
+	require.ErrorContains(t, IdentityRegexp{Emails: "^release@"}.Match(cert), "ci@example.com")
+	// and there has to be at least one value
+	require.ErrorContains(t, IdentityRegexp{DNSNames: ".*"}.Match(cert), "no dns name")
+	require.ErrorContains(t, IdentityRegexp{Fulcio: certificate.Extensions{Issuer: ".*"}}.Match(cert), "no fulcio extension Issuer")
+}
+
+func TestIdentityRegexpMatchFulcioExtensions(t *testing.T) {
+	extensions, err := certificate.Extensions{
+		Issuer:                     "https://token.actions.githubusercontent.com",
+		SourceRepositoryURI:        "https://github.com/in-toto/witness",
+		SourceRepositoryRef:        "refs/tags/v1.0.0",
+		SourceRepositoryIdentifier: "1234",
+	}.Render()
+	require.NoError(t, err)
+	cert, _ := selfSigned(t, &x509.Certificate{Subject: pkix.Name{CommonName: "fulcio"}, ExtraExtensions: extensions})
+
+	require.NoError(t, IdentityRegexp{Fulcio: certificate.Extensions{
+		Issuer:              `^https://token\.actions\.githubusercontent\.com$`,
+		SourceRepositoryURI: `^https://github\.com/in-toto/`,
+		SourceRepositoryRef: `^refs/tags/v\d+`,
+	}}.Match(cert))
+
+	require.ErrorContains(t, IdentityRegexp{Fulcio: certificate.Extensions{SourceRepositoryRef: "^refs/heads/"}}.Match(cert), "refs/tags/v1.0.0")
+	require.Error(t, IdentityRegexp{Fulcio: certificate.Extensions{BuildTrigger: "["}}.Validate())
+}
+
+func jsonString(s string) string {
+	return `"` + strings.ReplaceAll(s, "\n", `\n`) + `"`
+}
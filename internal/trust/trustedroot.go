@@ -0,0 +1,248 @@
+// Copyright 2026 The Witness Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package trust
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/in-toto/go-witness/log"
+	"github.com/in-toto/go-witness/timestamp"
+)
+
+const trustedRootMediaTypePrefix = "application/vnd.dev.sigstore.trustedroot"
+
+// ErrUnknownAuthority is returned when a certificate doesn't chain to any certificate authority of a trusted root
+var ErrUnknownAuthority = errors.New("certificate was not issued by any authority in the trusted root")
+
+// TrustedRoot is the certificate and timestamp authorities of a Sigstore trusted_root.json. Transparency and
+// certificate transparency logs are not used by witness and are ignored.
+type TrustedRoot struct {
+	CertificateAuthorities []Authority
+	TimestampAuthorities   []Authority
+}
+
+// Authority is a certificate chain that is trusted during a window of time
+type Authority struct {
+	Root          *x509.Certificate
+	Intermediates []*x509.Certificate
+	// Start and End bound the window the authority is trusted in. A zero End means the window is open ended.
+	Start time.Time
+	End   time.Time
+}
+
+// ValidAt returns true if the authority was trusted at the provided time
+func (a Authority) ValidAt(t time.Time) bool {
+	if t.Before(a.Start) {
+		return false
+	}
+
+	return a.End.IsZero() || !t.After(a.End)
+}
+
+// trustedRootJSON mirrors the parts of the dev.sigstore.trustroot.v1.TrustedRoot protobuf message we use
+type trustedRootJSON struct {
+	MediaType              string          `json:"mediaType"`
+	CertificateAuthorities []authorityJSON `json:"certificateAuthorities"`
+	TimestampAuthorities   []authorityJSON `json:"timestampAuthorities"`
+}
+
+type authorityJSON struct {
+	URI       string `json:"uri"`
+	CertChain struct {
+		Certificates []struct {
+			RawBytes []byte `json:"rawBytes"`
+		} `json:"certificates"`
+	} `json:"certChain"`
+	ValidFor struct {
+		Start time.Time  `json:"start"`
+		End   *time.Time `json:"end"`
+	} `json:"validFor"`
+}
+
+// LoadTrustedRoot reads a Sigstore trusted_root.json from disk
+func LoadTrustedRoot(path string) (TrustedRoot, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return TrustedRoot{}, fmt.Errorf("failed to open trusted root: %w", err)
+	}
+
+	defer func() {
+		if err := f.Close(); err != nil {
+			log.Errorf("failed to close trusted root: %w", err)
+		}
+	}()
+
+	return ParseTrustedRoot(f)
+}
+
+// ParseTrustedRoot reads a Sigstore trusted_root.json
+func ParseTrustedRoot(r io.Reader) (TrustedRoot, error) {
+	raw := trustedRootJSON{}
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return TrustedRoot{}, fmt.Errorf("failed to parse trusted root: %w", err)
+	}
+
+	if !strings.HasPrefix(raw.MediaType, trustedRootMediaTypePrefix) {
+		return TrustedRoot{}, fmt.Errorf("unexpected trusted root media type %q", raw.MediaType)
+	}
+
+	tr := TrustedRoot{}
+	var err error
+	if tr.CertificateAuthorities, err = parseAuthorities(raw.CertificateAuthorities); err != nil {
+		return tr, fmt.Errorf("failed to parse certificate authorities: %w", err)
+	}
+
+	if tr.TimestampAuthorities, err = parseAuthorities(raw.TimestampAuthorities); err != nil {
+		return tr, fmt.Errorf("failed to parse timestamp authorities: %w", err)
+	}
+
+	if len(tr.CertificateAuthorities) == 0 && len(tr.TimestampAuthorities) == 0 {
+		return tr, fmt.Errorf("trusted root has no certificate or timestamp authorities")
+	}
+
+	return tr, nil
+}
+
+func parseAuthorities(raw []authorityJSON) ([]Authority, error) {
+	authorities := []Authority{}
+	for _, a := range raw {
+		// chains are ordered from the issuing certificate to the root
+		chain := []*x509.Certificate{}
+		for _, c := range a.CertChain.Certificates {
+			cert, err := x509.ParseCertificate(c.RawBytes)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse certificate of %s: %w", a.URI, err)
+			}
+
+			chain = append(chain, cert)
+		}
+
+		if len(chain) == 0 {
+			return nil, fmt.Errorf("authority %s has no certificates", a.URI)
+		}
+
+		authority := Authority{
+			Root:          chain[len(chain)-1],
+			Intermediates: chain[:len(chain)-1],
+			Start:         a.ValidFor.Start,
+		}
+
+		if a.ValidFor.End != nil {
+			authority.End = *a.ValidFor.End
+		}
+
+		authorities = append(authorities, authority)
+	}
+
+	return authorities, nil
+}
+
+// Roots returns the root certificates of every certificate authority
+func (tr TrustedRoot) Roots() []*x509.Certificate {
+	roots := []*x509.Certificate{}
+	for _, a := range tr.CertificateAuthorities {
+		roots = append(roots, a.Root)
+	}
+
+	return roots
+}
+
+// Intermediates returns the intermediate certificates of every certificate authority
+func (tr TrustedRoot) Intermediates() []*x509.Certificate {
+	intermediates := []*x509.Certificate{}
+	for _, a := range tr.CertificateAuthorities {
+		intermediates = append(intermediates, a.Intermediates...)
+	}
+
+	return intermediates
+}
+
+// CheckCertificate checks that a certificate authority the certificate chains to was trusted when the certificate was
+// issued. ErrUnknownAuthority is returned if the certificate doesn't chain to any of the certificate authorities.
+func (tr TrustedRoot) CheckCertificate(cert *x509.Certificate, intermediates []*x509.Certificate) error {
+	chained := false
+	for _, a := range tr.CertificateAuthorities {
+		roots := x509.NewCertPool()
+		roots.AddCert(a.Root)
+		intermediatePool := x509.NewCertPool()
+		for _, intermediate := range intermediates {
+			intermediatePool.AddCert(intermediate)
+		}
+
+		for _, intermediate := range a.Intermediates {
+			intermediatePool.AddCert(intermediate)
+		}
+
+		if _, err := cert.Verify(x509.VerifyOptions{
+			Roots:         roots,
+			Intermediates: intermediatePool,
+			CurrentTime:   cert.NotBefore,
+			KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+		}); err != nil {
+			continue
+		}
+
+		chained = true
+		if a.ValidAt(cert.NotBefore) {
+			return nil
+		}
+	}
+
+	if !chained {
+		return ErrUnknownAuthority
+	}
+
+	return fmt.Errorf("certificate %s was issued at %s, outside the validity window of its certificate authority", cert.Subject, cert.NotBefore.Format(time.RFC3339))
+}
+
+// TimestampVerifiers returns a verifier for every timestamp authority that only accepts timestamps within the
+// authority's validity window
+func (tr TrustedRoot) TimestampVerifiers() []timestamp.TimestampVerifier {
+	verifiers := []timestamp.TimestampVerifier{}
+	for _, a := range tr.TimestampAuthorities {
+		verifiers = append(verifiers, windowedTimestampVerifier{
+			authority: a,
+			verifier:  timestamp.NewVerifier(timestamp.VerifyWithCerts(append([]*x509.Certificate{a.Root}, a.Intermediates...))),
+		})
+	}
+
+	return verifiers
+}
+
+type windowedTimestampVerifier struct {
+	authority Authority
+	verifier  timestamp.TimestampVerifier
+}
+
+func (v windowedTimestampVerifier) Verify(ctx context.Context, tsrData, signedData io.Reader) (time.Time, error) {
+	ts, err := v.verifier.Verify(ctx, tsrData, signedData)
+	if err != nil {
+		return ts, err
+	}
+
+	if !v.authority.ValidAt(ts) {
+		return time.Time{}, fmt.Errorf("timestamp %s is outside the validity window of timestamp authority %s", ts.Format(time.RFC3339), v.authority.Root.Subject)
+	}
+
+	return ts, nil
+}
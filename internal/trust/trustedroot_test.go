@@ -0,0 +1,155 @@
+// Copyright 2026 The Witness Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package trust
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/in-toto/go-witness/timestamp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type issuedCert struct {
+	cert *x509.Certificate
+	key  crypto.Signer
+}
+
+func issue(t *testing.T, name string, notBefore time.Time, isCA bool, parent *issuedCert) *issuedCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             notBefore,
+		NotAfter:              notBefore.Add(72 * time.Hour),
+		IsCA:                  isCA,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+	}
+
+	parentCert, parentKey := template, crypto.Signer(key)
+	if parent != nil {
+		parentCert, parentKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parentCert, key.Public(), parentKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &issuedCert{cert: cert, key: key}
+}
+
+func trustedRootJSONFor(t *testing.T, cas, tsas []authorityJSON) []byte {
+	b, err := json.Marshal(map[string]any{
+		"mediaType":              "application/vnd.dev.sigstore.trustedroot+json;version=0.1",
+		"certificateAuthorities": cas,
+		"timestampAuthorities":   tsas,
+		"tlogs":                  []any{},
+	})
+	require.NoError(t, err)
+	return b
+}
+
+func authorityFor(start time.Time, end *time.Time, chain ...*x509.Certificate) authorityJSON {
+	a := authorityJSON{URI: "https://example.com"}
+	for _, cert := range chain {
+		a.CertChain.Certificates = append(a.CertChain.Certificates, struct {
+			RawBytes []byte `json:"rawBytes"`
+		}{cert.Raw})
+	}
+
+	a.ValidFor.Start = start
+	a.ValidFor.End = end
+	return a
+}
+
+func TestParseTrustedRoot(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	root := issue(t, "root", now.Add(-48*time.Hour), true, nil)
+	intermediate := issue(t, "intermediate", now.Add(-48*time.Hour), true, root)
+	tsa := issue(t, "tsa", now.Add(-48*time.Hour), true, nil)
+	end := now.Add(-time.Hour)
+
+	tr, err := ParseTrustedRoot(bytes.NewReader(trustedRootJSONFor(t,
+		[]authorityJSON{authorityFor(now.Add(-48*time.Hour), &end, intermediate.cert, root.cert)},
+		[]authorityJSON{authorityFor(now.Add(-48*time.Hour), nil, tsa.cert)},
+	)))
+	require.NoError(t, err)
+
+	require.Len(t, tr.CertificateAuthorities, 1)
+	assert.Equal(t, root.cert.Raw, tr.Roots()[0].Raw)
+	require.Len(t, tr.Intermediates(), 1)
+	assert.Equal(t, intermediate.cert.Raw, tr.Intermediates()[0].Raw)
+	assert.True(t, tr.CertificateAuthorities[0].End.Equal(end))
+	require.Len(t, tr.TimestampAuthorities, 1)
+	assert.True(t, tr.TimestampAuthorities[0].End.IsZero())
+	assert.Len(t, tr.TimestampVerifiers(), 1)
+
+	_, err = ParseTrustedRoot(strings.NewReader(`{"mediaType": "application/json"}`))
+	require.ErrorContains(t, err, "unexpected trusted root media type")
+}
+
+func TestTrustedRootCheckCertificate(t *testing.T) {
+	now := time.Now()
+	root := issue(t, "root", now.Add(-48*time.Hour), true, nil)
+	intermediate := issue(t, "intermediate", now.Add(-48*time.Hour), true, root)
+	end := now.Add(-time.Hour)
+	tr := TrustedRoot{CertificateAuthorities: []Authority{{
+		Root:          root.cert,
+		Intermediates: []*x509.Certificate{intermediate.cert},
+		Start:         now.Add(-48 * time.Hour),
+		End:           end,
+	}}}
+
+	issuedInWindow := issue(t, "leaf", now.Add(-2*time.Hour), false, intermediate)
+	require.NoError(t, tr.CheckCertificate(issuedInWindow.cert, nil))
+
+	issuedAfterWindow := issue(t, "leaf", now.Add(-30*time.Minute), false, intermediate)
+	require.ErrorContains(t, tr.CheckCertificate(issuedAfterWindow.cert, nil), "outside the validity window")
+
+	other := issue(t, "other", now.Add(-48*time.Hour), true, nil)
+	require.ErrorIs(t, tr.CheckCertificate(issue(t, "leaf", now.Add(-2*time.Hour), false, other).cert, nil), ErrUnknownAuthority)
+}
+
+func TestWindowedTimestampVerifier(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+	authority := Authority{Root: issue(t, "tsa", now.Add(-time.Hour), true, nil).cert, Start: now.Add(-time.Hour), End: now}
+
+	inWindow := timestamp.FakeTimestamper{T: now.Add(-time.Minute)}
+	verifier := windowedTimestampVerifier{authority: authority, verifier: inWindow}
+	ts, err := verifier.Verify(ctx, strings.NewReader(inWindow.T.Format(time.RFC3339)), strings.NewReader("sig"))
+	require.NoError(t, err)
+	assert.Equal(t, inWindow.T, ts)
+
+	afterWindow := timestamp.FakeTimestamper{T: now.Add(time.Minute)}
+	verifier = windowedTimestampVerifier{authority: authority, verifier: afterWindow}
+	_, err = verifier.Verify(ctx, strings.NewReader(afterWindow.T.Format(time.RFC3339)), strings.NewReader("sig"))
+	require.ErrorContains(t, err, "outside the validity window")
+}
@@ -0,0 +1,32 @@
+// Copyright 2026 The Witness Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package options
+
+import "github.com/spf13/cobra"
+
+// ConfigInitOptions holds the flags of witness config init
+type ConfigInitOptions struct {
+	OutFilePath string
+	Attestors   []string
+	Signers     []string
+	Force       bool
+}
+
+func (cio *ConfigInitOptions) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&cio.OutFilePath, "outfile", "o", "", "File to write the config template to. Defaults to stdout, which - also selects")
+	cmd.Flags().StringSliceVarP(&cio.Attestors, "attestors", "a", DefaultAttestors, "Attestors to run and include the options of in the template")
+	cmd.Flags().StringSliceVar(&cio.Signers, "signers", []string{"file"}, "Signer and verifier providers to include the options of in the template")
+	cmd.Flags().BoolVar(&cio.Force, "force", false, "Overwrite the outfile if it already exists")
+}
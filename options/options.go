@@ -44,7 +44,7 @@ func addFlags[T any](prefix string, regName string, options []registry.Configure
 			{
 				// this is kind of a hacky solution to maintain backward compatibility with the old "-k" flag
 				var val *string
-				if name == "signer-file-key-path" {
+				if name == "signer-file-key-path" && cmd.Flags().ShorthandLookup("k") == nil {
 					val = cmd.Flags().StringP(name, "k", optT.DefaultVal(), optT.Description())
 				} else {
 					val = cmd.Flags().String(name, optT.DefaultVal(), opt.Description())
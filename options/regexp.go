@@ -0,0 +1,51 @@
+// Copyright 2026 The Witness Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package options
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// regexpValue is a string flag that only accepts valid regular expressions, so mistakes are reported when the flags
+// are parsed rather than when a certificate is matched against them
+type regexpValue struct {
+	value *string
+}
+
+func newRegexpValue(p *string) *regexpValue {
+	return &regexpValue{value: p}
+}
+
+func (r *regexpValue) Set(s string) error {
+	if _, err := regexp.Compile(s); err != nil {
+		return fmt.Errorf("invalid regular expression: %w", err)
+	}
+
+	*r.value = s
+	return nil
+}
+
+func (r *regexpValue) String() string {
+	if r.value == nil {
+		return ""
+	}
+
+	return *r.value
+}
+
+func (r *regexpValue) Type() string {
+	return "regexp"
+}
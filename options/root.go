@@ -20,6 +20,7 @@ import (
 
 type RootOptions struct {
 	Config         string
+	Profile        string
 	LogLevel       string
 	CpuProfileFile string
 	MemProfileFile string
@@ -27,6 +28,7 @@ type RootOptions struct {
 
 func (ro *RootOptions) AddFlags(cmd *cobra.Command) {
 	cmd.PersistentFlags().StringVarP(&ro.Config, "config", "c", "", "Path to the witness config file")
+	cmd.PersistentFlags().StringVar(&ro.Profile, "profile", "", "Name of the config file profile to apply over the base configuration")
 	cmd.PersistentFlags().StringVarP(&ro.LogLevel, "log-level", "l", "info", "Level of logging to output (debug, info, warn, error)")
 	cmd.PersistentFlags().StringVar(&ro.CpuProfileFile, "debug-cpu-profile-file", "", "Path to store the CPU profile. Profiling will be enabled if this is non-empty")
 	cmd.PersistentFlags().StringVar(&ro.MemProfileFile, "debug-mem-profile-file", "", "Path to store the Memory profile. Profiling will be enabled if this is non-empty")
@@ -30,9 +30,11 @@ var DefaultAttestors = []string{"environment", "git"}
 type RunOptions struct {
 	SignerOptions            SignerOptions
 	KMSSignerProviderOptions KMSSignerProviderOptions
+	SignerStrict             bool
 	ArchivistaOptions        ArchivistaOptions
 	WorkingDir               string
 	Attestations             []string
+	ManifestPath             string
 	DirHashGlobs             []string
 	Hashes                   []string
 	OutFilePath              string
@@ -58,12 +60,14 @@ var OneRequiredPKSignFlags = []string{
 
 func (ro *RunOptions) AddFlags(cmd *cobra.Command) {
 	ro.SignerOptions.AddFlags(cmd)
+	addStrictFlag("signer", &ro.SignerStrict, cmd)
 	ro.ArchivistaOptions.AddFlags(cmd)
 	cmd.Flags().StringVarP(&ro.WorkingDir, "workingdir", "d", "", "Directory from which commands will run")
 	cmd.Flags().StringSliceVarP(&ro.Attestations, "attestations", "a", DefaultAttestors, "Attestations to record ('product' and 'material' are always recorded)")
+	cmd.Flags().StringVar(&ro.ManifestPath, "manifest", "", "Path to a run manifest declaring the attestors to record and their options")
 	cmd.Flags().StringSliceVar(&ro.DirHashGlobs, "dirhash-glob", []string{}, "Dirhash glob can be used to collapse material and product hashes on matching directory matches.")
 	cmd.Flags().StringSliceVar(&ro.Hashes, "hashes", []string{"sha256"}, "Hashes selected for digest calculation. Defaults to SHA256")
-	cmd.Flags().StringVarP(&ro.OutFilePath, "outfile", "o", "", "File to write signed data to")
+	cmd.Flags().StringVarP(&ro.OutFilePath, "outfile", "o", "", "File to write signed data to. Defaults to stdout, which - also selects")
 	cmd.Flags().StringVarP(&ro.StepName, "step", "s", "", "Name of the step being run")
 	cmd.Flags().BoolVarP(&ro.Tracing, "trace", "r", false, "Enable tracing for the command")
 	cmd.Flags().StringSliceVarP(&ro.TimestampServers, "timestamp-servers", "t", []string{}, "Timestamp Authority Servers to use when signing envelope")
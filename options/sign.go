@@ -17,26 +17,70 @@ package options
 import "github.com/spf13/cobra"
 
 type SignOptions struct {
-	SignerOptions            SignerOptions
-	KMSSignerProviderOptions KMSSignerProviderOptions
-	DataType                 string
-	OutFilePath              string
-	InFilePath               string
-	TimestampServers         []string
+	SignerOptions              SignerOptions
+	KMSSignerProviderOptions   KMSSignerProviderOptions
+	SignerStrict               bool
+	DataType                   string
+	OutFilePath                string
+	InFilePath                 string
+	TimestampServers           []string
+	VerifyAfter                bool
+	PredicatePath              string
+	PredicateType              string
+	Subjects                   []string
+	SubjectFiles               []string
+	AppendPath                 string
+	ExistingKeyPaths           []string
+	ExistingCARootPaths        []string
+	ExistingTimestampCertPaths []string
+	Detached                   bool
 }
 
+var OneRequiredSignInputFlags = []string{
+	"infile",
+	"predicate",
+	"append",
+}
+
+// RequiredSignFlags were the flags witness sign required together before it could sign predicates and append
+// signatures.
+//
+// Deprecated: witness sign no longer requires these flags together, it takes one of OneRequiredSignInputFlags.
 var RequiredSignFlags = []string{
 	"infile",
 	"outfile",
 }
 
+var RequiredPredicateFlags = []string{
+	"predicate",
+	"predicate-type",
+}
+
 func (so *SignOptions) AddFlags(cmd *cobra.Command) {
 	so.SignerOptions.AddFlags(cmd)
 	so.KMSSignerProviderOptions.AddFlags(cmd)
+	addStrictFlag("signer", &so.SignerStrict, cmd)
 	cmd.Flags().StringVarP(&so.DataType, "datatype", "t", "https://witness.testifysec.com/policy/v0.1", "The URI reference to the type of data being signed. Defaults to the Witness policy type")
-	cmd.Flags().StringVarP(&so.OutFilePath, "outfile", "o", "", "File to write signed data. Defaults to stdout")
-	cmd.Flags().StringVarP(&so.InFilePath, "infile", "f", "", "Witness policy file to sign")
+	cmd.Flags().StringVarP(&so.OutFilePath, "outfile", "o", "", "File to write signed data. Defaults to stdout, which - also selects")
+	cmd.Flags().StringVarP(&so.InFilePath, "infile", "f", "", "Witness policy file to sign, or - to read it from stdin")
 	cmd.Flags().StringSliceVar(&so.TimestampServers, "timestamp-servers", []string{}, "Timestamp Authority Servers to use when signing envelope")
+	cmd.Flags().BoolVar(&so.VerifyAfter, "verify-after", false, "Verify the signed envelope with the signer after signing and print the signer's identity along with the witness verify flags that trust it")
+	cmd.Flags().StringVar(&so.PredicatePath, "predicate", "", "Path to a JSON predicate to wrap in an in-toto v1 statement and sign instead of --infile")
+	cmd.Flags().StringVar(&so.PredicateType, "predicate-type", "", "The URI reference to the type of the predicate. Predicates of a type registered by an attestor are validated against its schema")
+	cmd.Flags().StringArrayVar(&so.Subjects, "subject", []string{}, "Subject of the statement as name=algorithm:digest, e.g. app.tar.gz=sha256:abc123. Repeat for each subject or digest")
+	cmd.Flags().StringSliceVar(&so.SubjectFiles, "subject-file", []string{}, "Files to add as subjects of the statement, named by their path and identified by their sha256 digest")
+
+	cmd.Flags().StringVar(&so.AppendPath, "append", "", "Path to a signed envelope to countersign. Its payload is signed as is and the new signatures are added to the existing ones")
+	cmd.Flags().StringSliceVar(&so.ExistingKeyPaths, "verify-existing-publickeys", []string{}, "Paths to public keys to verify the existing signatures of the --append envelope with before countersigning")
+	cmd.Flags().StringSliceVar(&so.ExistingCARootPaths, "verify-existing-ca-roots", []string{}, "Paths to CA root certificates to verify the existing signatures of the --append envelope with before countersigning")
+	cmd.Flags().StringSliceVar(&so.ExistingTimestampCertPaths, "verify-existing-timestamp-servers", []string{}, "Paths to the CA certificates of Timestamp Authority Servers to verify the timestamps of the existing signatures with")
+
+	cmd.Flags().BoolVar(&so.Detached, "detached", false, "Leave the payload out of the signed envelope and record its digest instead. Unless --outfile is set the envelope is written next to the input file with a .sig extension, where verification expects it")
 
-	cmd.MarkFlagsRequiredTogether(RequiredSignFlags...)
+	cmd.MarkFlagsOneRequired(OneRequiredSignInputFlags...)
+	cmd.MarkFlagsMutuallyExclusive(OneRequiredSignInputFlags...)
+	cmd.MarkFlagsMutuallyExclusive("datatype", "predicate", "append")
+	// a statement built from a predicate isn't on disk to be reattached
+	cmd.MarkFlagsMutuallyExclusive("detached", "predicate")
+	cmd.MarkFlagsRequiredTogether(RequiredPredicateFlags...)
 }
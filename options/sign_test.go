@@ -41,6 +41,17 @@ func TestSignOptions_AddFlags(t *testing.T) {
 		"outfile",
 		"infile",
 		"timestamp-servers",
+		"verify-after",
+		"predicate",
+		"predicate-type",
+		"subject",
+		"subject-file",
+		"append",
+		"verify-existing-publickeys",
+		"verify-existing-ca-roots",
+		"verify-existing-timestamp-servers",
+		"detached",
+		"signer-strict",
 	}
 
 	for _, name := range flags {
@@ -54,4 +65,6 @@ func TestSignOptions_AddFlags(t *testing.T) {
 	// Test required flags
 	requiredFlags := RequiredSignFlags
 	assert.Equal(t, []string{"infile", "outfile"}, requiredFlags, "Required flags should be set correctly")
+	assert.Equal(t, []string{"infile", "predicate", "append"}, OneRequiredSignInputFlags, "One of the input flags should be required")
+	assert.Equal(t, []string{"predicate", "predicate-type"}, RequiredPredicateFlags, "Predicate flags should be required together")
 }
@@ -15,6 +15,8 @@
 package options
 
 import (
+	"fmt"
+
 	"github.com/in-toto/go-witness/signer"
 	"github.com/in-toto/go-witness/signer/kms"
 	"github.com/spf13/cobra"
@@ -27,6 +29,12 @@ func (so *SignerOptions) AddFlags(cmd *cobra.Command) {
 	*so = addFlagsFromRegistry("signer", signerRegistrations, cmd)
 }
 
+// addStrictFlag adds the --<prefix>-strict flag, which makes a command fail when any requested provider fails to load
+// rather than carrying on with the ones that loaded
+func addStrictFlag(prefix string, strict *bool, cmd *cobra.Command) {
+	cmd.Flags().BoolVar(strict, prefix+"-strict", false, fmt.Sprintf("Fail if any requested %s provider fails to load instead of continuing with the ones that loaded", prefix))
+}
+
 type KMSSignerProviderOptions map[string][]func(signer.SignerProvider) (signer.SignerProvider, error)
 
 func (ko *KMSSignerProviderOptions) AddFlags(cmd *cobra.Command) {
@@ -37,3 +45,24 @@ func (ko *KMSSignerProviderOptions) AddFlags(cmd *cobra.Command) {
 		}
 	}
 }
+
+// SignersTestOptions holds the flags of witness signers test
+type SignersTestOptions struct {
+	SignerOptions              SignerOptions
+	KMSSignerProviderOptions   KMSSignerProviderOptions
+	SignerStrict               bool
+	KeyPath                    string
+	VerifierOptions            VerifierOptions
+	KMSVerifierProviderOptions KMSVerifierProviderOptions
+	VerifierStrict             bool
+}
+
+func (sto *SignersTestOptions) AddFlags(cmd *cobra.Command) {
+	sto.SignerOptions.AddFlags(cmd)
+	sto.KMSSignerProviderOptions.AddFlags(cmd)
+	addStrictFlag("signer", &sto.SignerStrict, cmd)
+	cmd.Flags().StringVar(&sto.KeyPath, "publickey", "", "Path to the signer's public key, as passed to witness verify")
+	sto.VerifierOptions.AddFlags(cmd)
+	sto.KMSVerifierProviderOptions.AddFlags(cmd)
+	addStrictFlag("verifier", &sto.VerifierStrict, cmd)
+}
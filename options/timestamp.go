@@ -0,0 +1,36 @@
+// Copyright 2026 The Witness Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package options
+
+import "github.com/spf13/cobra"
+
+type TimestampOptions struct {
+	OutFilePath        string
+	TimestampServers   []string
+	TimestampCertPaths []string
+}
+
+var RequiredTimestampFlags = []string{
+	"timestamp-servers",
+	"timestamp-certs",
+}
+
+func (to *TimestampOptions) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&to.OutFilePath, "outfile", "o", "", "File to write the timestamped envelope to, or - for stdout. Defaults to updating the envelope in place, or stdout when it was read from stdin")
+	cmd.Flags().StringSliceVar(&to.TimestampServers, "timestamp-servers", []string{}, "Timestamp Authority Servers to request timestamps from, e.g. https://freetsa.org/tsr or a local stand-in such as http://localhost:3000/api/v1/timestamp")
+	cmd.Flags().StringSliceVar(&to.TimestampCertPaths, "timestamp-certs", []string{}, "Paths to the CA certificates of the Timestamp Authority Servers. Timestamps that don't verify against them are not written")
+
+	cmd.MarkFlagsRequiredTogether(RequiredTimestampFlags...)
+}
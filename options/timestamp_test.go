@@ -0,0 +1,47 @@
+// Copyright 2026 The Witness Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package options
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimestampOptions_AddFlags(t *testing.T) {
+	newCmd := func() (*cobra.Command, *TimestampOptions) {
+		cmd := &cobra.Command{Use: "test", RunE: func(cmd *cobra.Command, args []string) error { return nil }}
+		to := &TimestampOptions{}
+		to.AddFlags(cmd)
+		return cmd, to
+	}
+
+	cmd, _ := newCmd()
+	for _, name := range []string{"outfile", "timestamp-servers", "timestamp-certs"} {
+		assert.NotNil(t, cmd.Flags().Lookup(name), "Flag '%s' should be added", name)
+	}
+
+	// tokens can't be written without the certificates to verify them with
+	cmd.SetArgs([]string{"--timestamp-servers", "https://freetsa.org/tsr"})
+	require.Error(t, cmd.Execute())
+
+	cmd, to := newCmd()
+	cmd.SetArgs([]string{"--timestamp-servers", "https://freetsa.org/tsr", "--timestamp-certs", "tsa.pem"})
+	require.NoError(t, cmd.Execute())
+	assert.Equal(t, []string{"https://freetsa.org/tsr"}, to.TimestampServers)
+	assert.Equal(t, []string{"tsa.pem"}, to.TimestampCertPaths)
+}
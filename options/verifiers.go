@@ -15,6 +15,8 @@
 package options
 
 import (
+	"fmt"
+
 	"github.com/in-toto/go-witness/signer"
 	"github.com/in-toto/go-witness/signer/kms"
 	"github.com/spf13/cobra"
@@ -27,13 +29,44 @@ func (vo *VerifierOptions) AddFlags(cmd *cobra.Command) {
 	*vo = addFlagsFromRegistry("verifier", verifierRegistrations, cmd)
 }
 
-type KMSVerifierProviderOptions map[string][]func(signer.SignerProvider) (signer.SignerProvider, error)
+// KMSVerifierProviderOptions holds the setters of each KMS backend's options, keyed by the backend's provider name
+type KMSVerifierProviderOptions map[string][]func(signer.VerifierProvider) (signer.VerifierProvider, error)
 
 func (ko *KMSVerifierProviderOptions) AddFlags(cmd *cobra.Command) {
+	*ko = KMSVerifierProviderOptions{}
 	kmsProviderOpts := kms.ProviderOptions()
 	for k := range kmsProviderOpts {
-		if kmsProviderOpts[k] != nil {
-			*ko = addFlags("verifier", kmsProviderOpts[k].ProviderName(), kmsProviderOpts[k].Init(), *ko, cmd)
+		if kmsProviderOpts[k] == nil {
+			continue
+		}
+
+		// the backends describe their options against the signer provider, which the kms verifier provider also is
+		name := kmsProviderOpts[k].ProviderName()
+		setters := addFlags("verifier", name, kmsProviderOpts[k].Init(), map[string][]func(signer.SignerProvider) (signer.SignerProvider, error){}, cmd)
+		for _, setter := range setters[name] {
+			(*ko)[name] = append((*ko)[name], verifierProviderSetter(setter))
+		}
+	}
+}
+
+// verifierProviderSetter lets a setter of a KMS backend option be applied to the kms verifier provider
+func verifierProviderSetter(setter func(signer.SignerProvider) (signer.SignerProvider, error)) func(signer.VerifierProvider) (signer.VerifierProvider, error) {
+	return func(vp signer.VerifierProvider) (signer.VerifierProvider, error) {
+		sp, ok := vp.(signer.SignerProvider)
+		if !ok {
+			return vp, fmt.Errorf("provided verifier provider is not a kms provider")
+		}
+
+		sp, err := setter(sp)
+		if err != nil {
+			return vp, err
 		}
+
+		vp, ok = sp.(signer.VerifierProvider)
+		if !ok {
+			return nil, fmt.Errorf("provided verifier provider is not a kms provider")
+		}
+
+		return vp, nil
 	}
 }
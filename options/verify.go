@@ -15,6 +15,9 @@
 package options
 
 import (
+	"time"
+
+	"github.com/in-toto/witness/internal/trust"
 	"github.com/sigstore/fulcio/pkg/certificate"
 	"github.com/spf13/cobra"
 )
@@ -23,11 +26,17 @@ type VerifyOptions struct {
 	ArchivistaOptions          ArchivistaOptions
 	VerifierOptions            VerifierOptions
 	KMSVerifierProviderOptions KMSVerifierProviderOptions
+	VerifierStrict             bool
+	SignerOptions              SignerOptions
+	KMSSignerProviderOptions   KMSSignerProviderOptions
+	SignerStrict               bool
 	KeyPath                    string
 	AttestationFilePaths       []string
 	PolicyFilePath             string
+	PolicyPayloadPath          string
 	ArtifactFilePath           string
 	ArtifactDirectoryPath      string
+	ImagePath                  string
 	AdditionalSubjects         []string
 	PolicyFulcioCertExtensions certificate.Extensions
 	PolicyCARootPaths          []string
@@ -38,6 +47,19 @@ type VerifyOptions struct {
 	PolicyEmails               []string
 	PolicyOrganizations        []string
 	PolicyURIs                 []string
+	PolicyIdentityRegexp       trust.IdentityRegexp
+	CacheDir                   string
+	CacheTTL                   time.Duration
+	OutFilePath                string
+	TimestampServers           []string
+	VerifyTime                 time.Time
+	VerifyTimeFromTimestamps   bool
+	CRLPaths                   []string
+	OCSPResponder              string
+	RevocationStrict           bool
+	TrustConfigPath            string
+	TrustProfile               string
+	TrustedRootPath            string
 }
 
 var RequiredVerifyFlags = []string{
@@ -50,37 +72,60 @@ var OneRequiredPKVerifyFlags = []string{
 	"policy-ca-roots",
 	"policy-ca-intermediates",
 	"verifier-kms-ref",
+	"trust-config",
+	"trusted-root",
 }
 
 var OneRequiredSubjectFlags = []string{
 	"artifactfile",
 	"subjects",
+	"image",
 }
 
 func (vo *VerifyOptions) AddFlags(cmd *cobra.Command) {
 	vo.VerifierOptions.AddFlags(cmd)
 	vo.ArchivistaOptions.AddFlags(cmd)
 	vo.KMSVerifierProviderOptions.AddFlags(cmd)
+	addStrictFlag("verifier", &vo.VerifierStrict, cmd)
 	cmd.Flags().StringVarP(&vo.KeyPath, "publickey", "k", "", "Path to the policy signer's public key")
-	cmd.Flags().StringSliceVarP(&vo.AttestationFilePaths, "attestations", "a", []string{}, "Attestation files to test against the policy")
-	cmd.Flags().StringVarP(&vo.PolicyFilePath, "policy", "p", "", "Path to the policy to verify")
+	cmd.Flags().StringSliceVarP(&vo.AttestationFilePaths, "attestations", "a", []string{}, "Attestation files to test against the policy. Use - to read one from stdin")
+	cmd.Flags().StringVarP(&vo.PolicyFilePath, "policy", "p", "", "Path to the policy to verify, or - to read it from stdin")
+	cmd.Flags().StringVar(&vo.PolicyPayloadPath, "policy-payload", "", "Path to the payload of a policy signed with --detached. Defaults to the policy path without its .sig extension")
 	cmd.Flags().StringVarP(&vo.ArtifactFilePath, "artifactfile", "f", "", "Path to the artifact subject to verify")
 	cmd.Flags().StringVarP(&vo.ArtifactDirectoryPath, "directory-path", "", "", "Path to the directory subject to verify")
+	cmd.Flags().StringVar(&vo.ImagePath, "image", "", "Path to a container image subject to verify, either an OCI image layout directory or a tarball created by docker save")
 	cmd.Flags().StringSliceVarP(&vo.AdditionalSubjects, "subjects", "s", []string{}, "Additional subjects to lookup attestations")
 	cmd.Flags().StringSliceVarP(&vo.PolicyCARootPaths, "policy-ca-roots", "", []string{}, "Paths to CA root certificates to use for verifying a policy signed with x.509")
 	cmd.Flags().StringSliceVarP(&vo.PolicyCAIntermediatePaths, "policy-ca-intermediates", "", []string{}, "Paths to CA intermediate certificates to use for verifying a policy signed with x.509")
+	cmd.Flags().StringVar(&vo.TrustConfigPath, "trust-config", "", "Path to a YAML or JSON file of trust profiles describing the keys, CAs, timestamp authorities and identities trusted to sign the policy")
+	cmd.Flags().StringVar(&vo.TrustProfile, "trust-profile", "", "Name of the profile to use from --trust-config. May be omitted if the file only has one profile")
+	cmd.Flags().StringVar(&vo.TrustedRootPath, "trusted-root", "", "Path to a Sigstore trusted_root.json whose certificate and timestamp authorities are trusted to sign the policy")
 	cmd.Flags().StringSliceVarP(&vo.PolicyTimestampServers, "policy-timestamp-servers", "", []string{}, "Paths to the CA certificates for Timestamp Authority Servers to use when verifying policy signed with x.509")
 	cmd.Flags().StringVar(&vo.PolicyCommonName, "policy-commonname", "*", "The common name to use when verifying a policy signed with x.509")
 	cmd.Flags().StringSliceVar(&vo.PolicyDNSNames, "policy-dns-names", []string{"*"}, "The DNS names to use when verifying a policy signed with x.509")
 	cmd.Flags().StringSliceVar(&vo.PolicyEmails, "policy-emails", []string{"*"}, "The DNS names to use when verifying a policy signed with x.509")
 	cmd.Flags().StringSliceVar(&vo.PolicyOrganizations, "policy-organizations", []string{"*"}, "The organizations to use when verifying a policy signed with x.509")
 	cmd.Flags().StringSliceVar(&vo.PolicyURIs, "policy-uris", []string{"*"}, "The URIs to use when verifying a policy signed with x.509")
+	cmd.Flags().Var(newRegexpValue(&vo.PolicyIdentityRegexp.CommonName), "policy-commonname-regexp", "Regular expression the common name must match when verifying a policy signed with x.509")
+	cmd.Flags().Var(newRegexpValue(&vo.PolicyIdentityRegexp.DNSNames), "policy-dns-names-regexp", "Regular expression every DNS name must match when verifying a policy signed with x.509")
+	cmd.Flags().Var(newRegexpValue(&vo.PolicyIdentityRegexp.Emails), "policy-emails-regexp", "Regular expression every email must match when verifying a policy signed with x.509")
+	cmd.Flags().Var(newRegexpValue(&vo.PolicyIdentityRegexp.Organizations), "policy-organizations-regexp", "Regular expression every organization must match when verifying a policy signed with x.509")
+	cmd.Flags().Var(newRegexpValue(&vo.PolicyIdentityRegexp.URIs), "policy-uris-regexp", "Regular expression every URI must match when verifying a policy signed with x.509")
+	cmd.Flags().StringVarP(&vo.OutFilePath, "outfile", "o", "", "File to write the signed verification summary to when a signer is provided. Defaults to stdout, which - also selects")
+	cmd.Flags().StringSliceVar(&vo.TimestampServers, "timestamp-servers", []string{}, "Timestamp Authority Servers to use when signing the verification summary")
+	cmd.Flags().TimeVar(&vo.VerifyTime, "verify-time", time.Time{}, []string{time.RFC3339}, "Point in time (RFC3339) to evaluate the policy expiry, the policy signer and functionary certificates and revocation at instead of the current time. The policy must also not have expired by the current time")
+	cmd.Flags().BoolVar(&vo.VerifyTimeFromTimestamps, "verify-time-from-timestamps", false, "Use the time the attestations passed with --attestations were produced as the verification time, taken from their timestamps as verified by the policy's timestamp authorities")
+	cmd.Flags().StringSliceVar(&vo.CRLPaths, "crl", []string{}, "Paths to PEM or DER encoded certificate revocation lists to check the policy signer and functionary certificate chains against")
+	cmd.Flags().StringVar(&vo.OCSPResponder, "ocsp-responder", "", "URL of an OCSP responder to check the policy signer and functionary certificate chains against")
+	cmd.Flags().BoolVar(&vo.RevocationStrict, "revocation-strict", false, "Fail verification when the revocation status of a certificate can't be determined, such as when the OCSP responder is unreachable or no CRL or OCSP responder covers it, instead of only warning")
+	cmd.Flags().StringVar(&vo.CacheDir, "cache-dir", "", "Directory to cache successful verification results in. Caching is disabled if empty, and skipped with a warning when signing a verification summary or using --ocsp-responder")
+	cmd.Flags().DurationVar(&vo.CacheTTL, "cache-ttl", time.Hour, "How long a cached verification result may be reused. Results are never reused past the expiry of the policy or its trusted certificates")
 	cmd.Flags().StringSliceVarP(&vo.PolicyCARootPaths, "policy-ca", "", []string{}, "Paths to CA certificates to use for verifying the policy (deprecated: use --policy-ca-roots instead)")
 
 	// -- Fulcio Cert extensions begin --
 	// Source: https://github.com/sigstore/fulcio/blob/main/docs/oid-info.md
 	cmd.Flags().StringVar(&vo.PolicyFulcioCertExtensions.Issuer, "policy-fulcio-oidc-issuer", "",
-		"The OIDC issuer expected in a valid Fulcio certificate, e.g. https://token.actions.githubusercontent.com or https://oauth2.sigstore.dev/auth. Either --certificate-oidc-issuer or --certificate-oidc-issuer-regexp must be set for keyless flows.")
+		"The OIDC issuer expected in a valid Fulcio certificate, e.g. https://token.actions.githubusercontent.com or https://oauth2.sigstore.dev/auth. Either --policy-fulcio-oidc-issuer or --policy-fulcio-oidc-issuer-regexp should be set for keyless flows.")
 
 	cmd.Flags().StringVar(&vo.PolicyFulcioCertExtensions.BuildTrigger, "policy-fulcio-build-trigger", "",
 		"Event or action that initiated the build.")
@@ -96,9 +141,32 @@ func (vo *VerifyOptions) AddFlags(cmd *cobra.Command) {
 
 	cmd.Flags().StringVar(&vo.PolicyFulcioCertExtensions.SourceRepositoryRef, "policy-fulcio-source-repository-ref", "",
 		"Source Repository Ref that the build run was based upon.")
+	cmd.Flags().Var(newRegexpValue(&vo.PolicyIdentityRegexp.Fulcio.Issuer), "policy-fulcio-oidc-issuer-regexp",
+		"Regular expression the OIDC issuer in a valid Fulcio certificate must match.")
+
+	cmd.Flags().Var(newRegexpValue(&vo.PolicyIdentityRegexp.Fulcio.BuildTrigger), "policy-fulcio-build-trigger-regexp",
+		"Regular expression the event or action that initiated the build must match.")
+
+	cmd.Flags().Var(newRegexpValue(&vo.PolicyIdentityRegexp.Fulcio.SourceRepositoryDigest), "policy-fulcio-source-repository-digest-regexp",
+		"Regular expression the source repository digest must match.")
+
+	cmd.Flags().Var(newRegexpValue(&vo.PolicyIdentityRegexp.Fulcio.RunInvocationURI), "policy-fulcio-run-invocation-uri-regexp",
+		"Regular expression the run invocation URL must match.")
+
+	cmd.Flags().Var(newRegexpValue(&vo.PolicyIdentityRegexp.Fulcio.SourceRepositoryIdentifier), "policy-fulcio-source-repository-identifier-regexp",
+		"Regular expression the source repository identifier must match.")
+
+	cmd.Flags().Var(newRegexpValue(&vo.PolicyIdentityRegexp.Fulcio.SourceRepositoryRef), "policy-fulcio-source-repository-ref-regexp",
+		"Regular expression the source repository ref must match, e.g. ^refs/tags/v.")
 	// -- Fulcio Cert extensions end --
 
+	// Signers are optional and only used to sign a summary of the verification
+	vo.SignerOptions.AddFlags(cmd)
+	vo.KMSSignerProviderOptions.AddFlags(cmd)
+	addStrictFlag("signer", &vo.SignerStrict, cmd)
+
 	cmd.MarkFlagsRequiredTogether(RequiredVerifyFlags...)
 	cmd.MarkFlagsOneRequired(OneRequiredPKVerifyFlags...)
 	cmd.MarkFlagsOneRequired(OneRequiredSubjectFlags...)
+	cmd.MarkFlagsMutuallyExclusive("verify-time", "verify-time-from-timestamps")
 }
@@ -19,6 +19,7 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // Test VerifyOptions.AddFlags method
@@ -30,6 +31,8 @@ func TestVerifyOptions_AddFlags(t *testing.T) {
 	vo := VerifyOptions{
 		VerifierOptions:            make(VerifierOptions),
 		KMSVerifierProviderOptions: make(KMSVerifierProviderOptions),
+		SignerOptions:              make(SignerOptions),
+		KMSSignerProviderOptions:   make(KMSSignerProviderOptions),
 		ArchivistaOptions:          ArchivistaOptions{},
 	}
 
@@ -43,6 +46,7 @@ func TestVerifyOptions_AddFlags(t *testing.T) {
 		"artifactfile",
 		"directory-path",
 		"subjects",
+		"image",
 		"publickey",
 		"policy-ca", // deprecated but should still be added
 		"policy-ca-roots",
@@ -59,6 +63,30 @@ func TestVerifyOptions_AddFlags(t *testing.T) {
 		"policy-fulcio-run-invocation-uri",
 		"policy-fulcio-source-repository-identifier",
 		"policy-fulcio-source-repository-ref",
+		"policy-commonname-regexp",
+		"policy-dns-names-regexp",
+		"policy-emails-regexp",
+		"policy-organizations-regexp",
+		"policy-uris-regexp",
+		"policy-fulcio-oidc-issuer-regexp",
+		"policy-fulcio-build-trigger-regexp",
+		"policy-fulcio-source-repository-digest-regexp",
+		"policy-fulcio-run-invocation-uri-regexp",
+		"policy-fulcio-source-repository-identifier-regexp",
+		"policy-fulcio-source-repository-ref-regexp",
+		"trust-config",
+		"trust-profile",
+		"trusted-root",
+		"crl",
+		"ocsp-responder",
+		"cache-dir",
+		"cache-ttl",
+		"outfile",
+		"timestamp-servers",
+		"verify-time",
+		"verify-time-from-timestamps",
+		"verifier-strict",
+		"signer-strict",
 	}
 
 	for _, name := range flags {
@@ -66,6 +94,9 @@ func TestVerifyOptions_AddFlags(t *testing.T) {
 		assert.NotNil(t, flag, "Flag '%s' should be added", name)
 	}
 
+	// The signer key path must not steal the -k shorthand from the policy public key
+	assert.Equal(t, "publickey", cmd.Flags().ShorthandLookup("k").Name)
+
 	// Test some of the flag defaults
 	assert.Equal(t, "[]", cmd.Flags().Lookup("policy-ca-roots").DefValue, "Default policy-ca-roots should be empty array")
 	assert.Equal(t, "[]", cmd.Flags().Lookup("policy-ca-intermediates").DefValue, "Default policy-ca-intermediates should be empty array")
@@ -75,3 +106,19 @@ func TestVerifyOptions_AddFlags(t *testing.T) {
 	assert.Contains(t, OneRequiredPKVerifyFlags, "publickey", "One required flags should include publickey")
 	assert.Contains(t, OneRequiredSubjectFlags, "artifactfile", "One required subject flags should include artifactfile")
 }
+
+func TestVerifyOptions_RegexpFlags(t *testing.T) {
+	cmd := &cobra.Command{
+		Use: "test",
+	}
+
+	vo := VerifyOptions{}
+	vo.AddFlags(cmd)
+
+	// expressions are validated as the flags are parsed
+	require.NoError(t, cmd.Flags().Parse([]string{"--policy-uris-regexp", "^https://github\\.com/in-toto/", "--policy-fulcio-source-repository-ref-regexp", "^refs/tags/"}))
+	assert.Equal(t, "^https://github\\.com/in-toto/", vo.PolicyIdentityRegexp.URIs)
+	assert.Equal(t, "^refs/tags/", vo.PolicyIdentityRegexp.Fulcio.SourceRepositoryRef)
+
+	require.ErrorContains(t, cmd.Flags().Parse([]string{"--policy-emails-regexp", "["}), "invalid regular expression")
+}